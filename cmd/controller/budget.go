@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+)
+
+//departmentKeyPrefix prefixes the department keys of the workqueue. Team keys are team names, which cannot contain a slash
+const departmentKeyPrefix = "department/"
+
+//budgetAllocation is the share of a parent budget given to each of its child teams.
+//Children are served in creation order: a child whose quotas do not fit in the remaining budget is over budget
+//and keeps the hard limits currently applied to its resourcequotas
+type budgetAllocation struct {
+	budget corev1.ResourceList
+	status aftouhv1.BudgetStatus
+	//exceeded lists the budgeted resources that do not fit, by over budget child
+	exceeded map[string][]corev1.ResourceName
+}
+
+//isOverBudget returns whether the team quotas do not fit in the budget
+func (a *budgetAllocation) isOverBudget(t *aftouhv1.Team) bool {
+	_, ok := a.exceeded[t.Name]
+	return ok
+}
+
+//getParentBudget returns the budget of the team or department
+func (tc *TeamController) getParentBudget(parent aftouhv1.TeamParent) (corev1.ResourceList, error) {
+	switch parent.Kind {
+	case aftouhv1.ParentKindTeam:
+		t, err := tc.tLister.Get(parent.Name)
+		if err != nil {
+			return nil, err
+		}
+		return t.Spec.Budget, nil
+	case aftouhv1.ParentKindDepartment:
+		d, err := tc.dLister.Get(parent.Name)
+		if err != nil {
+			return nil, err
+		}
+		return d.Spec.Budget, nil
+	}
+	return nil, fmt.Errorf("unknown parent kind %q", parent.Kind)
+}
+
+//getTeamAllocation returns the allocation of the budget of the team parent, or nil if the team has no parent.
+//A missing parent returns a not found error
+func (tc *TeamController) getTeamAllocation(t *aftouhv1.Team) (*budgetAllocation, error) {
+	if t.Spec.Parent == nil {
+		return nil, nil
+	}
+	budget, err := tc.getParentBudget(*t.Spec.Parent)
+	if err != nil {
+		return nil, err
+	}
+	return tc.allocateBudget(*t.Spec.Parent, budget)
+}
+
+//allocateBudget shares the budget between the child teams of the parent
+func (tc *TeamController) allocateBudget(parent aftouhv1.TeamParent, budget corev1.ResourceList) (*budgetAllocation, error) {
+	children, err := tc.getChildTeams(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &budgetAllocation{
+		budget:   budget,
+		exceeded: make(map[string][]corev1.ResourceName),
+	}
+	allocated := corev1.ResourceList{}
+	for _, child := range children {
+		a.status.Children = append(a.status.Children, child.Name)
+
+		requested := tc.getRequestedQuota(child, budget)
+		exceeded := exceededResources(budget, allocated, requested)
+		if len(exceeded) == 0 {
+			addResources(allocated, requested)
+			continue
+		}
+
+		a.exceeded[child.Name] = exceeded
+		a.status.OverBudget = append(a.status.OverBudget, child.Name)
+		applied, err := tc.getAppliedQuota(child, budget)
+		if err != nil {
+			return nil, err
+		}
+		addResources(allocated, applied)
+	}
+	if len(allocated) > 0 {
+		a.status.Allocated = allocated
+	}
+	return a, nil
+}
+
+//getChildTeams returns the teams of the parent that are not being deleted, oldest first
+func (tc *TeamController) getChildTeams(parent aftouhv1.TeamParent) ([]*aftouhv1.Team, error) {
+	teams, err := tc.tLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var children []*aftouhv1.Team
+	for _, t := range teams {
+		if t.Spec.Parent != nil && *t.Spec.Parent == parent && t.DeletionTimestamp == nil {
+			children = append(children, t)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		ci, cj := children[i].CreationTimestamp, children[j].CreationTimestamp
+		if !ci.Equal(&cj) {
+			return ci.Before(&cj)
+		}
+		return children[i].Name < children[j].Name
+	})
+	return children, nil
+}
+
+//getRequestedQuota returns the sum of the hard limits of the budgeted resources in the resourcequotas of the team
+func (tc *TeamController) getRequestedQuota(t *aftouhv1.Team, budget corev1.ResourceList) corev1.ResourceList {
+	t = t.DeepCopy()
+	tc.defaults.SetDefaults(t)
+	//A missing class is reported on the child team, which then only requests its own quotas
+	class, _ := tc.getTeamClass(t)
+
+	requested := corev1.ResourceList{}
+	for _, env := range getTeamEnvironments(t) {
+		for _, rq := range newResourceQuotas(t, env, "", class) {
+			addResources(requested, budgetedResources(rq.Spec.Hard, budget))
+		}
+	}
+	return requested
+}
+
+//getAppliedQuota returns the sum of the hard limits of the budgeted resources in the resourcequotas owned by the team
+func (tc *TeamController) getAppliedQuota(t *aftouhv1.Team, budget corev1.ResourceList) (corev1.ResourceList, error) {
+	rqs, err := tc.rqLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	applied := corev1.ResourceList{}
+	for _, rq := range rqs {
+		if metav1.IsControlledBy(rq, t) {
+			addResources(applied, budgetedResources(rq.Spec.Hard, budget))
+		}
+	}
+	return applied, nil
+}
+
+//budgetedResources returns the resources of the list that are part of the budget
+func budgetedResources(rl, budget corev1.ResourceList) corev1.ResourceList {
+	budgeted := corev1.ResourceList{}
+	for name, q := range rl {
+		if _, ok := budget[name]; ok {
+			budgeted[name] = q
+		}
+	}
+	return budgeted
+}
+
+//addResources adds the quantities of rl to total
+func addResources(total, rl corev1.ResourceList) {
+	for name, q := range rl {
+		sum := total[name]
+		sum.Add(q)
+		total[name] = sum
+	}
+}
+
+//exceededResources returns the budgeted resources whose requested quantity does not fit in the remaining budget, sorted by name
+func exceededResources(budget, allocated, requested corev1.ResourceList) []corev1.ResourceName {
+	var exceeded []corev1.ResourceName
+	for name, q := range requested {
+		sum := allocated[name]
+		sum.Add(q)
+		if limit := budget[name]; sum.Cmp(limit) > 0 {
+			exceeded = append(exceeded, name)
+		}
+	}
+	sort.Slice(exceeded, func(i, j int) bool { return exceeded[i] < exceeded[j] })
+	return exceeded
+}
+
+//capResourceQuota keeps the budgeted hard limits of an over budget team from growing.
+//A budgeted resource the current resourcequota does not limit yet is limited to zero
+func capResourceQuota(expected, current *corev1.ResourceQuota, budget corev1.ResourceList) *corev1.ResourceQuota {
+	var currentHard corev1.ResourceList
+	if current != nil {
+		currentHard = current.Spec.Hard
+	}
+
+	capped := expected.DeepCopy()
+	for name, q := range capped.Spec.Hard {
+		if _, ok := budget[name]; !ok {
+			continue
+		}
+		applied, ok := currentHard[name]
+		switch {
+		case !ok:
+			capped.Spec.Hard[name] = *resource.NewQuantity(0, q.Format)
+		case applied.Cmp(q) < 0:
+			capped.Spec.Hard[name] = applied.DeepCopy()
+		}
+	}
+	return capped
+}
+
+//getOverBudgetCondition returns the OverBudget condition of the team
+func getOverBudgetCondition(t *aftouhv1.Team, allocation *budgetAllocation, allocationErr error, now metav1.Time) aftouhv1.TeamCondition {
+	cond := newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionFalse, "", "", now)
+	switch {
+	case t.Spec.Parent == nil:
+	case errors.IsNotFound(allocationErr):
+		cond.Reason = reasonParentNotFound
+		cond.Message = fmt.Sprintf("%s %q not found, the team quotas are not capped", t.Spec.Parent.Kind, t.Spec.Parent.Name)
+	case allocationErr != nil:
+		cond.Status, cond.Reason, cond.Message = corev1.ConditionUnknown, reasonSyncFailed, allocationErr.Error()
+	case allocation.isOverBudget(t):
+		var names []string
+		for _, name := range allocation.exceeded[t.Name] {
+			names = append(names, string(name))
+		}
+		cond.Status, cond.Reason = corev1.ConditionTrue, reasonBudgetExceeded
+		cond.Message = fmt.Sprintf("Quotas of %s exceed the remaining budget of %s %q", strings.Join(names, ", "), t.Spec.Parent.Kind, t.Spec.Parent.Name)
+	default:
+		cond.Reason = reasonWithinBudget
+	}
+	return cond
+}
+
+//getTeamBudgetStatus returns the allocation of the team budget to its child teams, or nil if the team has neither budget nor children
+func (tc *TeamController) getTeamBudgetStatus(t *aftouhv1.Team) (*aftouhv1.BudgetStatus, error) {
+	a, err := tc.allocateBudget(aftouhv1.TeamParent{Kind: aftouhv1.ParentKindTeam, Name: t.Name}, t.Spec.Budget)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Spec.Budget) == 0 && len(a.status.Children) == 0 {
+		return nil, nil
+	}
+	return &a.status, nil
+}
+
+//syncDepartment updates the department status with the allocation of its budget
+func (tc *TeamController) syncDepartment(name string) error {
+	d, err := tc.dLister.Get(name)
+	switch {
+	case errors.IsNotFound(err):
+		klog.V(4).Infof("Department %v has been deleted", name)
+		return nil
+	case err != nil:
+		return fmt.Errorf("Unable to retrieve department %v from store: %v", name, err)
+	}
+
+	a, err := tc.allocateBudget(aftouhv1.TeamParent{Kind: aftouhv1.ParentKindDepartment, Name: d.Name}, d.Spec.Budget)
+	if err != nil {
+		return err
+	}
+	status := aftouhv1.DepartmentStatus{ObservedGeneration: d.Generation, Budget: a.status}
+	if equality.Semantic.DeepEqual(d.Status, status) {
+		return nil
+	}
+
+	d = d.DeepCopy()
+	d.Status = status
+	if _, err := tc.tClientSet.AftouhV1().Departments().UpdateStatus(d); err != nil {
+		return fmt.Errorf("Failed updating department status: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/diff"
+	core "k8s.io/client-go/testing"
+)
+
+var testDepartment = aftouhv1.TeamParent{Kind: aftouhv1.ParentKindDepartment, Name: "engineering"}
+
+// newChildTeam returns a team of the engineering department requesting cpu in its default resourcequota.
+// Teams are created one minute apart in the order of their age
+func newChildTeam(name, cpu string, age int) *aftouhv1.Team {
+	team := newTeam(name, "", "dev", corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+		corev1.ResourceRequestsCPU: resource.MustParse(cpu),
+		corev1.ResourcePods:        resource.MustParse("10"),
+	}})
+	team.UID = types.UID(name)
+	team.CreationTimestamp = metav1.NewTime(testTime.Add(time.Duration(age) * time.Minute))
+	parent := testDepartment
+	team.Spec.Parent = &parent
+	return team
+}
+
+// newBudgetFixture returns a fixture of the engineering department with a budget of 4 cpus shared by:
+// "first" requesting 2 cpus, "over" requesting 3 cpus with 1 cpu currently applied and "last" requesting 1 cpu
+func newBudgetFixture(t *testing.T) (*fixture, *aftouhv1.Team) {
+	f := newFixture(t)
+	f.addObj(&aftouhv1.Department{
+		ObjectMeta: metav1.ObjectMeta{Name: "engineering", Generation: 1},
+		Spec:       aftouhv1.DepartmentSpec{Budget: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")}},
+	})
+	f.addObj(newChildTeam("first", "2", 0))
+	f.addObj(newChildTeam("last", "1", 2))
+
+	over := newChildTeam("over", "3", 1)
+	f.addObj(over)
+	ns := newNamespace(over, "dev", defaultTeamNamespace(over, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	rq := newResourceQuotas(over, getTeamEnvironments(over)[0], ns.Name, nil)[0]
+	rq.Spec.Hard[corev1.ResourceRequestsCPU] = resource.MustParse("1")
+	f.addObj(rq)
+
+	return f, over
+}
+
+func TestAllocateBudget(t *testing.T) {
+	f, _ := newBudgetFixture(t)
+	tc, _, _ := f.newTeamController()
+
+	a, err := tc.allocateBudget(testDepartment, corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")})
+	if err != nil {
+		t.Fatalf("failed allocating budget: %v", err)
+	}
+
+	//The over budget team counts its applied quota, which leaves room for the last team
+	expected := aftouhv1.BudgetStatus{
+		Allocated:  corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")},
+		Children:   []string{"first", "over", "last"},
+		OverBudget: []string{"over"},
+	}
+	if !equality.Semantic.DeepEqual(a.status, expected) {
+		t.Errorf("unexpected allocation\nDiff:\n %s", diff.ObjectGoPrintSideBySide(expected, a.status))
+	}
+	if exceeded := a.exceeded["over"]; len(exceeded) != 1 || exceeded[0] != corev1.ResourceRequestsCPU {
+		t.Errorf("expected team over to exceed the requests.cpu budget, got %v", exceeded)
+	}
+}
+
+func TestOverBudgetTeam(t *testing.T) {
+	f, team := newBudgetFixture(t)
+	team.Status = readyStatus(team)
+
+	//The resourcequota keeps its applied cpu limit and is not updated
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Conditions[8] = newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionTrue, reasonBudgetExceeded,
+		`Quotas of requests.cpu exceed the remaining budget of Department "engineering"`, testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestCapResourceQuota(t *testing.T) {
+	budget := corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4"), corev1.ResourceRequestsMemory: resource.MustParse("8Gi")}
+	expected := &corev1.ResourceQuota{Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+		corev1.ResourceRequestsCPU:    resource.MustParse("2"),
+		corev1.ResourceRequestsMemory: resource.MustParse("4Gi"),
+		corev1.ResourcePods:           resource.MustParse("10"),
+	}}}
+	current := &corev1.ResourceQuota{Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+		corev1.ResourceRequestsCPU:    resource.MustParse("1"),
+		corev1.ResourceRequestsMemory: resource.MustParse("6Gi"),
+	}}}
+
+	//Budgeted limits are not raised, lowered ones and unbudgeted resources are applied
+	capped := capResourceQuota(expected, current, budget)
+	want := corev1.ResourceList{
+		corev1.ResourceRequestsCPU:    resource.MustParse("1"),
+		corev1.ResourceRequestsMemory: resource.MustParse("4Gi"),
+		corev1.ResourcePods:           resource.MustParse("10"),
+	}
+	if !equality.Semantic.DeepEqual(capped.Spec.Hard, want) {
+		t.Errorf("expected hard limits %v, got %v", want, capped.Spec.Hard)
+	}
+
+	//A new resourcequota gets no budgeted resources
+	capped = capResourceQuota(expected, nil, budget)
+	if q := capped.Spec.Hard[corev1.ResourceRequestsCPU]; !q.IsZero() {
+		t.Errorf("expected requests.cpu limited to zero, got %s", q.String())
+	}
+}
+
+func TestMissingParent(t *testing.T) {
+	team := newChildTeam("orphan", "1", 0)
+	tc, _, _ := newFixture(t).newTeamController()
+	_, err := tc.getTeamAllocation(team)
+	cond := getOverBudgetCondition(team, nil, err, testTime)
+	if cond.Status != corev1.ConditionFalse || cond.Reason != reasonParentNotFound {
+		t.Errorf("expected missing parent not to cap the team, got %+v", cond)
+	}
+}
+
+func TestSyncDepartment(t *testing.T) {
+	f, _ := newBudgetFixture(t)
+	tc, _, _ := f.newTeamController()
+
+	if err := tc.syncHandler(departmentKeyPrefix + "engineering"); err != nil {
+		t.Fatalf("failed syncing department: %v", err)
+	}
+
+	actions := f.tClientSet.Actions()
+	if len(actions) != 1 || !actions[0].Matches("update", "departments") || actions[0].GetSubresource() != "status" {
+		t.Fatalf("expected department status update, got %+v", actions)
+	}
+	d := actions[0].(core.UpdateAction).GetObject().(*aftouhv1.Department)
+	if d.Status.ObservedGeneration != 1 || !equality.Semantic.DeepEqual(d.Status.Budget.OverBudget, []string{"over"}) {
+		t.Errorf("unexpected department status %+v", d.Status)
+	}
+}
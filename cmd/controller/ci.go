@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+const (
+	//ciName is the name of the ci service account
+	ciName      = "team-ci"
+	ciTokenName = "team-ci-token"
+	//ciRoleBindingName is the name of the ci service account rolebinding. It does not start with rbPrefix so that
+	//it cannot be the rolebinding of a member role
+	ciRoleBindingName = "ci-team-ci"
+
+	kindRole        = "Role"
+	kindClusterRole = "ClusterRole"
+)
+
+//ciConfig configures the deploy service account created in every team namespace for the team pipelines
+type ciConfig struct {
+	//roleRef is the role bound to the service account. No service account is created without role
+	roleRef *rbacv1.RoleRef
+	//token creates a long-lived token secret for the service account
+	token bool
+}
+
+//parseRoleRef parses a role of the -ci-role flag, as Role/<name>, ClusterRole/<name> or <name> for a Role
+func parseRoleRef(s string) (*rbacv1.RoleRef, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	kind, name := kindRole, s
+	if i := strings.Index(s, "/"); i >= 0 {
+		kind, name = s[:i], s[i+1:]
+	}
+	if kind != kindRole && kind != kindClusterRole {
+		return nil, fmt.Errorf("invalid role kind %q, expected %s or %s", kind, kindRole, kindClusterRole)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("missing role name in %q", s)
+	}
+	return &rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: kind, Name: name}, nil
+}
+
+//enabled returns true if the team namespaces get a ci service account
+func (c ciConfig) enabled() bool {
+	return c.roleRef != nil
+}
+
+//validateRole returns an error if the ci role is not one of the roles the controller is allowed to bind
+func (c ciConfig) validateRole(allowedRoles []string) error {
+	if !c.enabled() {
+		return nil
+	}
+	for _, role := range allowedRoles {
+		if role == c.roleRef.Name {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %q is not one of the allowed roles %s", c.roleRef.Kind, c.roleRef.Name, strings.Join(allowedRoles, ","))
+}
+
+//newServiceAccount returns the ci service account of a team environment, or nil if ci service accounts are disabled
+func (c ciConfig) newServiceAccount(t *aftouhv1.Team, env, namespace string, class *aftouhv1.TeamClass) *corev1.ServiceAccount {
+	if !c.enabled() {
+		return nil
+	}
+	return &corev1.ServiceAccount{ObjectMeta: newCIObjectMeta(t, env, namespace, ciName, class)}
+}
+
+//newRoleBinding returns the rolebinding of the ci service account, or nil if ci service accounts are disabled
+func (c ciConfig) newRoleBinding(t *aftouhv1.Team, env, namespace string, class *aftouhv1.TeamClass) *rbacv1.RoleBinding {
+	if !c.enabled() {
+		return nil
+	}
+	return &rbacv1.RoleBinding{
+		ObjectMeta: newCIObjectMeta(t, env, namespace, ciRoleBindingName, class),
+		RoleRef:    *c.roleRef,
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: ciName, Namespace: namespace}},
+	}
+}
+
+//newTokenSecret returns the long-lived token secret of the ci service account, or nil if it is disabled.
+//The token controller fills the secret data
+func (c ciConfig) newTokenSecret(t *aftouhv1.Team, env, namespace string, class *aftouhv1.TeamClass) *corev1.Secret {
+	if !c.enabled() || !c.token {
+		return nil
+	}
+	meta := newCIObjectMeta(t, env, namespace, ciTokenName, class)
+	meta.Annotations[corev1.ServiceAccountNameKey] = ciName
+	return &corev1.Secret{ObjectMeta: meta, Type: corev1.SecretTypeServiceAccountToken}
+}
+
+func newCIObjectMeta(t *aftouhv1.Team, env, namespace, name string, class *aftouhv1.TeamClass) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Labels:      getObjectLabels(t, env, class),
+		Annotations: getObjectAnnotations(t, env, class),
+		OwnerReferences: []metav1.OwnerReference{
+			*metav1.NewControllerRef(t, aftouhv1.SchemeGroupVersion.WithKind("Team")),
+		},
+	}
+}
+
+//syncCIServiceAccount creates or updates the ci service account of the namespace and its token secret.
+//They are deleted when disabled. The rolebinding of the service account is synced with the member ones
+func (tc *TeamController) syncCIServiceAccount(t *aftouhv1.Team, env, namespaceName string, class *aftouhv1.TeamClass) error {
+	if err := tc.syncServiceAccount(t, namespaceName, tc.ci.newServiceAccount(t, env, namespaceName, class)); err != nil {
+		return err
+	}
+	return tc.syncTokenSecret(t, namespaceName, tc.ci.newTokenSecret(t, env, namespaceName, class))
+}
+
+func (tc *TeamController) syncServiceAccount(t *aftouhv1.Team, namespaceName string, expectedSa *corev1.ServiceAccount) error {
+	sa, err := tc.saLister.ServiceAccounts(namespaceName).Get(ciName)
+	switch {
+	case errors.IsNotFound(err) && expectedSa == nil:
+		return nil
+	case errors.IsNotFound(err):
+		klog.V(2).Infof("Creating serviceaccount %s/%s", namespaceName, ciName)
+		_, err = tc.kClientSet.CoreV1().ServiceAccounts(namespaceName).Create(expectedSa)
+		return err
+	case err != nil:
+		return err
+	case !metav1.IsControlledBy(sa, t):
+		if expectedSa == nil {
+			return nil
+		}
+		msg := fmt.Sprintf(messageResourceExists, sa.Name)
+		tc.recorder.Event(t, corev1.EventTypeWarning, errResourceExists, msg)
+		return fmt.Errorf(msg)
+	case expectedSa == nil:
+		klog.V(2).Infof("Deleting serviceaccount %s/%s", namespaceName, sa.Name)
+		err = tc.kClientSet.CoreV1().ServiceAccounts(namespaceName).Delete(sa.Name, &metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	//Check of external modification
+	if missingLabels(sa, expectedSa.Labels) || missingAnnotations(sa, expectedSa.Annotations) {
+		sa = sa.DeepCopy()
+		mergeLabels(sa, expectedSa.Labels)
+		mergeAnnotations(sa, expectedSa.Annotations)
+		klog.V(2).Infof("Updating serviceaccount %s/%s", namespaceName, sa.Name)
+		_, err = tc.kClientSet.CoreV1().ServiceAccounts(namespaceName).Update(sa)
+	}
+	return err
+}
+
+func (tc *TeamController) syncTokenSecret(t *aftouhv1.Team, namespaceName string, expectedSecret *corev1.Secret) error {
+	secret, err := tc.secretLister.Secrets(namespaceName).Get(ciTokenName)
+	switch {
+	case errors.IsNotFound(err) && expectedSecret == nil:
+		return nil
+	case errors.IsNotFound(err):
+		klog.V(2).Infof("Creating secret %s/%s", namespaceName, ciTokenName)
+		_, err = tc.kClientSet.CoreV1().Secrets(namespaceName).Create(expectedSecret)
+		return err
+	case err != nil:
+		return err
+	case !metav1.IsControlledBy(secret, t):
+		if expectedSecret == nil {
+			return nil
+		}
+		msg := fmt.Sprintf(messageResourceExists, secret.Name)
+		tc.recorder.Event(t, corev1.EventTypeWarning, errResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+
+	//The secret type is immutable and a token of another service account must not be kept: the secret is recreated
+	if expectedSecret == nil || secret.Type != expectedSecret.Type ||
+		secret.Annotations[corev1.ServiceAccountNameKey] != expectedSecret.Annotations[corev1.ServiceAccountNameKey] {
+		klog.V(2).Infof("Deleting secret %s/%s", namespaceName, secret.Name)
+		if err := tc.kClientSet.CoreV1().Secrets(namespaceName).Delete(secret.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if expectedSecret == nil {
+			return nil
+		}
+		klog.V(2).Infof("Creating secret %s/%s", namespaceName, ciTokenName)
+		_, err = tc.kClientSet.CoreV1().Secrets(namespaceName).Create(expectedSecret)
+		return err
+	}
+
+	//Check of external modification
+	if missingLabels(secret, expectedSecret.Labels) || missingAnnotations(secret, expectedSecret.Annotations) {
+		secret = secret.DeepCopy()
+		mergeLabels(secret, expectedSecret.Labels)
+		mergeAnnotations(secret, expectedSecret.Annotations)
+		klog.V(2).Infof("Updating secret %s/%s", namespaceName, secret.Name)
+		_, err = tc.kClientSet.CoreV1().Secrets(namespaceName).Update(secret)
+	}
+	return err
+}
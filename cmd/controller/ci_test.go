@@ -0,0 +1,135 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+var testCIRole = &rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: kindRole, Name: "deployer"}
+
+// newCIFixture returns a fixture of a synced team whose controller creates ci service accounts bound to the deployer role
+func newCIFixture(t *testing.T, token bool) (*fixture, *aftouhv1.Team) {
+	f := newFixture(t)
+	f.ci = ciConfig{roleRef: testCIRole, token: token}
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	team.Status.CIServiceAccount = ciName
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], ns.Name, nil)[0])
+
+	return f, team
+}
+
+func TestParseRoleRef(t *testing.T) {
+	tests := map[string]*rbacv1.RoleRef{
+		"":                 nil,
+		"deployer":         testCIRole,
+		"Role/deployer":    testCIRole,
+		"ClusterRole/edit": {APIGroup: rbacv1.GroupName, Kind: kindClusterRole, Name: "edit"},
+	}
+	for s, expected := range tests {
+		ref, err := parseRoleRef(s)
+		if err != nil || !reflect.DeepEqual(ref, expected) {
+			t.Errorf("expected %q to be parsed as %+v, got %+v, %v", s, expected, ref, err)
+		}
+	}
+
+	for _, s := range []string{"Group/devs", "Role/"} {
+		if _, err := parseRoleRef(s); err == nil {
+			t.Errorf("expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestValidateCIRole(t *testing.T) {
+	allowed := []string{"admin", "edit", "view"}
+	if err := (ciConfig{}).validateRole(allowed); err != nil {
+		t.Errorf("expected disabled ci service account to be valid, got %v", err)
+	}
+	edit := ciConfig{roleRef: &rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: kindClusterRole, Name: "edit"}}
+	if err := edit.validateRole(allowed); err != nil {
+		t.Errorf("expected allowed ci role to be valid, got %v", err)
+	}
+	if err := (ciConfig{roleRef: testCIRole}).validateRole(allowed); err == nil {
+		t.Errorf("expected ci role %q missing from the allowed roles to be rejected", testCIRole.Name)
+	}
+}
+
+func TestCreateCIServiceAccount(t *testing.T) {
+	f, team := newCIFixture(t, true)
+	team.Status.CIServiceAccount = ""
+	ns := defaultTeamNamespace(team, "dev")
+
+	rb := f.ci.newRoleBinding(team, "dev", ns, nil)
+	if rb.Subjects[0] != (rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: ciName, Namespace: ns}) {
+		t.Errorf("expected the rolebinding to bind the ci service account, got %+v", rb.Subjects)
+	}
+	f.expectCreateRoleBindingAction(rb)
+	f.expectCreateAction("serviceaccounts", f.ci.newServiceAccount(team, "dev", ns, nil))
+	f.expectCreateAction("secrets", f.ci.newTokenSecret(team, "dev", ns, nil))
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.CIServiceAccount = ciName
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestRecreateCITokenSecret(t *testing.T) {
+	f, team := newCIFixture(t, true)
+	ns := defaultTeamNamespace(team, "dev")
+	f.addObj(f.ci.newRoleBinding(team, "dev", ns, nil))
+	f.addObj(f.ci.newServiceAccount(team, "dev", ns, nil))
+
+	//The token of another service account is replaced
+	secret := f.ci.newTokenSecret(team, "dev", ns, nil)
+	secret.Annotations[corev1.ServiceAccountNameKey] = "default"
+	f.addObj(secret)
+
+	f.expectDeleteAction("secrets", secret)
+	f.expectCreateAction("secrets", f.ci.newTokenSecret(team, "dev", ns, nil))
+
+	f.run(team.Name)
+}
+
+func TestDisableCIToken(t *testing.T) {
+	f, team := newCIFixture(t, false)
+	ns := defaultTeamNamespace(team, "dev")
+	f.addObj(f.ci.newRoleBinding(team, "dev", ns, nil))
+	f.addObj(f.ci.newServiceAccount(team, "dev", ns, nil))
+
+	secret := (&ciConfig{roleRef: testCIRole, token: true}).newTokenSecret(team, "dev", ns, nil)
+	f.addObj(secret)
+
+	f.expectDeleteAction("secrets", secret)
+
+	f.run(team.Name)
+}
+
+func TestCIServiceAccountWithCIMemberRole(t *testing.T) {
+	f, team := newCIFixture(t, false)
+	f.allowedMemberRoles = []string{"ci"}
+	ns := defaultTeamNamespace(team, "dev")
+	f.addObj(f.ci.newServiceAccount(team, "dev", ns, nil))
+
+	//The rolebinding of a ci member role does not replace the ci service account one
+	team.Spec.Members = []aftouhv1.TeamMember{{Kind: aftouhv1.MemberKindUser, Name: "alice", Role: "ci"}}
+	rbs := newRoleBindings(team, "dev", ns, nil)
+	rb := f.ci.newRoleBinding(team, "dev", ns, nil)
+	if _, ok := rbs[rb.Name]; ok {
+		t.Fatalf("expected the ci service account rolebinding %q not to be a member one", rb.Name)
+	}
+	f.expectCreateRoleBindingAction(rb)
+	f.expectCreateRoleBindingAction(rbs[rbPrefix+"ci"])
+
+	f.run(team.Name)
+}
@@ -3,27 +3,54 @@ package main
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"k8s.io/klog"
 
 	tclient "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned"
+	tinformers "github.com/aftouh/k8s-sample-controller/pkg/client/informers/externalversions"
 	tinformer "github.com/aftouh/k8s-sample-controller/pkg/client/informers/externalversions/team/v1"
 	tlister "github.com/aftouh/k8s-sample-controller/pkg/client/listers/team/v1"
 
 	aftouh "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
 
+	kinformers "k8s.io/client-go/informers"
+
 	//Core informers and listers
 	cinformer "k8s.io/client-go/informers/core/v1"
 	clister "k8s.io/client-go/listers/core/v1"
 
+	//Networking informers and listers
+	networkinginformer "k8s.io/client-go/informers/networking/v1"
+	networkinglister "k8s.io/client-go/listers/networking/v1"
+
+	//Rbac informers and listers
+	rbacinformer "k8s.io/client-go/informers/rbac/v1"
+	rbaclister "k8s.io/client-go/listers/rbac/v1"
+
+	//Apps informers and listers
+	appsinformer "k8s.io/client-go/informers/apps/v1"
+	appslister "k8s.io/client-go/listers/apps/v1"
+
+	//Autoscaling informers and listers
+	autoscalinginformer "k8s.io/client-go/informers/autoscaling/v1"
+	autoscalinglister "k8s.io/client-go/listers/autoscaling/v1"
+
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
@@ -42,69 +69,269 @@ type TeamController struct {
 	//kubernetes
 	kClientSet kubernetes.Interface
 
+	//metadata of any resource, to list the content of a namespace before deleting it
+	mClientSet metadata.Interface
+
 	//team
-	tClientSet    tclient.Interface
-	tLister       tlister.TeamLister
-	tListerSynced cache.InformerSynced
+	tClientSet tclient.Interface
+	tLister    tlister.TeamLister
+
+	//teamClass
+	tcLister tlister.TeamClassLister
+
+	//department
+	dLister tlister.DepartmentLister
 
 	//namespace
-	nLister       clister.NamespaceLister
-	nListerSynced cache.InformerSynced
+	nLister clister.NamespaceLister
 
 	//resourceQuota
-	rqLister       clister.ResourceQuotaLister
-	rqListerSynced cache.InformerSynced
+	rqLister clister.ResourceQuotaLister
+
+	//limitRange
+	lrLister clister.LimitRangeLister
+
+	//networkPolicy
+	npLister networkinglister.NetworkPolicyLister
+
+	//roleBinding
+	rbLister rbaclister.RoleBindingLister
+
+	//pod
+	podLister clister.PodLister
+
+	//serviceAccount
+	saLister clister.ServiceAccountLister
+
+	//secret
+	secretLister clister.SecretLister
+
+	//configMap
+	cmLister clister.ConfigMapLister
+
+	//service
+	svcLister clister.ServiceLister
+
+	//deployment
+	deployLister appslister.DeploymentLister
+
+	//statefulSet
+	ssLister appslister.StatefulSetLister
+
+	//horizontalPodAutoscaler
+	hpaLister autoscalinglister.HorizontalPodAutoscalerLister
+
+	//listersSynced are waited for before starting the workers, one per informer
+	listersSynced []cache.InformerSynced
 
 	//workqueue
 	queue workqueue.RateLimitingInterface
 
 	//kubernetes event recorder
 	recorder record.EventRecorder
+
+	//clock used to set team conditions transition time
+	clock clock.Clock
+
+	teamControllerConfig
 }
 
-//NewTeamController creates team controller
-func NewTeamController(tClientSet tclient.Interface,
-	kClientSet kubernetes.Interface,
-	tInformer tinformer.TeamInformer,
-	nInformer cinformer.NamespaceInformer,
-	rqInformer cinformer.ResourceQuotaInformer) *TeamController {
+//teamInformers are the informers of the resources the team controller syncs or watches
+type teamInformers struct {
+	teams           tinformer.TeamInformer
+	teamClasses     tinformer.TeamClassInformer
+	departments     tinformer.DepartmentInformer
+	namespaces      cinformer.NamespaceInformer
+	resourceQuotas  cinformer.ResourceQuotaInformer
+	limitRanges     cinformer.LimitRangeInformer
+	networkPolicies networkinginformer.NetworkPolicyInformer
+	roleBindings    rbacinformer.RoleBindingInformer
+	pods            cinformer.PodInformer
+	serviceAccounts cinformer.ServiceAccountInformer
+	secrets         cinformer.SecretInformer
+	configMaps      cinformer.ConfigMapInformer
+	services        cinformer.ServiceInformer
+	deployments     appsinformer.DeploymentInformer
+	statefulSets    appsinformer.StatefulSetInformer
+	autoscalers     autoscalinginformer.HorizontalPodAutoscalerInformer
+}
 
+//newTeamInformers returns the informers of the team controller from the shared informer factories
+func newTeamInformers(tFactory tinformers.SharedInformerFactory, kFactory kinformers.SharedInformerFactory) teamInformers {
+	return teamInformers{
+		teams:           tFactory.Aftouh().V1().Teams(),
+		teamClasses:     tFactory.Aftouh().V1().TeamClasses(),
+		departments:     tFactory.Aftouh().V1().Departments(),
+		namespaces:      kFactory.Core().V1().Namespaces(),
+		resourceQuotas:  kFactory.Core().V1().ResourceQuotas(),
+		limitRanges:     kFactory.Core().V1().LimitRanges(),
+		networkPolicies: kFactory.Networking().V1().NetworkPolicies(),
+		roleBindings:    kFactory.Rbac().V1().RoleBindings(),
+		pods:            kFactory.Core().V1().Pods(),
+		serviceAccounts: kFactory.Core().V1().ServiceAccounts(),
+		secrets:         kFactory.Core().V1().Secrets(),
+		configMaps:      kFactory.Core().V1().ConfigMaps(),
+		services:        kFactory.Core().V1().Services(),
+		deployments:     kFactory.Apps().V1().Deployments(),
+		statefulSets:    kFactory.Apps().V1().StatefulSets(),
+		autoscalers:     kFactory.Autoscaling().V1().HorizontalPodAutoscalers(),
+	}
+}
+
+//teamControllerConfig is the cluster-wide configuration of the team controller, set by the controller flags
+type teamControllerConfig struct {
+	//cluster-wide team default values
+	defaults aftouh.TeamDefaults
+
+	//namer generates the namespace names of team environments
+	namer *namespaceNamer
+
+	//ci configures the deploy service account of the team namespaces
+	ci ciConfig
+
+	//pullSecrets lists the image pull secrets replicated into the team namespaces
+	pullSecrets pullSecretConfig
+
+	//syncedNamespaces are the namespaces of the resources teams may sync with spec.syncedResources
+	syncedNamespaces []string
+
+	//allowedMemberRoles are the cluster roles the team members may be bound to
+	allowedMemberRoles []string
+}
+
+//NewTeamController creates team controller
+func NewTeamController(tClientSet tclient.Interface, kClientSet kubernetes.Interface, mClientSet metadata.Interface, informers teamInformers, config teamControllerConfig) *TeamController {
 	eventBrodcaster := record.NewBroadcaster()
 	eventBrodcaster.StartLogging(klog.Infof)
 	eventBrodcaster.StartRecordingToSink(&coreTyped.EventSinkImpl{Interface: kClientSet.CoreV1().Events("")})
 
 	tc := &TeamController{
 		kClientSet: kClientSet,
-
-		tClientSet:    tClientSet,
-		tLister:       tInformer.Lister(),
-		tListerSynced: tInformer.Informer().HasSynced,
-
-		nLister:       nInformer.Lister(),
-		nListerSynced: nInformer.Informer().HasSynced,
-
-		rqLister:       rqInformer.Lister(),
-		rqListerSynced: rqInformer.Informer().HasSynced,
+		mClientSet: mClientSet,
+		tClientSet: tClientSet,
+
+		tLister:      informers.teams.Lister(),
+		tcLister:     informers.teamClasses.Lister(),
+		dLister:      informers.departments.Lister(),
+		nLister:      informers.namespaces.Lister(),
+		rqLister:     informers.resourceQuotas.Lister(),
+		lrLister:     informers.limitRanges.Lister(),
+		npLister:     informers.networkPolicies.Lister(),
+		rbLister:     informers.roleBindings.Lister(),
+		podLister:    informers.pods.Lister(),
+		saLister:     informers.serviceAccounts.Lister(),
+		secretLister: informers.secrets.Lister(),
+		cmLister:     informers.configMaps.Lister(),
+		svcLister:    informers.services.Lister(),
+		deployLister: informers.deployments.Lister(),
+		ssLister:     informers.statefulSets.Lister(),
+		hpaLister:    informers.autoscalers.Lister(),
 
 		queue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
 		recorder: eventBrodcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "team-controller"}),
+		clock:    clock.RealClock{},
+
+		teamControllerConfig: config,
+	}
+
+	//watch adds the handlers of an informer and waits for its cache before starting the workers
+	watch := func(informer cache.SharedIndexInformer, handler cache.ResourceEventHandler) {
+		if handler != nil {
+			informer.AddEventHandler(handler)
+		}
+		tc.listersSynced = append(tc.listersSynced, informer.HasSynced)
 	}
 
-	tInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	watch(informers.teams.Informer(), cache.ResourceEventHandlerFuncs{
 		AddFunc:    tc.addTeam,
 		UpdateFunc: tc.updateTeam,
+		DeleteFunc: tc.deleteTeam,
+	})
+
+	watch(informers.teamClasses.Informer(), cache.ResourceEventHandlerFuncs{
+		AddFunc:    tc.addTeamClass,
+		UpdateFunc: tc.updateTeamClass,
+		DeleteFunc: tc.deleteTeamClass,
+	})
+
+	watch(informers.departments.Informer(), cache.ResourceEventHandlerFuncs{
+		AddFunc:    tc.addDepartment,
+		UpdateFunc: tc.updateDepartment,
+		DeleteFunc: tc.deleteDepartment,
+	})
+
+	watch(informers.namespaces.Informer(), cache.ResourceEventHandlerFuncs{
+		UpdateFunc: tc.updateObj,
+		DeleteFunc: tc.deleteObj,
+	})
+
+	//Status updates of the resourcequotas refresh the quota usage of the team
+	watch(informers.resourceQuotas.Informer(), cache.ResourceEventHandlerFuncs{
+		UpdateFunc: tc.updateObj,
+		DeleteFunc: tc.deleteObj,
+	})
+
+	watch(informers.limitRanges.Informer(), cache.ResourceEventHandlerFuncs{
+		UpdateFunc: tc.updateObj,
+		DeleteFunc: tc.deleteObj,
 	})
 
-	nInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	watch(informers.networkPolicies.Informer(), cache.ResourceEventHandlerFuncs{
 		UpdateFunc: tc.updateObj,
 		DeleteFunc: tc.deleteObj,
 	})
 
-	rqInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	watch(informers.roleBindings.Informer(), cache.ResourceEventHandlerFuncs{
 		UpdateFunc: tc.updateObj,
 		DeleteFunc: tc.deleteObj,
 	})
 
+	watch(informers.pods.Informer(), cache.ResourceEventHandlerFuncs{
+		AddFunc:    tc.addPod,
+		UpdateFunc: tc.updatePod,
+		DeleteFunc: tc.deletePod,
+	})
+
+	//The ci service account and its token secret are recreated when deleted or modified.
+	//The default service accounts are also watched to reference the pull secrets
+	watch(informers.serviceAccounts.Informer(), cache.ResourceEventHandlerFuncs{
+		AddFunc:    tc.addServiceAccount,
+		UpdateFunc: tc.updateServiceAccount,
+		DeleteFunc: tc.deleteObj,
+	})
+
+	watch(informers.secrets.Informer(), cache.ResourceEventHandlerFuncs{
+		AddFunc:    tc.addSecret,
+		UpdateFunc: tc.updateSecret,
+		DeleteFunc: tc.deleteObj,
+	})
+
+	watch(informers.configMaps.Informer(), cache.ResourceEventHandlerFuncs{
+		AddFunc:    tc.addConfigMap,
+		UpdateFunc: tc.updateConfigMap,
+		DeleteFunc: tc.deleteObj,
+	})
+
+	//Services are only read by the Copy namespace migration
+	watch(informers.services.Informer(), nil)
+
+	//Workloads started or scaled up while the team is suspended are scaled down again
+	watch(informers.deployments.Informer(), cache.ResourceEventHandlerFuncs{
+		AddFunc:    tc.addWorkload,
+		UpdateFunc: tc.updateDeployment,
+	})
+
+	watch(informers.statefulSets.Informer(), cache.ResourceEventHandlerFuncs{
+		AddFunc:    tc.addWorkload,
+		UpdateFunc: tc.updateWorkload,
+	})
+
+	//Autoscalers allowed to scale to zero are paused while the team is suspended
+	watch(informers.autoscalers.Informer(), cache.ResourceEventHandlerFuncs{
+		AddFunc:    tc.addWorkload,
+		UpdateFunc: tc.updateAutoscaler,
+	})
+
 	return tc
 }
 
@@ -112,6 +339,7 @@ func (tc *TeamController) addTeam(obj interface{}) {
 	t := obj.(*aftouh.Team)
 	klog.V(4).Infof("Detect add of team %q", t.Name)
 	tc.enqueue(t)
+	tc.enqueueBudgetTeams(t)
 }
 
 func (tc *TeamController) updateTeam(old, cur interface{}) {
@@ -119,6 +347,143 @@ func (tc *TeamController) updateTeam(old, cur interface{}) {
 	curT := cur.(*aftouh.Team)
 	klog.V(4).Infof("Detect update of team %s", oldT.Name)
 	tc.enqueue(curT)
+
+	//Spec changes and deletions change the budget allocation of the parents and of the team children.
+	//Status updates are ignored so that syncing the siblings does not trigger endless syncs
+	if oldT.Generation != curT.Generation || (oldT.DeletionTimestamp == nil) != (curT.DeletionTimestamp == nil) {
+		tc.enqueueBudgetTeams(oldT)
+		tc.enqueueBudgetTeams(curT)
+	}
+}
+
+func (tc *TeamController) deleteTeam(obj interface{}) {
+	t, ok := obj.(*aftouh.Team)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		t, ok = tombstone.Obj.(*aftouh.Team)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a Team %#v", obj))
+			return
+		}
+	}
+	klog.V(4).Infof("Detect delete of team %q", t.Name)
+	tc.enqueueBudgetTeams(t)
+}
+
+func (tc *TeamController) addTeamClass(obj interface{}) {
+	class := obj.(*aftouh.TeamClass)
+	klog.V(4).Infof("Detect add of team class %q", class.Name)
+	tc.enqueueClassTeams(class)
+}
+
+func (tc *TeamController) updateTeamClass(old, cur interface{}) {
+	oldClass := old.(*aftouh.TeamClass)
+	curClass := cur.(*aftouh.TeamClass)
+	if oldClass.ResourceVersion == curClass.ResourceVersion {
+		return
+	}
+	klog.V(4).Infof("Detect update of team class %q", curClass.Name)
+	tc.enqueueClassTeams(curClass)
+}
+
+func (tc *TeamController) deleteTeamClass(obj interface{}) {
+	class, ok := obj.(*aftouh.TeamClass)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		class, ok = tombstone.Obj.(*aftouh.TeamClass)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a TeamClass %#v", obj))
+			return
+		}
+	}
+	klog.V(4).Infof("Detect delete of team class %q", class.Name)
+	tc.enqueueClassTeams(class)
+}
+
+//enqueueClassTeams enqueues all the teams using the class
+func (tc *TeamController) enqueueClassTeams(class *aftouh.TeamClass) {
+	teams, err := tc.tLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("Couldn't list teams of class %q: %v", class.Name, err))
+		return
+	}
+	for _, t := range teams {
+		if t.Spec.ClassName == class.Name {
+			tc.enqueue(t)
+		}
+	}
+}
+
+func (tc *TeamController) addDepartment(obj interface{}) {
+	d := obj.(*aftouh.Department)
+	klog.V(4).Infof("Detect add of department %q", d.Name)
+	tc.enqueueParent(aftouh.TeamParent{Kind: aftouh.ParentKindDepartment, Name: d.Name})
+}
+
+func (tc *TeamController) updateDepartment(old, cur interface{}) {
+	oldD := old.(*aftouh.Department)
+	curD := cur.(*aftouh.Department)
+	//Status updates do not change the allocation
+	if oldD.Generation == curD.Generation {
+		return
+	}
+	klog.V(4).Infof("Detect update of department %q", curD.Name)
+	tc.enqueueParent(aftouh.TeamParent{Kind: aftouh.ParentKindDepartment, Name: curD.Name})
+}
+
+func (tc *TeamController) deleteDepartment(obj interface{}) {
+	d, ok := obj.(*aftouh.Department)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		d, ok = tombstone.Obj.(*aftouh.Department)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a Department %#v", obj))
+			return
+		}
+	}
+	klog.V(4).Infof("Detect delete of department %q", d.Name)
+	tc.enqueueParent(aftouh.TeamParent{Kind: aftouh.ParentKindDepartment, Name: d.Name})
+}
+
+//enqueueBudgetTeams enqueues the parent of the team with its children, and the children of the team
+func (tc *TeamController) enqueueBudgetTeams(t *aftouh.Team) {
+	if t.Spec.Parent != nil {
+		tc.enqueueParent(*t.Spec.Parent)
+	}
+	tc.enqueueParent(aftouh.TeamParent{Kind: aftouh.ParentKindTeam, Name: t.Name})
+}
+
+//enqueueParent enqueues a parent team or department together with its child teams
+func (tc *TeamController) enqueueParent(parent aftouh.TeamParent) {
+	switch parent.Kind {
+	case aftouh.ParentKindDepartment:
+		tc.queue.Add(departmentKeyPrefix + parent.Name)
+	case aftouh.ParentKindTeam:
+		if t, err := tc.tLister.Get(parent.Name); err == nil {
+			tc.enqueue(t)
+		}
+	}
+
+	children, err := tc.getChildTeams(parent)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("Couldn't list child teams of %s %q: %v", parent.Kind, parent.Name, err))
+		return
+	}
+	for _, t := range children {
+		tc.enqueue(t)
+	}
 }
 
 func (tc *TeamController) updateObj(old, cur interface{}) {
@@ -149,7 +514,8 @@ func (tc *TeamController) updateObj(old, cur interface{}) {
 func (tc *TeamController) deleteObj(del interface{}) {
 	var obj metav1.Object
 	switch del.(type) {
-	case *corev1.Namespace, *corev1.ResourceQuota:
+	case *corev1.Namespace, *corev1.ResourceQuota, *corev1.LimitRange, *networkingv1.NetworkPolicy, *rbacv1.RoleBinding,
+		*corev1.ServiceAccount, *corev1.Secret, *corev1.ConfigMap:
 		obj = del.(metav1.Object)
 	default:
 		tombstone, ok := del.(cache.DeletedFinalStateUnknown)
@@ -159,10 +525,11 @@ func (tc *TeamController) deleteObj(del interface{}) {
 		}
 
 		switch tombstone.Obj.(type) {
-		case *corev1.Namespace, *corev1.ResourceQuota:
-			obj = del.(metav1.Object)
+		case *corev1.Namespace, *corev1.ResourceQuota, *corev1.LimitRange, *networkingv1.NetworkPolicy, *rbacv1.RoleBinding,
+			*corev1.ServiceAccount, *corev1.Secret, *corev1.ConfigMap:
+			obj = tombstone.Obj.(metav1.Object)
 		default:
-			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a Namespace or ResourceQuota %#v", obj))
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not managed by Team %#v", obj))
 			return
 		}
 	}
@@ -184,6 +551,58 @@ func (tc *TeamController) deleteObj(del interface{}) {
 	tc.enqueue(team)
 }
 
+//Pods are watched to report the pod security violations of the team namespaces
+
+func (tc *TeamController) addPod(obj interface{}) {
+	pod := obj.(*corev1.Pod)
+	tc.enqueueNamespaceTeam(pod.Namespace)
+}
+
+func (tc *TeamController) updatePod(old, cur interface{}) {
+	oldPod := old.(*corev1.Pod)
+	curPod := cur.(*corev1.Pod)
+	//Only the pod phase, spec and annotations matter to pod security
+	if oldPod.Status.Phase == curPod.Status.Phase && reflect.DeepEqual(oldPod.Spec, curPod.Spec) &&
+		reflect.DeepEqual(oldPod.Annotations, curPod.Annotations) {
+		return
+	}
+	tc.enqueueNamespaceTeam(curPod.Namespace)
+}
+
+func (tc *TeamController) deletePod(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		pod, ok = tombstone.Obj.(*corev1.Pod)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a Pod %#v", obj))
+			return
+		}
+	}
+	tc.enqueueNamespaceTeam(pod.Namespace)
+}
+
+//enqueueNamespaceTeam enqueues the team owning the namespace, if any
+func (tc *TeamController) enqueueNamespaceTeam(namespace string) {
+	ns, err := tc.nLister.Get(namespace)
+	if err != nil {
+		return
+	}
+	ownerRef := metav1.GetControllerOf(ns)
+	if ownerRef == nil || ownerRef.Kind != "Team" {
+		return
+	}
+	team, err := tc.tLister.Get(ownerRef.Name)
+	if err != nil {
+		return
+	}
+	tc.enqueue(team)
+}
+
 func (tc *TeamController) enqueue(t *aftouh.Team) {
 	key, err := cache.MetaNamespaceKeyFunc(t)
 	if err != nil {
@@ -200,7 +619,7 @@ func (tc *TeamController) Run(workers int, stopCh <-chan struct{}) error {
 	defer tc.queue.ShutDown()
 
 	klog.Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, tc.tListerSynced, tc.nListerSynced, tc.rqListerSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, tc.listersSynced...); !ok {
 		return fmt.Errorf("failed to sync informer caches")
 	}
 	klog.Info("Informers cache synced sucessfully")
@@ -234,6 +653,10 @@ func (tc *TeamController) processNextWorkItem() bool {
 }
 
 func (tc *TeamController) syncHandler(key string) error {
+	if strings.HasPrefix(key, departmentKeyPrefix) {
+		return tc.syncDepartment(strings.TrimPrefix(key, departmentKeyPrefix))
+	}
+
 	startTime := time.Now()
 	klog.V(4).Infof("Started syncing team %q", key)
 	defer func() {
@@ -247,63 +670,169 @@ func (tc *TeamController) syncHandler(key string) error {
 		err = nil
 	case err != nil:
 		err = fmt.Errorf("Unable to retrieve team %v from store: %v", key, err)
+	case team.DeletionTimestamp != nil:
+		err = tc.finalizeTeam(team)
+	case !hasFinalizer(team):
+		err = tc.addFinalizer(team)
 	default:
 		t := team.DeepCopy()
-		if err := tc.syncNamespace(t); err != nil {
-			return fmt.Errorf("Failed syncing team namespace: %v", err)
+		//Teams created before the defaulting webhook may miss default values
+		tc.defaults.SetDefaults(t)
+		if expired, err := tc.syncExpiry(t); expired || err != nil {
+			return err
 		}
+		migrations, syncErr := tc.syncTeam(t)
 
-		if err := tc.syncResourceQuota(t); err != nil {
-			return fmt.Errorf("Failed syncing team resourcequota: %v", err)
-		}
-
-		teamStatus, err := tc.calculateTeamStatus(t)
+		//Team status is updated even if the sync failed so that failures are reported in conditions
+		teamStatus, err := tc.calculateTeamStatus(t, migrations, syncErr)
 		if err != nil {
 			return fmt.Errorf("Failed calculating team status: %v", err)
 		}
-		t.Status = teamStatus
-		_, err = tc.tClientSet.AftouhV1().Teams().Update(t)
-		if err != nil {
-			return fmt.Errorf("Failed updating team status: %v", err)
+		if err := tc.updateTeamStatus(t, teamStatus); err != nil {
+			return err
 		}
+		return syncErr
 	}
 
 	return err
 }
 
-func (tc *TeamController) syncNamespace(t *aftouh.Team) error {
-	namespaceName := getTeamNamespace(t)
-	namespace, err := tc.nLister.Get(namespaceName)
+//updateTeamStatus writes the team status subresource if it has changed.
+//Quantities of the budget status are compared by value
+func (tc *TeamController) updateTeamStatus(t *aftouh.Team, teamStatus aftouh.TeamStatus) error {
+	if equality.Semantic.DeepEqual(t.Status, teamStatus) {
+		klog.V(4).Infof("Status of team %q is up to date", t.Name)
+		return nil
+	}
 
-	//Namespace does not exist. Need to be created
-	if errors.IsNotFound(err) {
-		var err error
-		//Delete old namespace if exists
-		oldNamespaceName := t.Status.Namespace
-		if oldNamespaceName != "" {
-			oldNS, err := tc.nLister.Get(oldNamespaceName)
-			switch {
-			case errors.IsNotFound(err):
-				klog.V(4).Infof("Old namespace %q has been deleted", oldNamespaceName)
-			case err != nil:
-				err = fmt.Errorf("Unable to retrieve namespace %q from store: %s", oldNamespaceName, err)
-			case !metav1.IsControlledBy(oldNS, t):
-				klog.Warningf("Namespace %q is not owned by team %q", oldNamespaceName, t.Name)
-			default:
-				klog.Warningf("Deleting namespace %q", oldNamespaceName)
-				err = tc.kClientSet.CoreV1().Namespaces().Delete(t.Status.Namespace, &metav1.DeleteOptions{})
+	t.Status = teamStatus
+	_, err := tc.tClientSet.AftouhV1().Teams().UpdateStatus(t)
+	if err != nil {
+		return fmt.Errorf("Failed updating team status: %v", err)
+	}
+	return nil
+}
+
+//syncTeam syncs the team namespaces and their resources. It returns the progress of the Copy migrations by old namespace
+func (tc *TeamController) syncTeam(t *aftouh.Team) (map[string]migrationProgress, error) {
+	class, err := tc.getTeamClass(t)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := tc.getTeamNamespaces(t, class)
+	if err != nil {
+		return nil, err
+	}
+
+	//A missing parent is reported by the OverBudget condition and does not cap the team quotas
+	allocation, err := tc.getTeamAllocation(t)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("Failed allocating the budget of the team parent: %v", err)
+	}
+	var capped corev1.ResourceList
+	if allocation != nil && allocation.isOverBudget(t) {
+		capped = allocation.budget
+	}
+
+	var errs []error
+	migrations := make(map[string]migrationProgress)
+	for _, env := range getTeamEnvironments(t) {
+		ns := namespaces[env.Name]
+		if ns.Pending != "" {
+			tc.recorder.Eventf(t, corev1.EventTypeWarning, reasonNamespaceMigrationPending,
+				"Namespace %q of environment %q is not migrated to %q. Set the %s=%s annotation to migrate it",
+				ns.Name, env.Name, ns.Pending, aftouh.MigrateNamespacesAnnotation, ns.Pending)
+		}
+
+		if err := tc.syncNamespace(t, env.Name, ns.Name, class); err != nil {
+			errs = append(errs, fmt.Errorf("Failed syncing team namespace: %v", err))
+			continue
+		}
+
+		if err := tc.syncResourceQuotas(t, env, ns.Name, class, capped); err != nil {
+			errs = append(errs, fmt.Errorf("Failed syncing team resourcequota: %v", err))
+		}
+
+		if err := tc.syncLimitRange(t, env.Name, ns.Name, class); err != nil {
+			errs = append(errs, fmt.Errorf("Failed syncing team limitrange: %v", err))
+		}
+
+		if err := tc.syncNetworkPolicies(t, env.Name, ns.Name, class); err != nil {
+			errs = append(errs, fmt.Errorf("Failed syncing team networkpolicies: %v", err))
+		}
+
+		if err := tc.syncRoleBindings(t, env.Name, ns.Name, class); err != nil {
+			errs = append(errs, fmt.Errorf("Failed syncing team rolebindings: %v", err))
+		}
+
+		if err := tc.syncCIServiceAccount(t, env.Name, ns.Name, class); err != nil {
+			errs = append(errs, fmt.Errorf("Failed syncing team ci service account: %v", err))
+		}
+
+		if err := tc.syncSyncedResources(t, env.Name, ns.Name, class); err != nil {
+			errs = append(errs, fmt.Errorf("Failed syncing team synced resources: %v", err))
+		}
+
+		if err := tc.syncPullSecrets(ns.Name); err != nil {
+			errs = append(errs, fmt.Errorf("Failed syncing team pull secrets: %v", err))
+		}
+
+		switch {
+		case isFrozen(t):
+			if err := tc.suspendNamespace(t, ns.Name); err != nil {
+				errs = append(errs, fmt.Errorf("Failed suspending namespace %q: %v", ns.Name, err))
+			}
+		case needsResume(t):
+			if err := tc.resumeNamespace(t, ns.Name); err != nil {
+				errs = append(errs, fmt.Errorf("Failed resuming namespace %q: %v", ns.Name, err))
 			}
 		}
 
-		if err == nil {
-			klog.V(2).Infof("Creating namespace %q", namespaceName)
-			_, err = tc.kClientSet.CoreV1().Namespaces().Create(newNamespace(t))
+		if ns.MigrateFrom != "" {
+			progress, err := tc.migrateNamespace(t, ns.MigrateFrom, ns.Name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("Failed migrating namespace %q: %v", ns.MigrateFrom, err))
+			}
+			migrations[ns.MigrateFrom] = progress
 		}
+	}
+
+	if err := tc.pruneNamespaces(t, namespaces); err != nil {
+		errs = append(errs, fmt.Errorf("Failed pruning team namespaces: %v", err))
+	}
+
+	return migrations, utilerrors.NewAggregate(errs)
+}
+
+//getTeamClass returns the class of the team or nil if the team has no class
+func (tc *TeamController) getTeamClass(t *aftouh.Team) (*aftouh.TeamClass, error) {
+	if t.Spec.ClassName == "" {
+		return nil, nil
+	}
+
+	class, err := tc.tcLister.Get(t.Spec.ClassName)
+	switch {
+	case errors.IsNotFound(err):
+		return nil, fmt.Errorf("TeamClass %q not found", t.Spec.ClassName)
+	case err != nil:
+		return nil, fmt.Errorf("Unable to retrieve team class %q from store: %v", t.Spec.ClassName, err)
+	}
+	return class, nil
+}
+
+func (tc *TeamController) syncNamespace(t *aftouh.Team, env, namespaceName string, class *aftouh.TeamClass) error {
+	namespace, err := tc.nLister.Get(namespaceName)
+
+	//Namespace does not exist. Need to be created
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("Creating namespace %q", namespaceName)
+		_, err = tc.kClientSet.CoreV1().Namespaces().Create(newNamespace(t, env, namespaceName, class))
 		return err
 	}
 
 	if err != nil {
-		return fmt.Errorf("Unable to retrieve namespace %q from store: %s", namespace, err)
+		return fmt.Errorf("Unable to retrieve namespace %q from store: %s", namespaceName, err)
 	}
 
 	// Namespace should be created by this controller
@@ -313,18 +842,63 @@ func (tc *TeamController) syncNamespace(t *aftouh.Team) error {
 		return fmt.Errorf(msg)
 	}
 
-	// Check namespace labels
-	if missingLabels(t, namespace) {
-		mergeLabels(t, namespace)
-		klog.V(2).Infof("Updating namespace %q labels", namespaceName)
+	// Check namespace labels and annotations
+	expectedNS := newNamespace(t, env, namespaceName, class)
+	if missingLabels(namespace, expectedNS.Labels) || missingAnnotations(namespace, expectedNS.Annotations) {
+		namespace = namespace.DeepCopy()
+		mergeLabels(namespace, expectedNS.Labels)
+		mergeAnnotations(namespace, expectedNS.Annotations)
+		klog.V(2).Infof("Updating namespace %q metadata", namespaceName)
 		_, err = tc.kClientSet.CoreV1().Namespaces().Update(namespace)
 	}
 
 	return err
 }
 
-func (tc *TeamController) syncResourceQuota(t *aftouh.Team) error {
-	namespaceName := getTeamNamespace(t)
+//pruneNamespaces deletes the namespaces owned by the team that do not match any team environment.
+//The resources deleted with them are reported in a team event
+func (tc *TeamController) pruneNamespaces(t *aftouh.Team, namespaces map[string]teamNamespace) error {
+	expected := make(map[string]bool)
+	for _, ns := range namespaces {
+		expected[ns.Name] = true
+		//The old namespace of a Copy migration is deleted once the migration completes
+		if ns.MigrateFrom != "" {
+			expected[ns.MigrateFrom] = true
+		}
+	}
+
+	allNS, err := tc.nLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, ns := range allNS {
+		if !metav1.IsControlledBy(ns, t) || expected[ns.Name] || ns.DeletionTimestamp != nil {
+			continue
+		}
+		resources, err := tc.listNamespaceResources(ns.Name, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Failed listing the resources of namespace %q: %v", ns.Name, err))
+			continue
+		}
+		klog.Warningf("Deleting namespace %q", ns.Name)
+		if len(resources) > 0 {
+			tc.recorder.Eventf(t, corev1.EventTypeWarning, reasonNamespacePruned, "Deleting namespace %q with its resources %s", ns.Name, summarizeResources(resources))
+		} else {
+			tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonNamespacePruned, "Deleting namespace %q", ns.Name)
+		}
+		if err := tc.kClientSet.CoreV1().Namespaces().Delete(ns.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+//syncResourceQuotas creates or updates the resourcequotas of the environment and prunes the ones removed from the team.
+//The hard limits of the capped resources are not raised above the current ones
+func (tc *TeamController) syncResourceQuotas(t *aftouh.Team, env aftouh.TeamEnvironment, namespaceName string, class *aftouh.TeamClass, capped corev1.ResourceList) error {
 	ns, err := tc.nLister.Get(namespaceName)
 	if err != nil {
 		return err
@@ -333,11 +907,43 @@ func (tc *TeamController) syncResourceQuota(t *aftouh.Team) error {
 		return fmt.Errorf("Namespace %q is not active yet", namespaceName)
 	}
 
-	rq, err := tc.rqLister.ResourceQuotas(namespaceName).Get(rqName)
+	var errs []error
+	expectedNames := make(map[string]bool)
+	for _, expectedRq := range newResourceQuotas(t, env, namespaceName, class) {
+		expectedNames[expectedRq.Name] = true
+		if capped != nil {
+			current, _ := tc.rqLister.ResourceQuotas(namespaceName).Get(expectedRq.Name)
+			expectedRq = capResourceQuota(expectedRq, current, capped)
+		}
+		if err := tc.syncResourceQuota(t, expectedRq); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	rqs, err := tc.rqLister.ResourceQuotas(namespaceName).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, rq := range rqs {
+		if expectedNames[rq.Name] || !metav1.IsControlledBy(rq, t) {
+			continue
+		}
+		klog.V(2).Infof("Deleting resourcequota %s/%s", namespaceName, rq.Name)
+		if err := tc.kClientSet.CoreV1().ResourceQuotas(namespaceName).Delete(rq.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (tc *TeamController) syncResourceQuota(t *aftouh.Team, expectedRq *corev1.ResourceQuota) error {
+	namespaceName := expectedRq.Namespace
+	rq, err := tc.rqLister.ResourceQuotas(namespaceName).Get(expectedRq.Name)
 	//ResourceQuota does not exist. Need to be created
 	if errors.IsNotFound(err) {
-		klog.V(2).Infof("Creating resourceQuota %q", rqName)
-		_, err = tc.kClientSet.CoreV1().ResourceQuotas(namespaceName).Create(newResourceQuota(t))
+		klog.V(2).Infof("Creating resourceQuota %s/%s", namespaceName, expectedRq.Name)
+		_, err = tc.kClientSet.CoreV1().ResourceQuotas(namespaceName).Create(expectedRq)
 		return err
 	}
 
@@ -352,15 +958,258 @@ func (tc *TeamController) syncResourceQuota(t *aftouh.Team) error {
 	}
 
 	//Check of external modification
-	expectedRq := newResourceQuota(t)
-	if !reflect.DeepEqual(expectedRq.Spec, rq.Spec) || missingLabels(t, rq) {
-		mergeLabels(t, rq)
+	if !reflect.DeepEqual(expectedRq.Spec, rq.Spec) || missingLabels(rq, expectedRq.Labels) || missingAnnotations(rq, expectedRq.Annotations) {
+		rq = rq.DeepCopy()
+		mergeLabels(rq, expectedRq.Labels)
+		mergeAnnotations(rq, expectedRq.Annotations)
 		rq.Spec = expectedRq.Spec
-		klog.V(2).Infof("Updating resourcequota %q", rq.Name)
+		klog.V(2).Infof("Updating resourcequota %s/%s", namespaceName, rq.Name)
 		_, err = tc.kClientSet.CoreV1().ResourceQuotas(namespaceName).Update(rq)
 	}
 
-	return nil
+	return err
+}
+
+//syncLimitRange creates or updates the team limitrange and deletes it when the team no longer defines one
+func (tc *TeamController) syncLimitRange(t *aftouh.Team, env, namespaceName string, class *aftouh.TeamClass) error {
+	expectedLr := newLimitRange(t, env, namespaceName, class)
+
+	lr, err := tc.lrLister.LimitRanges(namespaceName).Get(lrName)
+	if expectedLr == nil {
+		if err != nil || !metav1.IsControlledBy(lr, t) {
+			return nil
+		}
+		klog.V(2).Infof("Deleting limitrange %s/%s", namespaceName, lrName)
+		err = tc.kClientSet.CoreV1().LimitRanges(namespaceName).Delete(lrName, &metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	ns, nsErr := tc.nLister.Get(namespaceName)
+	if nsErr != nil {
+		return nsErr
+	}
+	if ns.Status.Phase != corev1.NamespaceActive {
+		return fmt.Errorf("Namespace %q is not active yet", namespaceName)
+	}
+
+	//LimitRange does not exist. Need to be created
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("Creating limitrange %s/%s", namespaceName, lrName)
+		_, err = tc.kClientSet.CoreV1().LimitRanges(namespaceName).Create(expectedLr)
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(lr, t) {
+		msg := fmt.Sprintf(messageResourceExists, lr.Name)
+		tc.recorder.Event(t, corev1.EventTypeWarning, errResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+
+	//Check of external modification
+	if !reflect.DeepEqual(expectedLr.Spec, lr.Spec) || missingLabels(lr, expectedLr.Labels) || missingAnnotations(lr, expectedLr.Annotations) {
+		lr = lr.DeepCopy()
+		mergeLabels(lr, expectedLr.Labels)
+		mergeAnnotations(lr, expectedLr.Annotations)
+		lr.Spec = expectedLr.Spec
+		klog.V(2).Infof("Updating limitrange %s/%s", namespaceName, lr.Name)
+		_, err = tc.kClientSet.CoreV1().LimitRanges(namespaceName).Update(lr)
+	}
+
+	return err
+}
+
+//syncNetworkPolicies creates, updates and prunes the baseline networkpolicies of the namespace
+func (tc *TeamController) syncNetworkPolicies(t *aftouh.Team, env, namespaceName string, class *aftouh.TeamClass) error {
+	expected := newNetworkPolicies(t, env, namespaceName, class)
+	if len(expected) > 0 {
+		ns, err := tc.nLister.Get(namespaceName)
+		if err != nil {
+			return err
+		}
+		if ns.Status.Phase != corev1.NamespaceActive {
+			return fmt.Errorf("Namespace %q is not active yet", namespaceName)
+		}
+	}
+
+	var errs []error
+	expectedNames := make(map[string]bool)
+	for _, expectedNp := range expected {
+		expectedNames[expectedNp.Name] = true
+		if err := tc.syncNetworkPolicy(t, expectedNp); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	//Prune the networkpolicies no longer required by the team mode
+	nps, err := tc.npLister.NetworkPolicies(namespaceName).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, np := range nps {
+		if expectedNames[np.Name] || !metav1.IsControlledBy(np, t) {
+			continue
+		}
+		klog.V(2).Infof("Deleting networkpolicy %s/%s", namespaceName, np.Name)
+		if err := tc.kClientSet.NetworkingV1().NetworkPolicies(namespaceName).Delete(np.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (tc *TeamController) syncNetworkPolicy(t *aftouh.Team, expectedNp *networkingv1.NetworkPolicy) error {
+	namespaceName := expectedNp.Namespace
+	np, err := tc.npLister.NetworkPolicies(namespaceName).Get(expectedNp.Name)
+	//NetworkPolicy does not exist. Need to be created
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("Creating networkpolicy %s/%s", namespaceName, expectedNp.Name)
+		_, err = tc.kClientSet.NetworkingV1().NetworkPolicies(namespaceName).Create(expectedNp)
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(np, t) {
+		msg := fmt.Sprintf(messageResourceExists, np.Name)
+		tc.recorder.Event(t, corev1.EventTypeWarning, errResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+
+	//Check of external modification
+	if !reflect.DeepEqual(expectedNp.Spec, np.Spec) || missingLabels(np, expectedNp.Labels) || missingAnnotations(np, expectedNp.Annotations) {
+		np = np.DeepCopy()
+		mergeLabels(np, expectedNp.Labels)
+		mergeAnnotations(np, expectedNp.Annotations)
+		np.Spec = expectedNp.Spec
+		klog.V(2).Infof("Updating networkpolicy %s/%s", namespaceName, np.Name)
+		_, err = tc.kClientSet.NetworkingV1().NetworkPolicies(namespaceName).Update(np)
+	}
+
+	return err
+}
+
+//syncRoleBindings creates, updates and prunes the rolebindings giving the team members and the ci service account their role in the namespace
+func (tc *TeamController) syncRoleBindings(t *aftouh.Team, env, namespaceName string, class *aftouh.TeamClass) error {
+	expected := newRoleBindings(t, env, namespaceName, class)
+	//The webhook rejects the other roles, they are ignored for the teams created without the webhook and their
+	//rolebindings are pruned
+	for _, name := range sortedRoleBindingNames(expected) {
+		if role := expected[name].RoleRef.Name; !tc.isAllowedMemberRole(role) {
+			tc.recorder.Eventf(t, corev1.EventTypeWarning, reasonRoleForbidden,
+				"Members with role %q are not bound, the role may not be given to team members", role)
+			delete(expected, name)
+		}
+	}
+	if rb := tc.ci.newRoleBinding(t, env, namespaceName, class); rb != nil {
+		expected[rb.Name] = rb
+	}
+	if len(expected) > 0 {
+		ns, err := tc.nLister.Get(namespaceName)
+		if err != nil {
+			return err
+		}
+		if ns.Status.Phase != corev1.NamespaceActive {
+			return fmt.Errorf("Namespace %q is not active yet", namespaceName)
+		}
+	}
+
+	var errs []error
+	for _, name := range sortedRoleBindingNames(expected) {
+		if err := tc.syncRoleBinding(t, expected[name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	//Prune the rolebindings of the roles no longer given to any member
+	rbs, err := tc.rbLister.RoleBindings(namespaceName).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, rb := range rbs {
+		if _, ok := expected[rb.Name]; ok || !metav1.IsControlledBy(rb, t) {
+			continue
+		}
+		klog.V(2).Infof("Deleting rolebinding %s/%s", namespaceName, rb.Name)
+		if err := tc.kClientSet.RbacV1().RoleBindings(namespaceName).Delete(rb.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+//parseMemberRoles parses the comma separated roles of the -allowed-member-roles flag
+func parseMemberRoles(s string) []string {
+	var roles []string
+	for _, role := range strings.Split(s, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+//isAllowedMemberRole returns true if the team members may be bound to the cluster role
+func (tc *TeamController) isAllowedMemberRole(role string) bool {
+	for _, r := range tc.allowedMemberRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (tc *TeamController) syncRoleBinding(t *aftouh.Team, expectedRb *rbacv1.RoleBinding) error {
+	namespaceName := expectedRb.Namespace
+	rb, err := tc.rbLister.RoleBindings(namespaceName).Get(expectedRb.Name)
+	//RoleBinding does not exist. Need to be created
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("Creating rolebinding %s/%s", namespaceName, expectedRb.Name)
+		_, err = tc.kClientSet.RbacV1().RoleBindings(namespaceName).Create(expectedRb)
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(rb, t) {
+		msg := fmt.Sprintf(messageResourceExists, rb.Name)
+		tc.recorder.Event(t, corev1.EventTypeWarning, errResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+
+	//RoleRef is immutable. The rolebinding has to be recreated
+	if rb.RoleRef != expectedRb.RoleRef {
+		klog.V(2).Infof("Recreating rolebinding %s/%s", namespaceName, rb.Name)
+		if err := tc.kClientSet.RbacV1().RoleBindings(namespaceName).Delete(rb.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = tc.kClientSet.RbacV1().RoleBindings(namespaceName).Create(expectedRb)
+		return err
+	}
+
+	//Check of external modification
+	if !reflect.DeepEqual(expectedRb.Subjects, rb.Subjects) || missingLabels(rb, expectedRb.Labels) || missingAnnotations(rb, expectedRb.Annotations) {
+		rb = rb.DeepCopy()
+		mergeLabels(rb, expectedRb.Labels)
+		mergeAnnotations(rb, expectedRb.Annotations)
+		rb.Subjects = expectedRb.Subjects
+		klog.V(2).Infof("Updating rolebinding %s/%s", namespaceName, rb.Name)
+		_, err = tc.kClientSet.RbacV1().RoleBindings(namespaceName).Update(rb)
+	}
+
+	return err
 }
 
 func (tc *TeamController) handleErr(err error, key interface{}) {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -9,16 +10,23 @@ import (
 
 	kinformers "k8s.io/client-go/informers"
 	kfake "k8s.io/client-go/kubernetes/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 
 	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
 	tfake "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/fake"
 	tinformers "github.com/aftouh/k8s-sample-controller/pkg/client/informers/externalversions"
 
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/diff"
 
 	core "k8s.io/client-go/testing"
@@ -27,6 +35,7 @@ import (
 var (
 	alwaysReady        = func() bool { return true }
 	noResyncPeriodFunc = func() time.Duration { return 0 }
+	testTime           = metav1.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
 )
 
 type fixture struct {
@@ -34,20 +43,53 @@ type fixture struct {
 
 	tClientSet *tfake.Clientset
 	kClientSet *kfake.Clientset
+	mClientSet *metadatafake.FakeMetadataClient
 
 	// Objects to put in the store.
-	tLister  []*aftouhv1.Team
-	nLister  []*corev1.Namespace
-	rqLister []*corev1.ResourceQuota
+	tLister      []*aftouhv1.Team
+	tcLister     []*aftouhv1.TeamClass
+	dLister      []*aftouhv1.Department
+	nLister      []*corev1.Namespace
+	rqLister     []*corev1.ResourceQuota
+	lrLister     []*corev1.LimitRange
+	npLister     []*networkingv1.NetworkPolicy
+	rbLister     []*rbacv1.RoleBinding
+	podLister    []*corev1.Pod
+	saLister     []*corev1.ServiceAccount
+	secretLister []*corev1.Secret
+	cmLister     []*corev1.ConfigMap
+	svcLister    []*corev1.Service
+	deployLister []*appsv1.Deployment
+	ssLister     []*appsv1.StatefulSet
+	hpaLister    []*autoscalingv1.HorizontalPodAutoscaler
 
 	// Actions expected to happen on the kubernetes client.
 	kActions []core.Action
 	// Actions expected to happen on the team client.
 	tActions []core.Action
 
+	// Team default values of the controller
+	defaults aftouhv1.TeamDefaults
+	// Template of the team namespaces. Defaults to defaultNamespaceTemplate
+	namespaceTemplate string
+	// CI service account configuration of the controller. Disabled by default
+	ci ciConfig
+	// Image pull secrets replicated by the controller. Disabled by default
+	pullSecrets pullSecretConfig
+	// Namespaces teams may sync resources from. None by default
+	syncedNamespaces []string
+	// Cluster roles team members may be given. Defaults to admin, edit and view
+	allowedMemberRoles []string
+	// Recorder of the controller events. Events are dropped by default
+	recorder *record.FakeRecorder
+
 	// Objects from here preloaded into NewSimpleFake.
 	kObjects []runtime.Object
 	tObjects []runtime.Object
+	// Metadata of the objects listed in the namespaces deleted by the controller
+	mObjects []runtime.Object
+	// Resources served by the discovery of the kubernetes client
+	apiResources []*metav1.APIResourceList
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -61,25 +103,56 @@ func newFixture(t *testing.T) *fixture {
 func (f *fixture) newTeamController() (*TeamController, tinformers.SharedInformerFactory, kinformers.SharedInformerFactory) {
 	f.tClientSet = tfake.NewSimpleClientset(f.tObjects...)
 	f.kClientSet = kfake.NewSimpleClientset(f.kObjects...)
+	f.kClientSet.Resources = f.apiResources
+	metadataScheme := runtime.NewScheme()
+	metav1.AddMetaToScheme(metadataScheme)
+	f.mClientSet = metadatafake.NewSimpleMetadataClient(metadataScheme, f.mObjects...)
+
+	if f.namespaceTemplate == "" {
+		f.namespaceTemplate = defaultNamespaceTemplate
+	}
+	if f.allowedMemberRoles == nil {
+		f.allowedMemberRoles = []string{"admin", "edit", "view"}
+	}
+	namer, err := newNamespaceNamer(f.namespaceTemplate)
+	if err != nil {
+		f.t.Fatal(err)
+	}
 
 	tInformer := tinformers.NewSharedInformerFactory(f.tClientSet, noResyncPeriodFunc())
 	kInfomer := kinformers.NewSharedInformerFactory(f.kClientSet, noResyncPeriodFunc())
 
-	tc := NewTeamController(f.tClientSet, f.kClientSet,
-		tInformer.Aftouh().V1().Teams(),
-		kInfomer.Core().V1().Namespaces(),
-		kInfomer.Core().V1().ResourceQuotas())
+	tc := NewTeamController(f.tClientSet, f.kClientSet, f.mClientSet,
+		newTeamInformers(tInformer, kInfomer),
+		teamControllerConfig{
+			defaults:           f.defaults,
+			namer:              namer,
+			ci:                 f.ci,
+			pullSecrets:        f.pullSecrets,
+			syncedNamespaces:   f.syncedNamespaces,
+			allowedMemberRoles: f.allowedMemberRoles,
+		})
 
-	tc.tListerSynced = alwaysReady
-	tc.nListerSynced = alwaysReady
-	tc.rqListerSynced = alwaysReady
+	tc.listersSynced = []cache.InformerSynced{alwaysReady}
 
 	tc.recorder = &record.FakeRecorder{}
+	if f.recorder != nil {
+		tc.recorder = f.recorder
+	}
+	tc.clock = clock.NewFakeClock(testTime.Time)
 
 	for _, t := range f.tLister {
 		tInformer.Aftouh().V1().Teams().Informer().GetIndexer().Add(t)
 	}
 
+	for _, class := range f.tcLister {
+		tInformer.Aftouh().V1().TeamClasses().Informer().GetIndexer().Add(class)
+	}
+
+	for _, d := range f.dLister {
+		tInformer.Aftouh().V1().Departments().Informer().GetIndexer().Add(d)
+	}
+
 	for _, n := range f.nLister {
 		kInfomer.Core().V1().Namespaces().Informer().GetIndexer().Add(n)
 	}
@@ -88,6 +161,49 @@ func (f *fixture) newTeamController() (*TeamController, tinformers.SharedInforme
 		kInfomer.Core().V1().ResourceQuotas().Informer().GetIndexer().Add(rq)
 	}
 
+	for _, lr := range f.lrLister {
+		kInfomer.Core().V1().LimitRanges().Informer().GetIndexer().Add(lr)
+	}
+
+	for _, np := range f.npLister {
+		kInfomer.Networking().V1().NetworkPolicies().Informer().GetIndexer().Add(np)
+	}
+
+	for _, rb := range f.rbLister {
+		kInfomer.Rbac().V1().RoleBindings().Informer().GetIndexer().Add(rb)
+	}
+
+	for _, pod := range f.podLister {
+		kInfomer.Core().V1().Pods().Informer().GetIndexer().Add(pod)
+	}
+
+	for _, sa := range f.saLister {
+		kInfomer.Core().V1().ServiceAccounts().Informer().GetIndexer().Add(sa)
+	}
+
+	for _, secret := range f.secretLister {
+		kInfomer.Core().V1().Secrets().Informer().GetIndexer().Add(secret)
+	}
+	for _, cm := range f.cmLister {
+		kInfomer.Core().V1().ConfigMaps().Informer().GetIndexer().Add(cm)
+	}
+
+	for _, svc := range f.svcLister {
+		kInfomer.Core().V1().Services().Informer().GetIndexer().Add(svc)
+	}
+
+	for _, d := range f.deployLister {
+		kInfomer.Apps().V1().Deployments().Informer().GetIndexer().Add(d)
+	}
+
+	for _, s := range f.ssLister {
+		kInfomer.Apps().V1().StatefulSets().Informer().GetIndexer().Add(s)
+	}
+
+	for _, hpa := range f.hpaLister {
+		kInfomer.Autoscaling().V1().HorizontalPodAutoscalers().Informer().GetIndexer().Add(hpa)
+	}
+
 	return tc, tInformer, kInfomer
 }
 
@@ -96,12 +212,51 @@ func (f *fixture) addObj(obj metav1.Object) {
 	case *aftouhv1.Team:
 		f.tLister = append(f.tLister, obj)
 		f.tObjects = append(f.tObjects, obj)
+	case *aftouhv1.TeamClass:
+		f.tcLister = append(f.tcLister, obj)
+		f.tObjects = append(f.tObjects, obj)
+	case *aftouhv1.Department:
+		f.dLister = append(f.dLister, obj)
+		f.tObjects = append(f.tObjects, obj)
 	case *corev1.Namespace:
 		f.nLister = append(f.nLister, obj)
 		f.kObjects = append(f.kObjects, obj)
 	case *corev1.ResourceQuota:
 		f.rqLister = append(f.rqLister, obj)
 		f.kObjects = append(f.kObjects, obj)
+	case *corev1.LimitRange:
+		f.lrLister = append(f.lrLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *networkingv1.NetworkPolicy:
+		f.npLister = append(f.npLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *rbacv1.RoleBinding:
+		f.rbLister = append(f.rbLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *corev1.Pod:
+		f.podLister = append(f.podLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *corev1.ServiceAccount:
+		f.saLister = append(f.saLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *corev1.Secret:
+		f.secretLister = append(f.secretLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *corev1.ConfigMap:
+		f.cmLister = append(f.cmLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *corev1.Service:
+		f.svcLister = append(f.svcLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *appsv1.Deployment:
+		f.deployLister = append(f.deployLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *appsv1.StatefulSet:
+		f.ssLister = append(f.ssLister, obj)
+		f.kObjects = append(f.kObjects, obj)
+	case *autoscalingv1.HorizontalPodAutoscaler:
+		f.hpaLister = append(f.hpaLister, obj)
+		f.kObjects = append(f.kObjects, obj)
 	}
 }
 
@@ -138,7 +293,7 @@ func (f *fixture) runController(teamName string, startInformers bool, expectErro
 		f.t.Errorf("%d additional expected team actions:%+v", len(f.tActions)-len(tActions), f.tActions[len(tActions):])
 	}
 
-	kActions := f.kClientSet.Actions()
+	kActions := filterDiscoveryActions(f.kClientSet.Actions())
 	for i, action := range kActions {
 		if len(f.kActions) < i+1 {
 			f.t.Errorf("%d unexpected kubernetes actions: %+v", len(kActions)-len(f.kActions), kActions[i:])
@@ -154,6 +309,18 @@ func (f *fixture) runController(teamName string, startInformers bool, expectErro
 	}
 }
 
+// filterDiscoveryActions removes the discovery requests of the namespace resources listings
+func filterDiscoveryActions(actions []core.Action) []core.Action {
+	var ret []core.Action
+	for _, action := range actions {
+		if action.Matches("get", "group") || action.Matches("get", "resource") {
+			continue
+		}
+		ret = append(ret, action)
+	}
+	return ret
+}
+
 // checkAction verifies that expected and actual actions are equal and both have
 // same attached resources
 func checkAction(expected, actual core.Action, t *testing.T) {
@@ -186,6 +353,18 @@ func checkAction(expected, actual core.Action, t *testing.T) {
 			t.Errorf("Action %s %s has wrong object\nDiff:\n %s",
 				a.GetVerb(), a.GetResource().Resource, diff.ObjectGoPrintSideBySide(expObject, object))
 		}
+	case core.DeleteActionImpl:
+		e, _ := expected.(core.DeleteActionImpl)
+		if e.GetName() != a.GetName() || e.GetNamespace() != a.GetNamespace() {
+			t.Errorf("Action %s %s has wrong object. Expected %s/%s, got %s/%s",
+				a.GetVerb(), a.GetResource().Resource, e.GetNamespace(), e.GetName(), a.GetNamespace(), a.GetName())
+		}
+	case core.ListActionImpl:
+		e, _ := expected.(core.ListActionImpl)
+		if e.GetNamespace() != a.GetNamespace() {
+			t.Errorf("Action %s %s has wrong namespace. Expected %s, got %s",
+				a.GetVerb(), a.GetResource().Resource, e.GetNamespace(), a.GetNamespace())
+		}
 	case core.PatchActionImpl:
 		e, _ := expected.(core.PatchActionImpl)
 		expPatch := e.GetPatch()
@@ -217,7 +396,67 @@ func (f *fixture) expectUpdateResourceQuotaAction(rq *corev1.ResourceQuota) {
 	f.kActions = append(f.kActions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "resourcequotas"}, rq.Namespace, rq))
 }
 
-func (f *fixture) expectUpdateTeamStatus(t *aftouhv1.Team) {
+func (f *fixture) expectCreateLimitRangeAction(lr *corev1.LimitRange) {
+	f.kActions = append(f.kActions, core.NewCreateAction(schema.GroupVersionResource{Resource: "limitranges"}, lr.Namespace, lr))
+}
+
+func (f *fixture) expectUpdateLimitRangeAction(lr *corev1.LimitRange) {
+	f.kActions = append(f.kActions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "limitranges"}, lr.Namespace, lr))
+}
+
+func (f *fixture) expectDeleteLimitRangeAction(lr *corev1.LimitRange) {
+	f.kActions = append(f.kActions, core.NewDeleteAction(schema.GroupVersionResource{Resource: "limitranges"}, lr.Namespace, lr.Name))
+}
+
+func (f *fixture) expectCreateNetworkPolicyAction(np *networkingv1.NetworkPolicy) {
+	f.kActions = append(f.kActions, core.NewCreateAction(schema.GroupVersionResource{Resource: "networkpolicies"}, np.Namespace, np))
+}
+
+func (f *fixture) expectUpdateNetworkPolicyAction(np *networkingv1.NetworkPolicy) {
+	f.kActions = append(f.kActions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "networkpolicies"}, np.Namespace, np))
+}
+
+func (f *fixture) expectDeleteNetworkPolicyAction(np *networkingv1.NetworkPolicy) {
+	f.kActions = append(f.kActions, core.NewDeleteAction(schema.GroupVersionResource{Resource: "networkpolicies"}, np.Namespace, np.Name))
+}
+
+func (f *fixture) expectCreateRoleBindingAction(rb *rbacv1.RoleBinding) {
+	f.kActions = append(f.kActions, core.NewCreateAction(schema.GroupVersionResource{Resource: "rolebindings"}, rb.Namespace, rb))
+}
+
+func (f *fixture) expectUpdateRoleBindingAction(rb *rbacv1.RoleBinding) {
+	f.kActions = append(f.kActions, core.NewUpdateAction(schema.GroupVersionResource{Resource: "rolebindings"}, rb.Namespace, rb))
+}
+
+func (f *fixture) expectDeleteRoleBindingAction(rb *rbacv1.RoleBinding) {
+	f.kActions = append(f.kActions, core.NewDeleteAction(schema.GroupVersionResource{Resource: "rolebindings"}, rb.Namespace, rb.Name))
+}
+
+func (f *fixture) expectDeleteResourceQuotaAction(rq *corev1.ResourceQuota) {
+	f.kActions = append(f.kActions, core.NewDeleteAction(schema.GroupVersionResource{Resource: "resourcequotas"}, rq.Namespace, rq.Name))
+}
+
+func (f *fixture) expectDeleteNamespaceAction(n *corev1.Namespace) {
+	f.kActions = append(f.kActions, core.NewRootDeleteAction(schema.GroupVersionResource{Resource: "namespaces"}, n.Name))
+}
+
+func (f *fixture) expectListAction(resource, namespace string) {
+	f.kActions = append(f.kActions, core.NewListAction(schema.GroupVersionResource{Resource: resource}, schema.GroupVersionKind{}, namespace, metav1.ListOptions{}))
+}
+
+func (f *fixture) expectCreateAction(resource string, obj metav1.Object) {
+	f.kActions = append(f.kActions, core.NewCreateAction(schema.GroupVersionResource{Resource: resource}, obj.GetNamespace(), obj.(runtime.Object)))
+}
+
+func (f *fixture) expectDeleteAction(resource string, obj metav1.Object) {
+	f.kActions = append(f.kActions, core.NewDeleteAction(schema.GroupVersionResource{Resource: resource}, obj.GetNamespace(), obj.GetName()))
+}
+
+func (f *fixture) expectUpdateAction(resource string, obj metav1.Object) {
+	f.kActions = append(f.kActions, core.NewUpdateAction(schema.GroupVersionResource{Resource: resource}, obj.GetNamespace(), obj.(runtime.Object)))
+}
+
+func (f *fixture) expectUpdateTeam(t *aftouhv1.Team) {
 	f.tActions = append(f.tActions, core.NewRootUpdateAction(schema.GroupVersionResource{
 		Resource: "teams",
 		Group:    aftouhv1.SchemeGroupVersion.Group,
@@ -225,12 +464,77 @@ func (f *fixture) expectUpdateTeamStatus(t *aftouhv1.Team) {
 	}, t))
 }
 
+func (f *fixture) expectDeleteTeam(t *aftouhv1.Team) {
+	f.tActions = append(f.tActions, core.NewRootDeleteAction(schema.GroupVersionResource{
+		Resource: "teams",
+		Group:    aftouhv1.SchemeGroupVersion.Group,
+		Version:  aftouhv1.SchemeGroupVersion.Version,
+	}, t.Name))
+}
+
+func (f *fixture) expectUpdateTeamStatus(t *aftouhv1.Team) {
+	f.tActions = append(f.tActions, core.NewRootUpdateSubresourceAction(schema.GroupVersionResource{
+		Resource: "teams",
+		Group:    aftouhv1.SchemeGroupVersion.Group,
+		Version:  aftouhv1.SchemeGroupVersion.Version,
+	}, "status", t))
+}
+
+// defaultTeamNamespace returns the namespace of a team environment generated by the default template
+func defaultTeamNamespace(t *aftouhv1.Team, env string) string {
+	return fmt.Sprintf("team-%s-%s", t.Spec.Name, env)
+}
+
+// readyStatus returns the status of a team whose namespace and resourcequota are synced
+func readyStatus(t *aftouhv1.Team) aftouhv1.TeamStatus {
+	return aftouhv1.TeamStatus{
+		Phase:          aftouhv1.TeamPhaseActive,
+		Namespace:      defaultTeamNamespace(t, t.Spec.Environment),
+		ResourceQuotas: []string{rqName},
+		Environments: []aftouhv1.EnvironmentStatus{
+			{Name: t.Spec.Environment, Namespace: defaultTeamNamespace(t, t.Spec.Environment), ResourceQuotas: []string{rqName}},
+		},
+		Conditions: []aftouhv1.TeamCondition{
+			newTeamCondition(aftouhv1.TeamReady, corev1.ConditionTrue, reasonSynced, "", testTime),
+			newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionTrue, reasonNamespaceActive, "", testTime),
+			newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionTrue, reasonResourceQuotaCreated, "", testTime),
+			newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+			newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionFalse, "", "", testTime),
+			newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+			newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionFalse, "", "", testTime),
+			newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionFalse, "", "", testTime),
+			newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionFalse, "", "", testTime),
+			newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionFalse, "", "", testTime),
+		},
+	}
+}
+
 func TestCreateNamespace(t *testing.T) {
 	f := newFixture(t)
 	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
 	f.addObj(team)
 
-	f.expectCreateNamespaceAction(newNamespace(team))
+	f.expectCreateNamespaceAction(newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil))
+
+	//Team status is updated with the failure
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Phase = aftouhv1.TeamPhaseActive
+	expectedTeam.Status.Environments = []aftouhv1.EnvironmentStatus{{Name: "dev"}}
+	expectedTeam.Status.Conditions = []aftouhv1.TeamCondition{
+		newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonSyncFailed,
+			`Failed syncing team resourcequota: namespace "team-test-dev" not found`, testTime),
+		newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionFalse, reasonNotFound,
+			`Namespace "team-test-dev" does not exist`, testTime),
+		newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionFalse, reasonNotFound, "", testTime),
+		newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionFalse, "", "", testTime),
+	}
+	f.expectUpdateTeamStatus(expectedTeam)
 
 	//We expect error because the new namespace is not visible by lister
 	//so resourcequota syncing return not found error
@@ -243,14 +547,32 @@ func TestCreateResourceQuota(t *testing.T) {
 	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
 
 	f.addObj(team)
-	ns := newNamespace(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
 	ns.Status.Phase = corev1.NamespaceActive
 	f.addObj(ns)
 
-	f.expectCreateResourceQuotaAction(newResourceQuota(team))
-
-	team.Status.Namespace = "team-test-dev"
-	f.expectUpdateTeamStatus(team)
+	f.expectCreateResourceQuotaAction(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	//The new resourcequota is not visible by lister yet
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Phase = aftouhv1.TeamPhaseActive
+	expectedTeam.Status.Namespace = "team-test-dev"
+	expectedTeam.Status.Environments = []aftouhv1.EnvironmentStatus{{Name: "dev", Namespace: "team-test-dev"}}
+	expectedTeam.Status.Conditions = []aftouhv1.TeamCondition{
+		newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonNotFound,
+			"ResourceQuota team-test-dev/team-default-rq does not exist", testTime),
+		newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionTrue, reasonNamespaceActive, "", testTime),
+		newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionFalse, reasonNotFound,
+			"ResourceQuota team-test-dev/team-default-rq does not exist", testTime),
+		newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionFalse, "", "", testTime),
+	}
+	f.expectUpdateTeamStatus(expectedTeam)
 
 	f.run(team.Name)
 }
@@ -269,25 +591,97 @@ func TestUpdateNamespaceLabels(t *testing.T) {
 	f.addObj(team)
 
 	//Create namespace with invalid labels
-	ns := newNamespace(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
 	ns.Labels["env"] = "prod"
 	ns.Labels["other"] = "other"
 	ns.Status.Phase = corev1.NamespaceActive
 	f.addObj(ns)
 
 	//Create team RS
-	rq := newResourceQuota(team)
+	rq := newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0]
 	f.addObj(rq)
 
 	//expect rq update
-	expectedNS := newNamespace(team)
+	expectedNS := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
 	expectedNS.Labels["other"] = "other"
 	expectedNS.Status.Phase = corev1.NamespaceActive
 	f.expectUpdateNamespaceAction(expectedNS)
 
-	team.Status.Namespace = "team-test-dev"
-	team.Status.ResourceQuota = rqName
-	f.expectUpdateTeamStatus(team)
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestUpdateNamespaceMetadata(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.NamespaceMetadata = &aftouhv1.TeamMetadata{Labels: map[string]string{"istio-injection": "enabled"}}
+	f.addObj(team)
+
+	//Objects synced with the previous team metadata and labeled by another tool
+	previous := team.DeepCopy()
+	previous.Spec.NamespaceMetadata = &aftouhv1.TeamMetadata{
+		Labels:      map[string]string{"cost-center": "platform"},
+		Annotations: map[string]string{"owner": "alice"},
+	}
+	ns := newNamespace(previous, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Labels["tool"] = "argo"
+	ns.Annotations["argocd.argoproj.io/sync-wave"] = "1"
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(previous, getTeamEnvironments(team)[0], defaultTeamNamespace(team, "dev"), nil)[0])
+
+	//Previous keys are removed, foreign keys are kept
+	expectedNS := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	expectedNS.Labels["tool"] = "argo"
+	expectedNS.Annotations["argocd.argoproj.io/sync-wave"] = "1"
+	expectedNS.Status.Phase = corev1.NamespaceActive
+	f.expectUpdateNamespaceAction(expectedNS)
+	f.expectUpdateResourceQuotaAction(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, "dev"), nil)[0])
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestPodSecurity(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "prod", corev1.ResourceQuotaSpec{})
+	team.Spec.PodSecurity = &aftouhv1.TeamPodSecurity{Enforce: aftouhv1.PodSecurityRestricted, Warn: aftouhv1.PodSecurityRestricted}
+	f.addObj(team)
+
+	//Pod security level manually lowered
+	ns := newNamespace(team, "prod", defaultTeamNamespace(team, "prod"), nil)
+	ns.Labels["pod-security.kubernetes.io/enforce"] = "privileged"
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, "prod"), nil)[0])
+
+	//Running pods, one of them breaking the restricted level
+	f.addObj(newRestrictedPod("app", ns.Name))
+	privileged := newRestrictedPod("debug", ns.Name)
+	privileged.Spec.Containers[0].SecurityContext.Privileged = boolPtr(true)
+	f.addObj(privileged)
+	completed := privileged.DeepCopy()
+	completed.Name, completed.Status.Phase = "job", corev1.PodSucceeded
+	f.addObj(completed)
+
+	expectedNS := newNamespace(team, "prod", defaultTeamNamespace(team, "prod"), nil)
+	expectedNS.Status.Phase = corev1.NamespaceActive
+	f.expectUpdateNamespaceAction(expectedNS)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	expectedTeam.Status.Environments[0].PodSecurityViolations = []string{`debug: privileged container "app"`}
+	expectedTeam.Status.Conditions[5] = newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionFalse, reasonPodSecurityViolated,
+		"1 pods violate the enforced pod security level", testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
 
 	f.run(team.Name)
 }
@@ -300,22 +694,24 @@ func TestUpdateRQLabels(t *testing.T) {
 	f.addObj(team)
 
 	//Create team
-	f.addObj(newNamespace(team))
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
 
 	//Create namespace with invalid labels
-	rq := newResourceQuota(team)
+	rq := newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0]
 	rq.Labels["env"] = "prod"
 	rq.Labels["other"] = "other"
 	f.addObj(rq)
 
 	//expect rq update
-	expectedNS := newResourceQuota(team)
+	expectedNS := newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0]
 	expectedNS.Labels["other"] = "other"
 	f.expectUpdateResourceQuotaAction(expectedNS)
 
-	team.Status.Namespace = "team-test-dev"
-	team.Status.ResourceQuota = rqName
-	f.expectUpdateTeamStatus(team)
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
 
 	f.run(team.Name)
 }
@@ -332,10 +728,12 @@ func TestUpdateRQSpec(t *testing.T) {
 	f.addObj(team)
 
 	//Create team
-	f.addObj(newNamespace(team))
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
 
 	//Create namespace with invalid labels
-	rq := newResourceQuota(team)
+	rq := newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0]
 	rq.Spec = corev1.ResourceQuotaSpec{
 		Hard: corev1.ResourceList{
 			corev1.ResourceCPU: *resource.NewQuantity(5, resource.DecimalSI),
@@ -344,12 +742,646 @@ func TestUpdateRQSpec(t *testing.T) {
 	f.addObj(rq)
 
 	//expect rq update
-	expectedNS := newResourceQuota(team)
+	expectedNS := newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0]
 	f.expectUpdateResourceQuotaAction(expectedNS)
 
-	team.Status.Namespace = "team-test-dev"
-	team.Status.ResourceQuota = rqName
-	f.expectUpdateTeamStatus(team)
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestNamespaceConflict(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	f.addObj(team)
+
+	//Namespace exists and is not owned by the team
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.OwnerReferences = nil
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+
+	msg := `Resource "team-test-dev" already exists and is not managed by Team`
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Phase = aftouhv1.TeamPhaseActive
+	expectedTeam.Status.Environments = []aftouhv1.EnvironmentStatus{{Name: "dev"}}
+	expectedTeam.Status.Conditions = []aftouhv1.TeamCondition{
+		newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonSyncFailed,
+			"Failed syncing team namespace: "+msg, testTime),
+		newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionFalse, errResourceExists, msg, testTime),
+		newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionFalse, reasonNotFound, "", testTime),
+		newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionTrue, errResourceExists, msg, testTime),
+		newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionFalse, "", "", testTime),
+	}
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.runExpectError(team.Name)
+}
+
+func TestUnchangedTeamStatus(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	//No action expected: everything is synced and status is up to date
+	f.run(team.Name)
+}
+
+func TestCreateEnvironmentNamespaces(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "", corev1.ResourceQuotaSpec{})
+	team.Spec.Environments = []aftouhv1.TeamEnvironment{{Name: "dev"}, {Name: "prod"}}
+	f.addObj(team)
+
+	//dev environment is already synced
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, team.Spec.Environments[0], defaultTeamNamespace(team, team.Spec.Environments[0].Name), nil)[0])
+
+	f.expectCreateNamespaceAction(newNamespace(team, "prod", defaultTeamNamespace(team, "prod"), nil))
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Phase = aftouhv1.TeamPhaseActive
+	expectedTeam.Status.Environments = []aftouhv1.EnvironmentStatus{
+		{Name: "dev", Namespace: "team-test-dev", ResourceQuotas: []string{rqName}},
+		{Name: "prod"},
+	}
+	expectedTeam.Status.Conditions = []aftouhv1.TeamCondition{
+		newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonSyncFailed,
+			`Failed syncing team resourcequota: namespace "team-test-prod" not found`, testTime),
+		newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionFalse, reasonNotFound,
+			`Namespace "team-test-prod" does not exist`, testTime),
+		newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionFalse, reasonNotFound, "", testTime),
+		newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionFalse, "", "", testTime),
+	}
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.runExpectError(team.Name)
+}
+
+func TestPruneEnvironmentNamespace(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	//staging environment has been removed from the team
+	oldNS := newNamespace(team, "staging", defaultTeamNamespace(team, "staging"), nil)
+	oldNS.Status.Phase = corev1.NamespaceActive
+	f.addObj(oldNS)
+
+	f.expectDeleteNamespaceAction(oldNS)
+
+	f.run(team.Name)
+}
+
+func TestCreateDefaultResourceQuota(t *testing.T) {
+	f := newFixture(t)
+	f.defaults.ResourceQuotaSpec = corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{
+			corev1.ResourcePods: *resource.NewQuantity(10, resource.DecimalSI),
+		},
+	}
+
+	//Team without hard limits gets the default resourcequota
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Spec.ResourceQuotaSpec = f.defaults.ResourceQuotaSpec
+	f.expectCreateResourceQuotaAction(newResourceQuotas(expectedTeam, getTeamEnvironments(expectedTeam)[0], defaultTeamNamespace(expectedTeam, getTeamEnvironments(expectedTeam)[0].Name), nil)[0])
+
+	expectedTeam.Status.ResourceQuotas = nil
+	expectedTeam.Status.Environments[0].ResourceQuotas = nil
+	expectedTeam.Status.Conditions = []aftouhv1.TeamCondition{
+		newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonNotFound,
+			"ResourceQuota team-test-dev/team-default-rq does not exist", testTime),
+		newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionTrue, reasonNamespaceActive, "", testTime),
+		newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionFalse, reasonNotFound,
+			"ResourceQuota team-test-dev/team-default-rq does not exist", testTime),
+		newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionFalse, "", "", testTime),
+	}
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestUpdateResourceQuotaFromClass(t *testing.T) {
+	f := newFixture(t)
+
+	class := &aftouhv1.TeamClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "standard"},
+		Spec: aftouhv1.TeamClassSpec{
+			ResourceQuotaSpec: corev1.ResourceQuotaSpec{
+				Hard: corev1.ResourceList{
+					corev1.ResourcePods: *resource.NewQuantity(10, resource.DecimalSI),
+					corev1.ResourceCPU:  *resource.NewQuantity(4, resource.DecimalSI),
+				},
+			},
+			Labels:      map[string]string{"cost-center": "42", "team": "ignored"},
+			Annotations: map[string]string{"owner": "platform"},
+		},
+	}
+	f.addObj(class)
+
+	//Team overrides the class pods limit
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{
+			corev1.ResourcePods: *resource.NewQuantity(4, resource.DecimalSI),
+		},
+	})
+	team.Spec.ClassName = "standard"
+	team.Status = readyStatus(team)
+	f.addObj(team)
+
+	//Namespace and resourcequota created before the team had a class
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	expectedNS := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), class)
+	expectedNS.Status.Phase = corev1.NamespaceActive
+	if expectedNS.Labels["team"] != "test" || expectedNS.Labels["cost-center"] != "42" {
+		t.Errorf("expected team labels to take precedence over class labels, got %v", expectedNS.Labels)
+	}
+	f.expectUpdateNamespaceAction(expectedNS)
+
+	expectedRQ := newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), class)[0]
+	expectedRQ.Spec.Hard = corev1.ResourceList{
+		corev1.ResourcePods: *resource.NewQuantity(4, resource.DecimalSI),
+		corev1.ResourceCPU:  *resource.NewQuantity(4, resource.DecimalSI),
+	}
+	f.expectUpdateResourceQuotaAction(expectedRQ)
+
+	f.run(team.Name)
+}
+
+func TestMissingTeamClass(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.ClassName = "missing"
+	f.addObj(team)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Phase = aftouhv1.TeamPhaseActive
+	expectedTeam.Status.Environments = []aftouhv1.EnvironmentStatus{{Name: "dev"}}
+	expectedTeam.Status.Conditions = []aftouhv1.TeamCondition{
+		newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonSyncFailed, `TeamClass "missing" not found`, testTime),
+		newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionFalse, reasonNotFound,
+			`Namespace "team-test-dev" does not exist`, testTime),
+		newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionFalse, reasonNotFound, "", testTime),
+		newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+		newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionFalse, "", "", testTime),
+		newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionFalse, "", "", testTime),
+	}
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.runExpectError(team.Name)
+}
+
+func TestEnqueueClassTeams(t *testing.T) {
+	f := newFixture(t)
+
+	class := &aftouhv1.TeamClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}}
+	withClass := newTeam("with-class", "", "dev", corev1.ResourceQuotaSpec{})
+	withClass.Spec.ClassName = "standard"
+	f.addObj(withClass)
+	f.addObj(newTeam("without-class", "", "dev", corev1.ResourceQuotaSpec{}))
+
+	tc, _, _ := f.newTeamController()
+	tc.enqueueClassTeams(class)
+
+	if tc.queue.Len() != 1 {
+		t.Fatalf("expected 1 team to be enqueued, got %d", tc.queue.Len())
+	}
+	if key, _ := tc.queue.Get(); key != "with-class" {
+		t.Errorf("expected team %q to be enqueued, got %v", "with-class", key)
+	}
+}
+
+func TestCreateRoleBindings(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.Members = []aftouhv1.TeamMember{
+		{Kind: aftouhv1.MemberKindUser, Name: "alice", Role: "admin"},
+		{Kind: aftouhv1.MemberKindGroup, Name: "developers", Role: "edit"},
+		{Kind: aftouhv1.MemberKindServiceAccount, Name: "deployer", Role: "edit"},
+	}
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	rbs := newRoleBindings(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	if len(rbs) != 2 {
+		t.Fatalf("expected 2 rolebindings, got %d", len(rbs))
+	}
+	expectedSubjects := []rbacv1.Subject{
+		{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: "developers"},
+		{Kind: rbacv1.ServiceAccountKind, Name: "deployer", Namespace: "team-test-dev"},
+	}
+	if !reflect.DeepEqual(rbs["team-edit"].Subjects, expectedSubjects) {
+		t.Errorf("expected subjects %+v, got %+v", expectedSubjects, rbs["team-edit"].Subjects)
+	}
+
+	f.expectCreateRoleBindingAction(rbs["team-admin"])
+	f.expectCreateRoleBindingAction(rbs["team-edit"])
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestUpdateRoleBindingSubjects(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.Members = []aftouhv1.TeamMember{{Kind: aftouhv1.MemberKindUser, Name: "alice", Role: "view"}}
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	//RoleBinding manually edited
+	rb := newRoleBindings(team, "dev", defaultTeamNamespace(team, "dev"), nil)["team-view"]
+	rb.Subjects = append(rb.Subjects, rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "mallory"})
+	f.addObj(rb)
+
+	f.expectUpdateRoleBindingAction(newRoleBindings(team, "dev", defaultTeamNamespace(team, "dev"), nil)["team-view"])
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestPruneRoleBinding(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	//RoleBinding of a removed member
+	withMember := team.DeepCopy()
+	withMember.Spec.Members = []aftouhv1.TeamMember{{Kind: aftouhv1.MemberKindUser, Name: "alice", Role: "admin"}}
+	rb := newRoleBindings(withMember, "dev", defaultTeamNamespace(withMember, "dev"), nil)["team-admin"]
+	f.addObj(rb)
+
+	//RoleBinding not managed by the team is kept
+	f.addObj(&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-test-dev"}})
+
+	f.expectDeleteRoleBindingAction(rb)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestForbiddenMemberRole(t *testing.T) {
+	f := newFixture(t)
+	f.recorder = record.NewFakeRecorder(10)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.Members = []aftouhv1.TeamMember{
+		{Kind: aftouhv1.MemberKindUser, Name: "alice", Role: "cluster-admin"},
+		{Kind: aftouhv1.MemberKindUser, Name: "bob", Role: "view"},
+	}
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], ns.Name, nil)[0])
+
+	//The rolebinding of the role that is no longer allowed is pruned
+	rbs := newRoleBindings(team, "dev", ns.Name, nil)
+	f.addObj(rbs["team-cluster-admin"])
+	f.addObj(rbs["team-view"])
+
+	f.expectDeleteRoleBindingAction(rbs["team-cluster-admin"])
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+	expectEvents(t, f.recorder, `Warning RoleForbidden Members with role "cluster-admin" are not bound, the role may not be given to team members`)
+}
+
+func newTestLimitRangeSpec(cpu int64) *corev1.LimitRangeSpec {
+	return &corev1.LimitRangeSpec{
+		Limits: []corev1.LimitRangeItem{{
+			Type: corev1.LimitTypeContainer,
+			DefaultRequest: corev1.ResourceList{
+				corev1.ResourceCPU: *resource.NewQuantity(cpu, resource.DecimalSI),
+			},
+		}},
+	}
+}
+
+func TestCreateLimitRange(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.LimitRange = newTestLimitRangeSpec(1)
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	f.expectCreateLimitRangeAction(newLimitRange(team, "dev", defaultTeamNamespace(team, "dev"), nil))
+
+	//The new limitrange is not visible by lister yet
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	expectedTeam.Status.Conditions[0] = newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonNotFound,
+		"LimitRange team-test-dev/team-default-lr does not exist", testTime)
+	expectedTeam.Status.Conditions[3] = newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionFalse, reasonNotFound,
+		"LimitRange team-test-dev/team-default-lr does not exist", testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestUpdateLimitRangeFromClass(t *testing.T) {
+	f := newFixture(t)
+
+	class := &aftouhv1.TeamClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "standard"},
+		Spec:       aftouhv1.TeamClassSpec{LimitRange: newTestLimitRangeSpec(1)},
+	}
+	f.addObj(class)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.ClassName = "standard"
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), class)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), class)[0])
+
+	//LimitRange manually edited
+	lr := newLimitRange(team, "dev", defaultTeamNamespace(team, "dev"), class)
+	lr.Spec = *newTestLimitRangeSpec(2)
+	f.addObj(lr)
+
+	f.expectUpdateLimitRangeAction(newLimitRange(team, "dev", defaultTeamNamespace(team, "dev"), class))
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	expectedTeam.Status.Environments[0].LimitRange = lrName
+	expectedTeam.Status.Conditions[3].Reason = reasonLimitRangeCreated
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestLimitRangeConflict(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.LimitRange = newTestLimitRangeSpec(1)
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	//LimitRange not managed by the team
+	f.addObj(&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: lrName, Namespace: "team-test-dev"}})
+
+	msg := `Resource "team-default-lr" already exists and is not managed by Team`
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	expectedTeam.Status.Conditions[0] = newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonSyncFailed,
+		"Failed syncing team limitrange: "+msg, testTime)
+	expectedTeam.Status.Conditions[3] = newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionFalse, errResourceExists, msg, testTime)
+	expectedTeam.Status.Conditions[4] = newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionTrue, errResourceExists, msg, testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.runExpectError(team.Name)
+}
+
+func TestDeleteLimitRange(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	//LimitRange created before spec.limitRange was removed
+	withLimitRange := team.DeepCopy()
+	withLimitRange.Spec.LimitRange = newTestLimitRangeSpec(1)
+	lr := newLimitRange(withLimitRange, "dev", defaultTeamNamespace(withLimitRange, "dev"), nil)
+	f.addObj(lr)
+
+	f.expectDeleteLimitRangeAction(lr)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestCreateTeamIsolatedNetworkPolicies(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.NetworkPolicy = &aftouhv1.TeamNetworkPolicy{
+		Mode:              aftouhv1.NetworkPolicyTeamIsolated,
+		AllowedNamespaces: []metav1.LabelSelector{{MatchLabels: map[string]string{"name": "monitoring"}}},
+	}
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	nps := newNetworkPolicies(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	if len(nps) != 4 {
+		t.Fatalf("expected 4 networkpolicies, got %d", len(nps))
+	}
+	teamPeer := nps[2].Spec.Ingress[0].From[0]
+	if !reflect.DeepEqual(teamPeer.NamespaceSelector.MatchLabels, map[string]string{"team": "test"}) {
+		t.Errorf("expected namespaces of team %q to be allowed, got %+v", "test", teamPeer.NamespaceSelector)
+	}
+	for _, np := range nps {
+		f.expectCreateNetworkPolicyAction(np)
+	}
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestUpdateNetworkPolicy(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.NetworkPolicy = &aftouhv1.TeamNetworkPolicy{Mode: aftouhv1.NetworkPolicyIsolated}
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	nps := newNetworkPolicies(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	f.addObj(nps[0])
+	//Deny policy manually opened to all the traffic
+	opened := nps[1].DeepCopy()
+	opened.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{}}
+	f.addObj(opened)
+
+	f.expectUpdateNetworkPolicyAction(nps[1])
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestPruneNetworkPolicies(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.NetworkPolicy = &aftouhv1.TeamNetworkPolicy{Mode: aftouhv1.NetworkPolicyIsolated}
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	//Policies created while other namespaces were allowed
+	withAllowed := team.DeepCopy()
+	withAllowed.Spec.NetworkPolicy.AllowedNamespaces = []metav1.LabelSelector{{MatchLabels: map[string]string{"name": "monitoring"}}}
+	nps := newNetworkPolicies(withAllowed, "dev", defaultTeamNamespace(withAllowed, "dev"), nil)
+	for _, np := range nps {
+		f.addObj(np)
+	}
+
+	f.expectDeleteNetworkPolicyAction(nps[2])
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestCreateScopedResourceQuota(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.ResourceQuotas = []aftouhv1.TeamResourceQuota{{
+		Name: "best-effort",
+		ResourceQuotaSpec: corev1.ResourceQuotaSpec{
+			Hard:   corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(2, resource.DecimalSI)},
+			Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort},
+		},
+	}}
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	rqs := newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)
+	f.addObj(rqs[0])
+
+	f.expectCreateResourceQuotaAction(rqs[1])
+
+	//The new resourcequota is not visible by lister yet
+	msg := "ResourceQuota team-test-dev/team-best-effort-rq does not exist"
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	expectedTeam.Status.Conditions[0] = newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonNotFound, msg, testTime)
+	expectedTeam.Status.Conditions[2] = newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionFalse, reasonNotFound, msg, testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestPruneResourceQuota(t *testing.T) {
+	f := newFixture(t)
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, getTeamEnvironments(team)[0].Name), nil)[0])
+
+	//ResourceQuota of a removed quota
+	removed := newResourceQuota(team, "dev", defaultTeamNamespace(team, "dev"), aftouhv1.TeamResourceQuota{Name: "objects"}, nil)
+	f.addObj(removed)
+
+	f.expectDeleteResourceQuotaAction(removed)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = readyStatus(team)
+	f.expectUpdateTeamStatus(expectedTeam)
 
 	f.run(team.Name)
 }
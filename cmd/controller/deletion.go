@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+)
+
+//hasFinalizer returns true if the team carries the controller finalizer
+func hasFinalizer(t *aftouhv1.Team) bool {
+	for _, f := range t.Finalizers {
+		if f == aftouhv1.TeamFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+//addFinalizer adds the controller finalizer to the team.
+//The team update triggers a new sync of the team
+func (tc *TeamController) addFinalizer(t *aftouhv1.Team) error {
+	t = t.DeepCopy()
+	t.Finalizers = append(t.Finalizers, aftouhv1.TeamFinalizer)
+	klog.V(2).Infof("Adding finalizer to team %q", t.Name)
+	if _, err := tc.tClientSet.AftouhV1().Teams().Update(t); err != nil {
+		return fmt.Errorf("Failed adding finalizer to team %q: %v", t.Name, err)
+	}
+	return nil
+}
+
+//removeFinalizer removes the controller finalizer so that the team deletion completes
+func (tc *TeamController) removeFinalizer(t *aftouhv1.Team) error {
+	t = t.DeepCopy()
+	var finalizers []string
+	for _, f := range t.Finalizers {
+		if f != aftouhv1.TeamFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	t.Finalizers = finalizers
+	klog.V(2).Infof("Removing finalizer of team %q", t.Name)
+	if _, err := tc.tClientSet.AftouhV1().Teams().Update(t); err != nil {
+		return fmt.Errorf("Failed removing finalizer of team %q: %v", t.Name, err)
+	}
+	return nil
+}
+
+//finalizeTeam applies the deletion policy of a deleted team, then releases its finalizer
+func (tc *TeamController) finalizeTeam(t *aftouhv1.Team) error {
+	if !hasFinalizer(t) {
+		return nil
+	}
+
+	namespaces, err := tc.getOwnedNamespaces(t)
+	if err != nil {
+		return err
+	}
+
+	switch t.Spec.DeletionPolicy {
+	case aftouhv1.DeletionPolicyRetain, aftouhv1.DeletionPolicyOrphan:
+		var errs []error
+		for _, ns := range namespaces {
+			//Objects managed in orphaned namespaces keep their owner reference and are garbage collected with the team
+			if t.Spec.DeletionPolicy == aftouhv1.DeletionPolicyRetain {
+				if err := tc.releaseNamespaceObjects(t, ns.Name); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+			}
+			ns = ns.DeepCopy()
+			releaseObject(t, ns)
+			klog.V(2).Infof("Releasing namespace %q of deleted team %q", ns.Name, t.Name)
+			if _, err := tc.kClientSet.CoreV1().Namespaces().Update(ns); err != nil {
+				errs = append(errs, fmt.Errorf("Failed releasing namespace %q: %v", ns.Name, err))
+				continue
+			}
+			tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonNamespaceReleased, "Namespace %q is kept (%s deletion policy)", ns.Name, t.Spec.DeletionPolicy)
+		}
+		if err := utilerrors.NewAggregate(errs); err != nil {
+			return err
+		}
+
+	default:
+		var terminating []string
+		for _, ns := range namespaces {
+			terminating = append(terminating, ns.Name)
+			if ns.DeletionTimestamp != nil {
+				continue
+			}
+			klog.V(2).Infof("Deleting namespace %q of deleted team %q", ns.Name, t.Name)
+			if err := tc.kClientSet.CoreV1().Namespaces().Delete(ns.Name, &metav1.DeleteOptions{}); err != nil {
+				return fmt.Errorf("Failed deleting namespace %q: %v", ns.Name, err)
+			}
+		}
+
+		//The finalizer is kept until the namespaces are gone. Their deletion enqueues the team again
+		if len(terminating) > 0 {
+			sort.Strings(terminating)
+			ts := *t.Status.DeepCopy()
+			setTeamCondition(&ts, newTeamCondition(aftouhv1.TeamTerminating, corev1.ConditionTrue, reasonNamespaceTerminating,
+				fmt.Sprintf("Waiting for namespaces %s to terminate", strings.Join(terminating, ", ")), metav1.NewTime(tc.clock.Now())))
+			return tc.updateTeamStatus(t, ts)
+		}
+	}
+
+	return tc.removeFinalizer(t)
+}
+
+//getOwnedNamespaces returns the namespaces controlled by the team
+func (tc *TeamController) getOwnedNamespaces(t *aftouhv1.Team) ([]*corev1.Namespace, error) {
+	allNS, err := tc.nLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list namespaces from store: %v", err)
+	}
+	var namespaces []*corev1.Namespace
+	for _, ns := range allNS {
+		if metav1.IsControlledBy(ns, t) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces, nil
+}
+
+//releaseNamespaceObjects removes the team owner reference of the objects managed in the namespace
+func (tc *TeamController) releaseNamespaceObjects(t *aftouhv1.Team, namespace string) error {
+	var errs []error
+
+	rqs, err := tc.rqLister.ResourceQuotas(namespace).List(labels.Everything())
+	errs = append(errs, err)
+	for _, rq := range rqs {
+		if metav1.IsControlledBy(rq, t) {
+			rq = rq.DeepCopy()
+			releaseObject(t, rq)
+			_, err := tc.kClientSet.CoreV1().ResourceQuotas(namespace).Update(rq)
+			errs = append(errs, err)
+		}
+	}
+
+	lrs, err := tc.lrLister.LimitRanges(namespace).List(labels.Everything())
+	errs = append(errs, err)
+	for _, lr := range lrs {
+		if metav1.IsControlledBy(lr, t) {
+			lr = lr.DeepCopy()
+			releaseObject(t, lr)
+			_, err := tc.kClientSet.CoreV1().LimitRanges(namespace).Update(lr)
+			errs = append(errs, err)
+		}
+	}
+
+	nps, err := tc.npLister.NetworkPolicies(namespace).List(labels.Everything())
+	errs = append(errs, err)
+	for _, np := range nps {
+		if metav1.IsControlledBy(np, t) {
+			np = np.DeepCopy()
+			releaseObject(t, np)
+			_, err := tc.kClientSet.NetworkingV1().NetworkPolicies(namespace).Update(np)
+			errs = append(errs, err)
+		}
+	}
+
+	rbs, err := tc.rbLister.RoleBindings(namespace).List(labels.Everything())
+	errs = append(errs, err)
+	for _, rb := range rbs {
+		if metav1.IsControlledBy(rb, t) {
+			rb = rb.DeepCopy()
+			releaseObject(t, rb)
+			_, err := tc.kClientSet.RbacV1().RoleBindings(namespace).Update(rb)
+			errs = append(errs, err)
+		}
+	}
+
+	sas, err := tc.saLister.ServiceAccounts(namespace).List(labels.Everything())
+	errs = append(errs, err)
+	for _, sa := range sas {
+		if metav1.IsControlledBy(sa, t) {
+			sa = sa.DeepCopy()
+			releaseObject(t, sa)
+			_, err := tc.kClientSet.CoreV1().ServiceAccounts(namespace).Update(sa)
+			errs = append(errs, err)
+		}
+	}
+
+	secrets, err := tc.secretLister.Secrets(namespace).List(labels.Everything())
+	errs = append(errs, err)
+	for _, secret := range secrets {
+		if metav1.IsControlledBy(secret, t) {
+			secret = secret.DeepCopy()
+			releaseObject(t, secret)
+			_, err := tc.kClientSet.CoreV1().Secrets(namespace).Update(secret)
+			errs = append(errs, err)
+		}
+	}
+
+	cms, err := tc.cmLister.ConfigMaps(namespace).List(labels.Everything())
+	errs = append(errs, err)
+	for _, cm := range cms {
+		if metav1.IsControlledBy(cm, t) {
+			cm = cm.DeepCopy()
+			releaseObject(t, cm)
+			_, err := tc.kClientSet.CoreV1().ConfigMaps(namespace).Update(cm)
+			errs = append(errs, err)
+		}
+	}
+
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return fmt.Errorf("Failed releasing objects of namespace %q: %v", namespace, err)
+	}
+	return nil
+}
+
+//releaseObject removes the team owner reference of the object and marks it as formerly owned by the team
+func releaseObject(t *aftouhv1.Team, obj metav1.Object) {
+	var refs []metav1.OwnerReference
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID != t.UID {
+			refs = append(refs, ref)
+		}
+	}
+	obj.SetOwnerReferences(refs)
+	obj.SetAnnotations(mergeKeys(obj.GetAnnotations(), map[string]string{aftouhv1.FormerlyOwnedByAnnotation: t.Name}))
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+//newDeletedTeam returns a team being deleted with its synced namespace and resourcequota
+func newDeletedTeam(f *fixture, policy aftouhv1.DeletionPolicy) (*aftouhv1.Team, *corev1.Namespace, *corev1.ResourceQuota) {
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.UID = "test-uid"
+	team.Spec.DeletionPolicy = policy
+	team.Status = readyStatus(team)
+	team.DeletionTimestamp = &testTime
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	rq := newResourceQuotas(team, getTeamEnvironments(team)[0], ns.Name, nil)[0]
+	f.addObj(rq)
+	return team, ns, rq
+}
+
+func TestAddTeamFinalizer(t *testing.T) {
+	f := newFixture(t)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Finalizers = nil
+	f.addObj(team)
+
+	//Team is synced again once the finalizer is added
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Finalizers = []string{aftouhv1.TeamFinalizer}
+	f.expectUpdateTeam(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestDeleteTeamNamespaces(t *testing.T) {
+	f := newFixture(t)
+	team, ns, _ := newDeletedTeam(f, "")
+
+	f.expectDeleteNamespaceAction(ns)
+
+	//Finalizer is kept while the namespace terminates
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Conditions = append(expectedTeam.Status.Conditions, newTeamCondition(aftouhv1.TeamTerminating, corev1.ConditionTrue,
+		reasonNamespaceTerminating, `Waiting for namespaces team-test-dev to terminate`, testTime))
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestTerminatingTeamNamespaces(t *testing.T) {
+	f := newFixture(t)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.DeletionTimestamp = &testTime
+	team.Status = readyStatus(team)
+	team.Status.Conditions = append(team.Status.Conditions, newTeamCondition(aftouhv1.TeamTerminating, corev1.ConditionTrue,
+		reasonNamespaceTerminating, `Waiting for namespaces team-test-dev to terminate`, testTime))
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.DeletionTimestamp = &testTime
+	ns.Status.Phase = corev1.NamespaceTerminating
+	f.addObj(ns)
+
+	//Nothing to do until the namespace is gone
+	f.run(team.Name)
+}
+
+func TestReleaseTeamFinalizer(t *testing.T) {
+	f := newFixture(t)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.DeletionTimestamp = &testTime
+	f.addObj(team)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Finalizers = nil
+	f.expectUpdateTeam(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestRetainTeamNamespaces(t *testing.T) {
+	f := newFixture(t)
+	team, ns, rq := newDeletedTeam(f, aftouhv1.DeletionPolicyRetain)
+
+	//Objects managed in the namespace are released first
+	expectedRq := rq.DeepCopy()
+	expectedRq.OwnerReferences = nil
+	expectedRq.Annotations[aftouhv1.FormerlyOwnedByAnnotation] = team.Name
+	f.expectUpdateResourceQuotaAction(expectedRq)
+
+	expectedNS := ns.DeepCopy()
+	expectedNS.OwnerReferences = nil
+	expectedNS.Annotations[aftouhv1.FormerlyOwnedByAnnotation] = team.Name
+	f.expectUpdateNamespaceAction(expectedNS)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Finalizers = nil
+	f.expectUpdateTeam(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestOrphanTeamNamespaces(t *testing.T) {
+	f := newFixture(t)
+	team, ns, _ := newDeletedTeam(f, aftouhv1.DeletionPolicyOrphan)
+
+	//The resourcequota keeps its owner reference and is garbage collected with the team
+	expectedNS := ns.DeepCopy()
+	expectedNS.OwnerReferences = nil
+	expectedNS.Annotations[aftouhv1.FormerlyOwnedByAnnotation] = team.Name
+	f.expectUpdateNamespaceAction(expectedNS)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Finalizers = nil
+	f.expectUpdateTeam(expectedTeam)
+
+	f.run(team.Name)
+}
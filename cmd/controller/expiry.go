@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/klog"
+)
+
+//expiryWarningPeriod is how long before its expiry a team gets the Expiring condition and a warning event
+const expiryWarningPeriod = 24 * time.Hour
+
+//getTeamExpiry returns the time the team expires at, or nil if it does not expire.
+//An invalid ExtendExpiryAnnotation is ignored, the webhook rejects it
+func getTeamExpiry(t *aftouhv1.Team) *metav1.Time {
+	var expiry time.Time
+	switch {
+	case t.Spec.ExpiresAt != nil:
+		expiry = t.Spec.ExpiresAt.Time
+	case t.Spec.TTL != nil:
+		expiry = t.CreationTimestamp.Add(t.Spec.TTL.Duration)
+	default:
+		return nil
+	}
+
+	if ext, ok := t.Annotations[aftouhv1.ExtendExpiryAnnotation]; ok {
+		d, err := time.ParseDuration(ext)
+		if err != nil || d < 0 {
+			klog.V(2).Infof("Ignoring invalid %s annotation %q of team %q", aftouhv1.ExtendExpiryAnnotation, ext, t.Name)
+		} else {
+			expiry = expiry.Add(d)
+		}
+	}
+	return &metav1.Time{Time: expiry}
+}
+
+//syncExpiry deletes the team once it has expired, the team finalizer then applies its deletion policy.
+//Teams that have not expired yet are requeued for the start of the warning period, for their expiry and when their
+//time left changes
+func (tc *TeamController) syncExpiry(t *aftouhv1.Team) (bool, error) {
+	expiry := getTeamExpiry(t)
+	if expiry == nil {
+		return false, nil
+	}
+
+	now := tc.clock.Now()
+	if left := expiry.Sub(now); left > 0 {
+		if left > expiryWarningPeriod {
+			left -= expiryWarningPeriod
+		}
+		if _, refresh := getTimeLeft(expiry.Time, now); refresh < left {
+			left = refresh
+		}
+		tc.queue.AddAfter(t.Name, left)
+		return false, nil
+	}
+
+	klog.V(2).Infof("Deleting expired team %q", t.Name)
+	tc.recorder.Eventf(t, corev1.EventTypeWarning, reasonTeamExpired, "Team expired at %s and is deleted", formatTime(*expiry))
+	err := tc.tClientSet.AftouhV1().Teams().Delete(t.Name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return true, fmt.Errorf("Failed deleting expired team: %v", err)
+	}
+	return true, nil
+}
+
+//getExpiringCondition returns the Expiring condition of the team.
+//A warning event is recorded when the team enters the warning period
+func (tc *TeamController) getExpiringCondition(t *aftouhv1.Team, expiry *metav1.Time, now metav1.Time) aftouhv1.TeamCondition {
+	cond := newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionFalse, "", "", now)
+	if expiry == nil {
+		return cond
+	}
+
+	cond.Reason, cond.Message = reasonExpiryScheduled, fmt.Sprintf("Team expires at %s", formatTime(*expiry))
+	if expiry.Sub(now.Time) > expiryWarningPeriod {
+		return cond
+	}
+	cond.Status, cond.Reason = corev1.ConditionTrue, reasonExpiresSoon
+
+	if c := getTeamCondition(t.Status, aftouhv1.TeamExpiring); c == nil || c.Status != corev1.ConditionTrue {
+		tc.recorder.Eventf(t, corev1.EventTypeWarning, reasonExpiresSoon, "Team expires in %s, at %s. Set the %s annotation to extend it",
+			formatTimeLeft(expiry.Sub(now.Time)), formatTime(*expiry), aftouhv1.ExtendExpiryAnnotation)
+	}
+	return cond
+}
+
+//getTimeLeft returns the time left before the expiry rounded up to the hour, or to the minute in the last hour, and
+//the delay before the rounded value changes. The status is only written again when the rounded value changes
+func getTimeLeft(expiry, now time.Time) (string, time.Duration) {
+	left := expiry.Sub(now)
+	if left <= 0 {
+		return formatTimeLeft(left), 0
+	}
+	unit := time.Hour
+	if left <= time.Hour {
+		unit = time.Minute
+	}
+	rounded := (left + unit - 1).Truncate(unit)
+	return formatTimeLeft(rounded), left - (rounded - unit)
+}
+
+//formatTimeLeft returns the time left rounded up to the minute, e.g. 5h or 2d
+func formatTimeLeft(d time.Duration) string {
+	if d <= 0 {
+		return "0s"
+	}
+	return duration.ShortHumanDuration((d + time.Minute - 1).Truncate(time.Minute))
+}
+
+//formatTime formats the time as RFC3339 in UTC
+func formatTime(t metav1.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestGetTeamExpiry(t *testing.T) {
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.CreationTimestamp = testTime
+	if expiry := getTeamExpiry(team); expiry != nil {
+		t.Errorf("expected a team without ttl not to expire, got %v", expiry)
+	}
+
+	team.Spec.TTL = &metav1.Duration{Duration: 2 * time.Hour}
+	if expiry := getTeamExpiry(team); expiry == nil || !expiry.Equal(&metav1.Time{Time: testTime.Add(2 * time.Hour)}) {
+		t.Errorf("expected the ttl to start at the team creation, got %v", expiry)
+	}
+
+	expiresAt := metav1.NewTime(testTime.Add(time.Hour))
+	team.Spec.TTL, team.Spec.ExpiresAt = nil, &expiresAt
+	team.Annotations = map[string]string{aftouhv1.ExtendExpiryAnnotation: "48h"}
+	if expiry := getTeamExpiry(team); expiry == nil || !expiry.Equal(&metav1.Time{Time: testTime.Add(49 * time.Hour)}) {
+		t.Errorf("expected the expiry to be extended by 48h, got %v", expiry)
+	}
+
+	team.Annotations[aftouhv1.ExtendExpiryAnnotation] = "two days"
+	if expiry := getTeamExpiry(team); expiry == nil || !expiry.Equal(&expiresAt) {
+		t.Errorf("expected an invalid extension to be ignored, got %v", expiry)
+	}
+}
+
+func TestExpiredTeam(t *testing.T) {
+	f := newFixture(t)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.CreationTimestamp = metav1.NewTime(testTime.Add(-2 * time.Hour))
+	team.Spec.TTL = &metav1.Duration{Duration: time.Hour}
+	team.Status = readyStatus(team)
+	f.addObj(team)
+
+	//The team is deleted without being synced, its finalizer applies the deletion policy
+	f.expectDeleteTeam(team)
+
+	f.run(team.Name)
+}
+
+func TestExpiringTeam(t *testing.T) {
+	f := newFixture(t)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	expiresAt := metav1.NewTime(testTime.Add(5*time.Hour + 30*time.Second))
+	team.Spec.ExpiresAt = &expiresAt
+	team.Status = readyStatus(team)
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], ns.Name, nil)[0])
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.ExpiresAt = &expiresAt
+	expectedTeam.Status.TimeLeft = "6h"
+	expectedTeam.Status.Conditions[9] = newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionTrue, reasonExpiresSoon,
+		"Team expires at 2020-05-01T15:00:30Z", testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestExpiringTeamUpToDate(t *testing.T) {
+	f := newFixture(t)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	expiresAt := metav1.NewTime(testTime.Add(5 * time.Hour))
+	team.Spec.ExpiresAt = &expiresAt
+	team.Status = readyStatus(team)
+	team.Status.ExpiresAt = &expiresAt
+	team.Status.TimeLeft = "5h"
+	team.Status.Conditions[9] = newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionTrue, reasonExpiresSoon,
+		"Team expires at 2020-05-01T15:00:00Z", metav1.NewTime(testTime.Add(-time.Hour)))
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], ns.Name, nil)[0])
+
+	//The status does not change while the team gets closer to its expiry
+	f.run(team.Name)
+}
+
+func TestExpiringCondition(t *testing.T) {
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	tc, _, _ := newFixture(t).newTeamController()
+	recorder := record.NewFakeRecorder(10)
+	tc.recorder = recorder
+
+	expiry := metav1.NewTime(testTime.Add(48 * time.Hour))
+	cond := tc.getExpiringCondition(team, &expiry, testTime)
+	if cond.Status != corev1.ConditionFalse || cond.Reason != reasonExpiryScheduled {
+		t.Errorf("expected the expiry to be scheduled, got %+v", cond)
+	}
+	expectEvents(t, recorder)
+
+	expiry = metav1.NewTime(testTime.Add(90 * time.Minute))
+	cond = tc.getExpiringCondition(team, &expiry, testTime)
+	expected := newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionTrue, reasonExpiresSoon, "Team expires at 2020-05-01T11:30:00Z", testTime)
+	if !reflect.DeepEqual(cond, expected) {
+		t.Errorf("expected condition %+v, got %+v", expected, cond)
+	}
+	expectEvents(t, recorder, "Warning ExpiresSoon Team expires in 1h, at 2020-05-01T11:30:00Z. Set the aftouh.io/extend-expiry annotation to extend it")
+
+	//The warning is only recorded once
+	setTeamCondition(&team.Status, cond)
+	tc.getExpiringCondition(team, &expiry, testTime)
+	expectEvents(t, recorder)
+}
+
+func TestGetTimeLeft(t *testing.T) {
+	tests := []struct {
+		left     time.Duration
+		expected string
+		refresh  time.Duration
+	}{
+		{-time.Minute, "0s", 0},
+		{5*time.Hour + 30*time.Minute, "6h", 30 * time.Minute},
+		{5 * time.Hour, "5h", time.Hour},
+		{time.Hour + time.Second, "2h", time.Second},
+		{time.Hour, "1h", time.Minute},
+		{90 * time.Second, "2m", 30 * time.Second},
+	}
+	for _, test := range tests {
+		left, refresh := getTimeLeft(testTime.Add(test.left), testTime.Time)
+		if left != test.expected || refresh != test.refresh {
+			t.Errorf("expected %s to be shown as %s until %s, got %s until %s", test.left, test.expected, test.refresh, left, refresh)
+		}
+	}
+}
+
+func TestFormatTimeLeft(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		-time.Minute:                 "0s",
+		20 * time.Second:             "1m",
+		59*time.Minute + time.Second: "1h",
+		3*time.Hour + time.Minute:    "3h",
+		50 * time.Hour:               "2d",
+	} {
+		if left := formatTimeLeft(d); left != expected {
+			t.Errorf("expected %v to be formatted as %q, got %q", d, expected, left)
+		}
+	}
+}
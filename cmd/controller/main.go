@@ -4,19 +4,45 @@ import (
 	"flag"
 	"time"
 
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
 	teamClient "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned"
 	teamInformer "github.com/aftouh/k8s-sample-controller/pkg/client/informers/externalversions"
 
+	"github.com/aftouh/k8s-sample-controller/pkg/webhook"
 	"github.com/aftouh/k8s-sample-controller/util/signals"
 
+	corev1 "k8s.io/api/core/v1"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
 )
 
 var (
-	kubeconfig = flag.String("kubeconfig", "", "Path to kubeconfig. Not needed inside the cluster")
+	kubeconfig  = flag.String("kubeconfig", "", "Path to kubeconfig. Not needed inside the cluster")
+	webhookPort = flag.Int("webhook-port", 8443, "Port of the webhook server")
+	tlsCertFile = flag.String("tls-cert-file", "", "Path to the webhook server certificate. The webhook server is disabled when empty")
+	tlsKeyFile  = flag.String("tls-private-key-file", "", "Path to the webhook server private key")
+
+	requireNameMatch = flag.Bool("require-name-match", false, "Reject teams whose spec.name differs from metadata.name")
+
+	defaultEnvironment   = flag.String("default-environment", "", "Default spec.environment of single environment teams")
+	defaultResourceQuota = flag.String("default-resource-quota", "", "Default hard limits of environments without resourcequota, e.g. pods=10,requests.cpu=4")
+	namespaceTemplate    = flag.String("namespace-template", defaultNamespaceTemplate, "Template of the team namespace names. Fields: .Name, .Environment, .Spec and .Labels of the team")
+	defaultNetworkPolicy = flag.String("default-network-policy", "", "Default networkpolicy mode of teams: open, isolated or team-isolated")
+	quotaWarning         = flag.Int("quota-warning-threshold", 80, "Default quota usage percentage raising a warning. 0 disables warnings")
+	quotaCritical        = flag.Int("quota-critical-threshold", 95, "Default quota usage percentage raising a critical alert. 0 disables critical alerts")
+
+	allowedMemberRoles = flag.String("allowed-member-roles", "admin,edit,view", "Comma separated cluster roles team members may be given. The controller clusterrole must allow binding them")
+
+	ciRole  = flag.String("ci-role", "", "Role bound to the ci service account of the team namespaces, as Role/<name> or ClusterRole/<name>, named in -allowed-member-roles. No ci service account is created when empty")
+	ciToken = flag.Bool("ci-token", false, "Create a long-lived token secret for the ci service account of the team namespaces")
+
+	pullSecretsNamespace = flag.String("pull-secrets-namespace", "", "Namespace of the image pull secrets replicated into the team namespaces")
+	pullSecretNames      = flag.String("pull-secrets", "", "Comma separated names of the image pull secrets replicated into the team namespaces and referenced by their default service account")
+	syncedResources      = flag.String("synced-resources", "", "Configmaps and secrets copied into every team namespace, e.g. ConfigMap/platform/ca-bundle,Secret/platform/proxy")
+	syncedNamespaces     = flag.String("synced-namespaces", "", "Comma separated namespaces whose configmaps and secrets teams may sync with spec.syncedResources. Teams cannot sync resources when empty")
 )
 
 const resyncPeriod = time.Second * 30
@@ -27,6 +53,48 @@ func main() {
 	flag.Parse()
 	klog.V(5).Infof("kubeconfig set to: %q", *kubeconfig)
 
+	defaultHard, err := parseResourceList(*defaultResourceQuota)
+	if err != nil {
+		klog.Fatalf("invalid default resource quota, %s", err)
+	}
+	switch mode := aftouhv1.NetworkPolicyMode(*defaultNetworkPolicy); mode {
+	case "", aftouhv1.NetworkPolicyOpen, aftouhv1.NetworkPolicyIsolated, aftouhv1.NetworkPolicyTeamIsolated:
+	default:
+		klog.Fatalf("invalid default network policy mode %q", mode)
+	}
+	defaultSyncedResources, err := parseSyncedResources(*syncedResources)
+	if err != nil {
+		klog.Fatalf("invalid synced resources, %s", err)
+	}
+	defaults := aftouhv1.TeamDefaults{
+		Environment:       *defaultEnvironment,
+		ResourceQuotaSpec: corev1.ResourceQuotaSpec{Hard: defaultHard},
+		NetworkPolicyMode: aftouhv1.NetworkPolicyMode(*defaultNetworkPolicy),
+		QuotaAlerts:       aftouhv1.TeamQuotaAlerts{Warning: int32(*quotaWarning), Critical: int32(*quotaCritical)},
+		SyncedResources:   defaultSyncedResources,
+	}
+
+	namer, err := newNamespaceNamer(*namespaceTemplate)
+	if err != nil {
+		klog.Fatalf("%s", err)
+	}
+
+	memberRoles := parseMemberRoles(*allowedMemberRoles)
+
+	ciRoleRef, err := parseRoleRef(*ciRole)
+	if err != nil {
+		klog.Fatalf("invalid ci role, %s", err)
+	}
+	ci := ciConfig{roleRef: ciRoleRef, token: *ciToken}
+	if err := ci.validateRole(memberRoles); err != nil {
+		klog.Fatalf("invalid ci role, %s", err)
+	}
+
+	pullSecrets, err := newPullSecretConfig(*pullSecretsNamespace, *pullSecretNames)
+	if err != nil {
+		klog.Fatalf("invalid pull secrets, %s", err)
+	}
+
 	cfg, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
 	if err != nil {
 		klog.Fatalf("failed loading config, %s", err)
@@ -42,16 +110,45 @@ func main() {
 		klog.Fatalf("failed building kubernetes client. %s", err)
 	}
 
+	mClientSet, err := metadata.NewForConfig(cfg)
+	if err != nil {
+		klog.Fatalf("failed building metadata client. %s", err)
+	}
+
 	stopChan := signals.StopChan()
 
 	tInfomerFactory := teamInformer.NewSharedInformerFactory(tClientSet, resyncPeriod)
 	kInformerFactory := kubeinformers.NewSharedInformerFactory(kClientSet, resyncPeriod)
 
-	controller := NewTeamController(tClientSet,
-		kClientSet,
-		tInfomerFactory.Aftouh().V1().Teams(),
-		kInformerFactory.Core().V1().Namespaces(),
-		kInformerFactory.Core().V1().ResourceQuotas())
+	controller := NewTeamController(tClientSet, kClientSet, mClientSet,
+		newTeamInformers(tInfomerFactory, kInformerFactory),
+		teamControllerConfig{
+			defaults:           defaults,
+			namer:              namer,
+			ci:                 ci,
+			pullSecrets:        pullSecrets,
+			syncedNamespaces:   parseNamespaces(*syncedNamespaces),
+			allowedMemberRoles: memberRoles,
+		})
+
+	if *tlsCertFile != "" {
+		server := webhook.NewServer(*webhookPort, *tlsCertFile, *tlsKeyFile)
+		server.Handle("/convert", webhook.NewConversionHandler())
+		server.Handle("/mutate", webhook.NewMutationHandler(defaults))
+		server.Handle("/validate", webhook.NewValidationHandler(
+			tInfomerFactory.Aftouh().V1().Teams().Lister(),
+			namer.namespaceFunc(tInfomerFactory.Aftouh().V1().TeamClasses().Lister()),
+			*requireNameMatch,
+			parseNamespaces(*syncedNamespaces),
+			memberRoles))
+		go func() {
+			if err := server.Run(stopChan); err != nil {
+				klog.Fatalf("failed running webhook server. %s", err)
+			}
+		}()
+	} else {
+		klog.Warning("No tls certificate provided, webhook server is disabled")
+	}
 
 	tInfomerFactory.Start(stopChan)
 	kInformerFactory.Start(stopChan)
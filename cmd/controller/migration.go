@@ -0,0 +1,345 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog"
+)
+
+//Kinds copied by the Copy migration policy
+const (
+	kindConfigMap  = "ConfigMap"
+	kindSecret     = "Secret"
+	kindDeployment = "Deployment"
+	kindService    = "Service"
+)
+
+//getMigrationKinds returns the kinds copied by the team migration
+func getMigrationKinds(t *aftouhv1.Team) []string {
+	if t.Spec.NamespaceMigration == nil || len(t.Spec.NamespaceMigration.Resources) == 0 {
+		return aftouhv1.NamespaceMigrationResources
+	}
+	return t.Spec.NamespaceMigration.Resources
+}
+
+//migrationProgress is the state of a Copy migration reported by the Migrating condition
+type migrationProgress struct {
+	//unavailable is a copied deployment that is not available yet
+	unavailable string
+	//uncopied lists the resources of the old namespace that the migration does not copy
+	uncopied []string
+}
+
+//migrateNamespace copies the resources of the old namespace into the new one.
+//The old namespace is deleted once the copied deployments are available. Their availability changes sync the team again.
+//The old namespace is kept while it holds resources that are not copied, until the new namespace is approved
+func (tc *TeamController) migrateNamespace(t *aftouhv1.Team, from, to string) (migrationProgress, error) {
+	kinds := getMigrationKinds(t)
+	for _, kind := range kinds {
+		var copied []string
+		var err error
+		switch kind {
+		case kindConfigMap:
+			copied, err = tc.copyConfigMaps(from, to)
+		case kindSecret:
+			copied, err = tc.copySecrets(from, to)
+		case kindDeployment:
+			copied, err = tc.copyDeployments(from, to)
+		case kindService:
+			copied, err = tc.copyServices(from, to)
+		}
+		for _, name := range copied {
+			tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonResourceCopied, "Copied %s %q from namespace %q to %q", kind, name, from, to)
+		}
+		if err != nil {
+			return migrationProgress{}, fmt.Errorf("Failed copying %s resources: %v", kind, err)
+		}
+	}
+
+	if containsString(kinds, kindDeployment) {
+		//The copies just created are not listed yet: the copy of every deployment of the old namespace is looked up
+		deployments, err := tc.deployLister.Deployments(from).List(labels.Everything())
+		if err != nil {
+			return migrationProgress{}, err
+		}
+		for _, d := range deployments {
+			if skipMigration(d.ObjectMeta) {
+				continue
+			}
+			c, err := tc.deployLister.Deployments(to).Get(d.Name)
+			if err != nil && !errors.IsNotFound(err) {
+				return migrationProgress{}, err
+			}
+			if err == nil && (c.Annotations[aftouhv1.MigratedFromAnnotation] != from || deploymentAvailable(c)) {
+				continue
+			}
+			klog.V(4).Infof("Waiting for deployment %s/%s to be available before deleting namespace %q", to, d.Name, from)
+			return migrationProgress{unavailable: d.Name}, nil
+		}
+	}
+
+	if !isMigrationApproved(t, to) {
+		uncopied, err := tc.listNamespaceResources(from, kinds)
+		if err != nil {
+			return migrationProgress{}, fmt.Errorf("Failed listing the resources of namespace %q: %v", from, err)
+		}
+		if len(uncopied) > 0 {
+			klog.V(4).Infof("Keeping namespace %q, its resources %s are not copied", from, strings.Join(uncopied, ", "))
+			return migrationProgress{uncopied: uncopied}, nil
+		}
+	}
+
+	klog.V(2).Infof("Deleting namespace %q migrated to %q", from, to)
+	if err := tc.kClientSet.CoreV1().Namespaces().Delete(from, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return migrationProgress{}, err
+	}
+	tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonNamespaceRetired, "Namespace %q is migrated to %q and deleted", from, to)
+	return migrationProgress{}, nil
+}
+
+//migrationGroupKinds are the API group and kind of the resources copied by the migration
+var migrationGroupKinds = map[string]schema.GroupKind{
+	kindConfigMap:  {Kind: kindConfigMap},
+	kindSecret:     {Kind: kindSecret},
+	kindService:    {Kind: kindService},
+	kindDeployment: {Group: appsv1.GroupName, Kind: kindDeployment},
+}
+
+//ignoredGroupKinds are recorded or maintained by the cluster for the namespace resources and never migrated
+var ignoredGroupKinds = map[schema.GroupKind]bool{
+	{Kind: "Event"}:                                    true,
+	{Group: "events.k8s.io", Kind: "Event"}:            true,
+	{Kind: "Endpoints"}:                                true,
+	{Group: "discovery.k8s.io", Kind: "EndpointSlice"}: true,
+}
+
+//listNamespaceResources returns the resources of the namespace, as kind/name, that are lost when it is deleted.
+//The resources of the copied kinds, the ones recreated by their controller and the ones every namespace gets are skipped
+func (tc *TeamController) listNamespaceResources(namespace string, copiedKinds []string) ([]string, error) {
+	skipped := make(map[schema.GroupKind]bool)
+	for gk := range ignoredGroupKinds {
+		skipped[gk] = true
+	}
+	for _, kind := range copiedKinds {
+		skipped[migrationGroupKinds[kind]] = true
+	}
+
+	lists, err := discovery.ServerPreferredNamespacedResources(tc.kClientSet.Discovery())
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []string
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list.APIResources {
+			if skipped[gv.WithKind(r.Kind).GroupKind()] || !containsString(r.Verbs, "list") {
+				continue
+			}
+			objs, err := tc.mClientSet.Resource(gv.WithResource(r.Name)).Namespace(namespace).List(metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("Failed listing %s: %v", r.Name, err)
+			}
+			for _, obj := range objs.Items {
+				if !skipNamespaceResource(r.Kind, obj.ObjectMeta) {
+					resources = append(resources, r.Kind+"/"+obj.Name)
+				}
+			}
+		}
+	}
+	sort.Strings(resources)
+	return resources, nil
+}
+
+//skipNamespaceResource returns true for the resources recreated in every namespace or by their owner
+func skipNamespaceResource(kind string, obj metav1.ObjectMeta) bool {
+	switch {
+	case skipMigration(obj):
+		return true
+	case kind == "ServiceAccount":
+		return obj.Name == defaultServiceAccountName
+	case kind == kindConfigMap:
+		return obj.Name == "kube-root-ca.crt"
+	case kind == kindSecret:
+		_, token := obj.Annotations[corev1.ServiceAccountNameKey]
+		return token
+	}
+	return false
+}
+
+//summarizeResources joins the first resources of the list
+func summarizeResources(resources []string) string {
+	const max = 10
+	if len(resources) <= max {
+		return strings.Join(resources, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(resources[:max], ", "), len(resources)-max)
+}
+
+//deploymentAvailable returns true if all the replicas of the deployment are updated and available
+func deploymentAvailable(d *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation && d.Status.UpdatedReplicas >= replicas && d.Status.AvailableReplicas >= replicas
+}
+
+//migratedObjectMeta returns the metadata of the copy of a resource
+func migratedObjectMeta(obj metav1.ObjectMeta, from, to string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        obj.Name,
+		Namespace:   to,
+		Labels:      obj.Labels,
+		Annotations: mergeKeys(mergeKeys(nil, obj.Annotations), map[string]string{aftouhv1.MigratedFromAnnotation: from}),
+	}
+}
+
+//skipMigration returns true for resources that are recreated by their owner
+func skipMigration(obj metav1.ObjectMeta) bool {
+	return metav1.GetControllerOf(&obj) != nil
+}
+
+func (tc *TeamController) copyConfigMaps(from, to string) ([]string, error) {
+	list, err := tc.cmLister.ConfigMaps(from).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var copied []string
+	for _, cm := range list {
+		//Every namespace gets its own root CA configmap
+		if skipMigration(cm.ObjectMeta) || cm.Name == "kube-root-ca.crt" {
+			continue
+		}
+		//Resources copied by a previous sync are not created again
+		if _, err := tc.cmLister.ConfigMaps(to).Get(cm.Name); err == nil {
+			continue
+		}
+		c := &corev1.ConfigMap{ObjectMeta: migratedObjectMeta(cm.ObjectMeta, from, to), Data: cm.Data, BinaryData: cm.BinaryData}
+		if _, err := tc.kClientSet.CoreV1().ConfigMaps(to).Create(c); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+			return copied, err
+		}
+		copied = append(copied, cm.Name)
+	}
+	return copied, nil
+}
+
+func (tc *TeamController) copySecrets(from, to string) ([]string, error) {
+	list, err := tc.secretLister.Secrets(from).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var copied []string
+	for _, s := range list {
+		//Service account tokens are generated for the service accounts of the new namespace
+		if skipMigration(s.ObjectMeta) || s.Type == corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		if _, err := tc.secretLister.Secrets(to).Get(s.Name); err == nil {
+			continue
+		}
+		c := &corev1.Secret{ObjectMeta: migratedObjectMeta(s.ObjectMeta, from, to), Type: s.Type, Data: s.Data}
+		if _, err := tc.kClientSet.CoreV1().Secrets(to).Create(c); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+			return copied, err
+		}
+		copied = append(copied, s.Name)
+	}
+	return copied, nil
+}
+
+func (tc *TeamController) copyDeployments(from, to string) ([]string, error) {
+	list, err := tc.deployLister.Deployments(from).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var copied []string
+	for _, d := range list {
+		if skipMigration(d.ObjectMeta) {
+			continue
+		}
+		if _, err := tc.deployLister.Deployments(to).Get(d.Name); err == nil {
+			continue
+		}
+		c := &appsv1.Deployment{ObjectMeta: migratedObjectMeta(d.ObjectMeta, from, to), Spec: d.Spec}
+		if _, err := tc.kClientSet.AppsV1().Deployments(to).Create(c); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+			return copied, err
+		}
+		copied = append(copied, d.Name)
+	}
+	return copied, nil
+}
+
+func (tc *TeamController) copyServices(from, to string) ([]string, error) {
+	list, err := tc.svcLister.Services(from).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var copied []string
+	for _, s := range list {
+		if skipMigration(s.ObjectMeta) {
+			continue
+		}
+		if _, err := tc.svcLister.Services(to).Get(s.Name); err == nil {
+			continue
+		}
+		c := &corev1.Service{ObjectMeta: migratedObjectMeta(s.ObjectMeta, from, to), Spec: *s.Spec.DeepCopy()}
+		//Cluster IPs and node ports are allocated again, headless services stay headless
+		if c.Spec.ClusterIP != corev1.ClusterIPNone {
+			c.Spec.ClusterIP = ""
+		}
+		c.Spec.HealthCheckNodePort = 0
+		for i := range c.Spec.Ports {
+			c.Spec.Ports[i].NodePort = 0
+		}
+		if _, err := tc.kClientSet.CoreV1().Services(to).Create(c); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+			return copied, err
+		}
+		copied = append(copied, s.Name)
+	}
+	return copied, nil
+}
+
+//updateDeployment also enqueues the team of a migrated deployment whose availability changes, the old namespace is
+//deleted once the copies are available
+func (tc *TeamController) updateDeployment(old, cur interface{}) {
+	oldD, curD := old.(*appsv1.Deployment), cur.(*appsv1.Deployment)
+	if _, ok := curD.Annotations[aftouhv1.MigratedFromAnnotation]; ok && deploymentAvailable(oldD) != deploymentAvailable(curD) {
+		tc.enqueueNamespaceTeam(curD.Namespace)
+		return
+	}
+	tc.updateWorkload(old, cur)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// newCopyMigrationFixture returns a fixture of a team whose dev namespace is renamed from team-test-dev to dev-test
+// with the Copy migration policy. Both namespaces exist
+func newCopyMigrationFixture(t *testing.T) (*fixture, *aftouhv1.Team, *corev1.Namespace) {
+	f := newFixture(t)
+	f.namespaceTemplate = "{{.Environment}}-{{.Name}}"
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.NamespaceMigration = &aftouhv1.TeamNamespaceMigration{Policy: aftouhv1.NamespaceMigrationCopy}
+	team.Status = readyStatus(team)
+	f.addObj(team)
+
+	oldNS := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	oldNS.Status.Phase = corev1.NamespaceActive
+	f.addObj(oldNS)
+	newNS := newNamespace(team, "dev", "dev-test", nil)
+	newNS.Status.Phase = corev1.NamespaceActive
+	f.addObj(newNS)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], "dev-test", nil)[0])
+
+	return f, team, oldNS
+}
+
+func TestCopyNamespaceMigration(t *testing.T) {
+	f, team, oldNS := newCopyMigrationFixture(t)
+	from := oldNS.Name
+	replicas := int32(2)
+	labels := map[string]string{"app": "web"}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: from}, Data: map[string]string{"key": "value"}}
+	rootCA := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: from}}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: from}, Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{"password": []byte("secret")}}
+	token := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "default-token", Namespace: from}, Type: corev1.SecretTypeServiceAccountToken}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: from, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: from},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeNodePort,
+			ClusterIP: "10.0.0.10",
+			Selector:  labels,
+			Ports:     []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080), NodePort: 30080}},
+		},
+	}
+	for _, obj := range []metav1.Object{cm, rootCA, secret, token, deployment, service} {
+		f.addObj(obj)
+	}
+
+	migrated := map[string]string{aftouhv1.MigratedFromAnnotation: from}
+	expectedCM := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "dev-test", Annotations: migrated}, Data: cm.Data}
+	expectedSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "dev-test", Annotations: migrated},
+		Type: corev1.SecretTypeOpaque, Data: secret.Data}
+	expectedDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev-test", Labels: labels, Annotations: migrated},
+		Spec:       deployment.Spec,
+	}
+	expectedService := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev-test", Annotations: migrated}, Spec: *service.Spec.DeepCopy()}
+	expectedService.Spec.ClusterIP = ""
+	expectedService.Spec.Ports[0].NodePort = 0
+
+	f.expectCreateAction("configmaps", expectedCM)
+	f.expectCreateAction("secrets", expectedSecret)
+	f.expectCreateAction("deployments", expectedDeployment)
+	f.expectCreateAction("services", expectedService)
+	//The copied deployment is not available yet, the old namespace is kept
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Namespace = "dev-test"
+	expectedTeam.Status.Environments[0].Namespace = "dev-test"
+	expectedTeam.Status.Environments[0].MigratingFrom = from
+	expectedTeam.Status.Conditions[6] = newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionTrue, reasonCopyingResources,
+		"Copying resources of namespaces "+from+` (waiting for deployment "web")`, testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestCompletedNamespaceMigration(t *testing.T) {
+	f, team, oldNS := newCopyMigrationFixture(t)
+	team.Spec.NamespaceMigration.Resources = []string{kindDeployment}
+	team.Status.Environments[0].MigratingFrom = oldNS.Name
+
+	replicas := int32(1)
+	available := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev-test", Annotations: map[string]string{aftouhv1.MigratedFromAnnotation: oldNS.Name}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	f.addObj(available)
+	//Copied by a previous sync
+	source := available.DeepCopy()
+	source.Namespace, source.Annotations = oldNS.Name, nil
+	f.addObj(source)
+
+	f.expectDeleteNamespaceAction(oldNS)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Namespace = "dev-test"
+	expectedTeam.Status.Environments[0].Namespace = "dev-test"
+	expectedTeam.Status.Conditions[6] = newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionTrue, reasonCopyingResources,
+		"Copying resources of namespaces "+oldNS.Name, testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+// namespacedAPIResources are served by the discovery of the fixtures listing the content of the deleted namespaces
+var namespacedAPIResources = []*metav1.APIResourceList{
+	{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "events", Namespaced: true, Kind: "Event", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "persistentvolumeclaims", Namespaced: true, Kind: "PersistentVolumeClaim", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "pods", Namespaced: true, Kind: "Pod", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "serviceaccounts", Namespaced: true, Kind: "ServiceAccount", Verbs: metav1.Verbs{"get", "list"}},
+		},
+	},
+	{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "statefulsets", Namespaced: true, Kind: "StatefulSet", Verbs: metav1.Verbs{"get", "list"}},
+		},
+	},
+}
+
+// newNamespaceContent returns the metadata of the objects of a namespace: a persistentvolumeclaim and a statefulset
+// that are lost with the namespace, and objects recreated in every namespace or by their owner
+func newNamespaceContent(namespace string) []runtime.Object {
+	meta := func(apiVersion, kind, name string) *metav1.PartialObjectMetadata {
+		return &metav1.PartialObjectMetadata{
+			TypeMeta:   metav1.TypeMeta{APIVersion: apiVersion, Kind: kind},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		}
+	}
+	db := meta("apps/v1", "StatefulSet", "db")
+	pod := meta("v1", "Pod", "db-0")
+	pod.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(db, appsv1.SchemeGroupVersion.WithKind("StatefulSet"))}
+	return []runtime.Object{
+		meta("v1", "PersistentVolumeClaim", "data"),
+		db,
+		pod,
+		meta("v1", "ConfigMap", "kube-root-ca.crt"),
+		meta("v1", "ServiceAccount", defaultServiceAccountName),
+		meta("v1", "Event", "db-0.1"),
+		meta("apps/v1", "Deployment", "web"),
+	}
+}
+
+func TestUncopiedResources(t *testing.T) {
+	f, team, oldNS := newCopyMigrationFixture(t)
+	team.Spec.NamespaceMigration.Resources = []string{kindDeployment}
+	team.Status.Environments[0].MigratingFrom = oldNS.Name
+	f.apiResources = namespacedAPIResources
+	f.mObjects = newNamespaceContent(oldNS.Name)
+
+	//The old namespace is kept until the deletion of its persistentvolumeclaim and statefulset is approved
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Namespace = "dev-test"
+	expectedTeam.Status.Environments[0].Namespace = "dev-test"
+	expectedTeam.Status.Conditions[6] = newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionTrue, reasonUncopiedResources,
+		`Resources are not copied: namespace "team-test-dev" keeps PersistentVolumeClaim/data, StatefulSet/db. `+
+			"Set the "+aftouhv1.MigrateNamespacesAnnotation+"=dev-test annotation to delete them", testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestApprovedUncopiedResources(t *testing.T) {
+	f, team, oldNS := newCopyMigrationFixture(t)
+	team.Annotations = map[string]string{aftouhv1.MigrateNamespacesAnnotation: "dev-test"}
+	team.Spec.NamespaceMigration.Resources = []string{kindDeployment}
+	team.Status.Environments[0].MigratingFrom = oldNS.Name
+	f.apiResources = namespacedAPIResources
+	f.mObjects = newNamespaceContent(oldNS.Name)
+
+	f.expectDeleteNamespaceAction(oldNS)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Namespace = "dev-test"
+	expectedTeam.Status.Environments[0].Namespace = "dev-test"
+	expectedTeam.Status.Conditions[6] = newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionTrue, reasonCopyingResources,
+		"Copying resources of namespaces "+oldNS.Name, testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestListNamespaceResources(t *testing.T) {
+	f := newFixture(t)
+	f.apiResources = namespacedAPIResources
+	f.mObjects = newNamespaceContent("ns")
+	tc, _, _ := f.newTeamController()
+
+	resources, err := tc.listNamespaceResources("ns", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"Deployment/web", "PersistentVolumeClaim/data", "StatefulSet/db"}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("expected resources %v, got %v", expected, resources)
+	}
+
+	resources, err = tc.listNamespaceResources("other", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 0 {
+		t.Errorf("expected no resources in another namespace, got %v", resources)
+	}
+}
+
+func TestSummarizeResources(t *testing.T) {
+	var resources []string
+	for i := 0; i < 12; i++ {
+		resources = append(resources, fmt.Sprintf("ConfigMap/cm-%02d", i))
+	}
+	if s := summarizeResources(resources[:2]); s != "ConfigMap/cm-00, ConfigMap/cm-01" {
+		t.Errorf("unexpected summary %q", s)
+	}
+	if s := summarizeResources(resources); !strings.HasSuffix(s, "ConfigMap/cm-09 and 2 more") {
+		t.Errorf("unexpected summary %q", s)
+	}
+}
+
+func TestUpdateMigratedDeployment(t *testing.T) {
+	f, team, oldNS := newCopyMigrationFixture(t)
+	tc, _, _ := f.newTeamController()
+
+	old := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev-test", Annotations: map[string]string{aftouhv1.MigratedFromAnnotation: oldNS.Name}}}
+	cur := old.DeepCopy()
+	cur.Status = appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 1}
+
+	//The team is synced again to delete the old namespace once its copied deployment is available
+	tc.updateDeployment(old, cur)
+	if tc.queue.Len() != 1 {
+		t.Fatalf("expected 1 team to be enqueued, got %d", tc.queue.Len())
+	}
+	if key, _ := tc.queue.Get(); key != team.Name {
+		t.Errorf("expected team %q to be enqueued, got %v", team.Name, key)
+	}
+}
+
+func TestDeploymentAvailable(t *testing.T) {
+	replicas := int32(2)
+	d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Generation: 2}, Spec: appsv1.DeploymentSpec{Replicas: &replicas}}
+	d.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: 2, AvailableReplicas: 1}
+	if deploymentAvailable(d) {
+		t.Error("expected deployment with an unavailable replica to be unavailable")
+	}
+	d.Status.AvailableReplicas = 2
+	if !deploymentAvailable(d) {
+		t.Error("expected deployment to be available")
+	}
+	d.Generation = 3
+	if deploymentAvailable(d) {
+		t.Error("expected deployment whose generation is not observed to be unavailable")
+	}
+}
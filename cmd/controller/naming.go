@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	tlister "github.com/aftouh/k8s-sample-controller/pkg/client/listers/team/v1"
+	"github.com/aftouh/k8s-sample-controller/pkg/webhook"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const defaultNamespaceTemplate = "team-{{.Name}}-{{.Environment}}"
+
+//namespaceTemplateData holds the fields available in namespace templates
+type namespaceTemplateData struct {
+	//Name is the team spec.name
+	Name        string
+	Environment string
+	Spec        aftouhv1.TeamSpec
+	//Labels are the team metadata labels
+	Labels map[string]string
+}
+
+//namespaceNamer generates the namespace names of team environments
+type namespaceNamer struct {
+	//defaultTemplate is used for teams whose class has no namespace template
+	defaultTemplate *template.Template
+}
+
+func newNamespaceNamer(defaultTemplate string) (*namespaceNamer, error) {
+	tmpl, err := parseNamespaceTemplate(defaultTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &namespaceNamer{defaultTemplate: tmpl}, nil
+}
+
+func parseNamespaceTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("namespace").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace template %q: %v", text, err)
+	}
+	return tmpl, nil
+}
+
+//namespace returns the namespace name of a team environment.
+//The name is generated by the class template if any, else by the default one
+func (n *namespaceNamer) namespace(t *aftouhv1.Team, env string, class *aftouhv1.TeamClass) (string, error) {
+	tmpl := n.defaultTemplate
+	if class != nil && class.Spec.NamespaceTemplate != "" {
+		var err error
+		if tmpl, err = parseNamespaceTemplate(class.Spec.NamespaceTemplate); err != nil {
+			return "", err
+		}
+	}
+
+	data := namespaceTemplateData{
+		Name:        t.Spec.Name,
+		Environment: env,
+		Spec:        t.Spec,
+		Labels:      t.Labels,
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("unable to generate namespace of environment %q: %v", env, err)
+	}
+
+	name := buf.String()
+	if msgs := validation.IsDNS1123Label(name); len(msgs) > 0 {
+		return "", fmt.Errorf("generated namespace %q is invalid: %s", name, strings.Join(msgs, ", "))
+	}
+	return name, nil
+}
+
+//namespaceFunc returns the function generating the team namespaces in the validating webhook.
+//Teams whose class does not exist yet get the default namespace name
+func (n *namespaceNamer) namespaceFunc(tcLister tlister.TeamClassLister) webhook.NamespaceFunc {
+	return func(t *aftouhv1.Team, env string) (string, error) {
+		var class *aftouhv1.TeamClass
+		if t.Spec.ClassName != "" {
+			class, _ = tcLister.Get(t.Spec.ClassName)
+		}
+		return n.namespace(t, env, class)
+	}
+}
+
+//teamNamespace is the namespace of a team environment
+type teamNamespace struct {
+	Name string
+	//Pending is the generated name the namespace is not migrated to yet
+	Pending string
+	//MigrateFrom is the previous namespace whose resources are copied into the namespace
+	MigrateFrom string
+}
+
+//getTeamNamespaces returns the namespaces of the team environments indexed by environment name.
+//When the generated name of an environment changes, with the Confirm migration policy the environment keeps its current
+//namespace until the team annotation approves the new one. The new namespace is then created and the old one pruned.
+//With the Copy policy the new namespace is created right away and the resources of the old one are copied into it
+func (tc *TeamController) getTeamNamespaces(t *aftouhv1.Team, class *aftouhv1.TeamClass) (map[string]teamNamespace, error) {
+	envs := getTeamEnvironments(t)
+	namespaces := make(map[string]teamNamespace)
+	for _, env := range envs {
+		name, err := tc.namer.namespace(t, env.Name, class)
+		if err != nil {
+			return nil, err
+		}
+		namespaces[env.Name] = teamNamespace{Name: name}
+
+		es := getEnvironmentStatus(t.Status, env.Name)
+		//The single environment of a team is renamed when spec.environment changes
+		if es.Namespace == "" && len(envs) == 1 && len(t.Status.Environments) == 1 {
+			es = t.Status.Environments[0]
+		}
+		current := es.Namespace
+		if es.MigratingFrom != "" {
+			current = es.MigratingFrom
+		}
+		if current == "" || current == name {
+			continue
+		}
+		ns, err := tc.nLister.Get(current)
+		switch {
+		case errors.IsNotFound(err):
+		case err != nil:
+			return nil, fmt.Errorf("Unable to retrieve namespace %q from store: %v", current, err)
+		case !metav1.IsControlledBy(ns, t) || ns.DeletionTimestamp != nil:
+		//The approval of a Copy migration only allows deleting the resources that are not copied
+		case getMigrationPolicy(t) == aftouhv1.NamespaceMigrationCopy:
+			namespaces[env.Name] = teamNamespace{Name: name, MigrateFrom: current}
+		//The approved old namespace is pruned
+		case isMigrationApproved(t, name):
+		default:
+			namespaces[env.Name] = teamNamespace{Name: current, Pending: name}
+		}
+	}
+	return namespaces, nil
+}
+
+//isMigrationApproved returns true if the team annotation approves the migration to the namespace.
+//The annotation names the approved namespaces so that, left on the team, it does not approve the next renames
+func isMigrationApproved(t *aftouhv1.Team, namespace string) bool {
+	for _, ns := range strings.Split(t.Annotations[aftouhv1.MigrateNamespacesAnnotation], ",") {
+		if strings.TrimSpace(ns) == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+//getMigrationPolicy returns the namespace migration policy of the team, Confirm by default
+func getMigrationPolicy(t *aftouhv1.Team) aftouhv1.NamespaceMigrationPolicy {
+	if t.Spec.NamespaceMigration == nil || t.Spec.NamespaceMigration.Policy == "" {
+		return aftouhv1.NamespaceMigrationConfirm
+	}
+	return t.Spec.NamespaceMigration.Policy
+}
+
+//getEnvironmentStatus returns the status of the environment or an empty status if the environment is not reported yet
+func getEnvironmentStatus(ts aftouhv1.TeamStatus, env string) aftouhv1.EnvironmentStatus {
+	for _, es := range ts.Environments {
+		if es.Name == env {
+			return es
+		}
+	}
+	return aftouhv1.EnvironmentStatus{Name: env}
+}
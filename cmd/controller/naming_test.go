@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestNamespaceTemplate(t *testing.T) {
+	team := newTeam("poc", "", "dev", corev1.ResourceQuotaSpec{})
+	team.Labels = map[string]string{"tenant": "acme"}
+
+	tests := []struct {
+		name          string
+		template      string
+		classTemplate string
+		expected      string
+		err           string
+	}{
+		{name: "default template", template: defaultNamespaceTemplate, expected: "team-poc-dev"},
+		{name: "environment first", template: "{{.Environment}}-{{.Name}}", expected: "dev-poc"},
+		{name: "single namespace", template: "{{.Name}}", expected: "poc"},
+		{name: "team label", template: "{{.Labels.tenant}}-{{.Name}}-{{.Environment}}", expected: "acme-poc-dev"},
+		{name: "class template", template: defaultNamespaceTemplate, classTemplate: "{{.Spec.Name}}", expected: "poc"},
+		{name: "missing label", template: "{{.Labels.owner}}-{{.Name}}", err: "unable to generate namespace"},
+		{name: "invalid namespace", template: "Team_{{.Name}}", err: `generated namespace "Team_poc" is invalid`},
+		{name: "invalid class template", template: defaultNamespaceTemplate, classTemplate: "{{.Name", err: "invalid namespace template"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namer, err := newNamespaceNamer(test.template)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var class *aftouhv1.TeamClass
+			if test.classTemplate != "" {
+				class = &aftouhv1.TeamClass{Spec: aftouhv1.TeamClassSpec{NamespaceTemplate: test.classTemplate}}
+			}
+
+			got, err := namer.namespace(team, "dev", class)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.expected {
+				t.Errorf("expected namespace %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestInvalidNamespaceTemplate(t *testing.T) {
+	if _, err := newNamespaceNamer("team-{{.Name"); err == nil {
+		t.Error("expected invalid template error")
+	}
+}
+
+func TestPendingNamespaceMigration(t *testing.T) {
+	f := newFixture(t)
+	f.namespaceTemplate = "{{.Environment}}-{{.Name}}"
+
+	//Team synced with the previous template
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, "dev"), nil)[0])
+
+	//The current namespace is kept until the migration is approved
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Environments[0].PendingNamespace = "dev-test"
+	expectedTeam.Status.Conditions[6] = newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionTrue, reasonMigrationPending,
+		"Namespaces dev-test are waiting for the "+aftouhv1.MigrateNamespacesAnnotation+"=dev-test annotation", testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestRenameAfterApprovedNamespaceMigration(t *testing.T) {
+	f := newFixture(t)
+	f.namespaceTemplate = "{{.Environment}}-{{.Name}}"
+
+	//Team migrated to its current namespace with an approval left on the team
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Annotations = map[string]string{aftouhv1.MigrateNamespacesAnnotation: defaultTeamNamespace(team, "dev")}
+	team.Status = readyStatus(team)
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, "dev"), nil)[0])
+
+	//The previous approval does not approve the next rename
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Environments[0].PendingNamespace = "dev-test"
+	expectedTeam.Status.Conditions[6] = newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionTrue, reasonMigrationPending,
+		"Namespaces dev-test are waiting for the "+aftouhv1.MigrateNamespacesAnnotation+"=dev-test annotation", testTime)
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestApprovedNamespaceMigration(t *testing.T) {
+	f := newFixture(t)
+	f.namespaceTemplate = "{{.Environment}}-{{.Name}}"
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Annotations = map[string]string{aftouhv1.MigrateNamespacesAnnotation: "dev-test"}
+	team.Status = readyStatus(team)
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.apiResources = namespacedAPIResources
+	f.mObjects = newNamespaceContent(ns.Name)
+	f.recorder = record.NewFakeRecorder(10)
+
+	f.expectCreateNamespaceAction(newNamespace(team, "dev", "dev-test", nil))
+	f.expectDeleteNamespaceAction(ns)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status = aftouhv1.TeamStatus{
+		Phase:        aftouhv1.TeamPhaseActive,
+		Environments: []aftouhv1.EnvironmentStatus{{Name: "dev"}},
+		Conditions: []aftouhv1.TeamCondition{
+			newTeamCondition(aftouhv1.TeamReady, corev1.ConditionFalse, reasonSyncFailed,
+				`Failed syncing team resourcequota: namespace "dev-test" not found`, testTime),
+			newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionFalse, reasonNotFound,
+				`Namespace "dev-test" does not exist`, testTime),
+			newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionFalse, reasonNotFound, "", testTime),
+			newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+			newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionFalse, "", "", testTime),
+			newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionTrue, reasonNotRequired, "", testTime),
+			newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionFalse, "", "", testTime),
+			newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionFalse, "", "", testTime),
+			newTeamCondition(aftouhv1.TeamOverBudget, corev1.ConditionFalse, "", "", testTime),
+			newTeamCondition(aftouhv1.TeamExpiring, corev1.ConditionFalse, "", "", testTime),
+		},
+	}
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.runExpectError(team.Name)
+	//Nothing is copied into the approved namespace, the resources deleted with the old one are reported
+	expectEvents(t, f.recorder,
+		`Warning NamespacePruned Deleting namespace "team-test-dev" with its resources Deployment/web, PersistentVolumeClaim/data, StatefulSet/db`)
+}
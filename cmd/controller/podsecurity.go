@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+//psaLabelPrefix is the prefix of the pod security admission namespace labels
+const psaLabelPrefix = "pod-security.kubernetes.io/"
+
+//baselineCapabilities are the capabilities containers may add in the baseline level
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true, "KILL": true, "MKNOD": true,
+	"NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+//safeSysctls are the sysctls pods may set in the baseline level
+var safeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced":              true,
+	"net.ipv4.ip_local_port_range":        true,
+	"net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.tcp_syncookies":             true,
+	"net.ipv4.ping_group_range":           true,
+}
+
+//getPodSecurityLabels returns the pod security admission labels of the namespace
+func getPodSecurityLabels(ps *aftouhv1.TeamPodSecurity) map[string]string {
+	labels := make(map[string]string)
+	if ps == nil {
+		return labels
+	}
+	modes := []struct {
+		mode    string
+		level   aftouhv1.PodSecurityLevel
+		version string
+	}{
+		{"enforce", ps.Enforce, ps.EnforceVersion},
+		{"audit", ps.Audit, ps.AuditVersion},
+		{"warn", ps.Warn, ps.WarnVersion},
+	}
+	for _, m := range modes {
+		if m.level == "" {
+			continue
+		}
+		labels[psaLabelPrefix+m.mode] = string(m.level)
+		if m.version != "" {
+			labels[psaLabelPrefix+m.mode+"-version"] = m.version
+		}
+	}
+	return labels
+}
+
+//getPodSecurityViolations returns the running pods of the namespace violating the level, with their violations
+func (tc *TeamController) getPodSecurityViolations(namespace string, level aftouhv1.PodSecurityLevel) ([]string, error) {
+	pods, err := tc.podLister.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list pods of namespace %q from cache: %v", namespace, err)
+	}
+	var violations []string
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if v := podSecurityViolations(level, pod); len(v) > 0 {
+			violations = append(violations, fmt.Sprintf("%s: %s", pod.Name, strings.Join(v, ", ")))
+		}
+	}
+	sort.Strings(violations)
+	return violations, nil
+}
+
+//getSeccompProfile returns the seccomp profile of the container annotation, or of the pod one when unset
+func getSeccompProfile(pod *corev1.Pod, container string) string {
+	if profile, ok := pod.Annotations[corev1.SeccompContainerAnnotationKeyPrefix+container]; ok {
+		return profile
+	}
+	return pod.Annotations[corev1.SeccompPodAnnotationKey]
+}
+
+//allowedSeccompProfile returns true for the runtime default and the localhost profiles allowed by the restricted level
+func allowedSeccompProfile(profile string) bool {
+	return profile == corev1.SeccompProfileRuntimeDefault || profile == corev1.DeprecatedSeccompProfileDockerDefault ||
+		strings.HasPrefix(profile, "localhost/")
+}
+
+//podSecurityViolations checks the pod against the main controls of the pod security standards level.
+//Seccomp profiles are only checked through the deprecated annotations
+func podSecurityViolations(level aftouhv1.PodSecurityLevel, pod *corev1.Pod) []string {
+	if level != aftouhv1.PodSecurityBaseline && level != aftouhv1.PodSecurityRestricted {
+		return nil
+	}
+	restricted := level == aftouhv1.PodSecurityRestricted
+	spec := pod.Spec
+	var violations []string
+
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		violations = append(violations, "host namespaces")
+	}
+	for _, v := range spec.Volumes {
+		switch {
+		case v.HostPath != nil:
+			violations = append(violations, fmt.Sprintf("hostPath volume %q", v.Name))
+		case restricted && v.ConfigMap == nil && v.CSI == nil && v.DownwardAPI == nil && v.EmptyDir == nil &&
+			v.PersistentVolumeClaim == nil && v.Projected == nil && v.Secret == nil:
+			violations = append(violations, fmt.Sprintf("restricted volume type of %q", v.Name))
+		}
+	}
+	if pod.Annotations[corev1.SeccompPodAnnotationKey] == "unconfined" {
+		violations = append(violations, "unconfined seccomp profile")
+	}
+
+	podNonRoot, podRoot := false, false
+	if sc := spec.SecurityContext; sc != nil {
+		for _, sysctl := range sc.Sysctls {
+			if !safeSysctls[sysctl.Name] {
+				violations = append(violations, fmt.Sprintf("unsafe sysctl %s", sysctl.Name))
+			}
+		}
+		podNonRoot = sc.RunAsNonRoot != nil && *sc.RunAsNonRoot
+		podRoot = sc.RunAsUser != nil && *sc.RunAsUser == 0
+	}
+
+	containers := append(append([]corev1.Container(nil), spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			violations = append(violations, fmt.Sprintf("privileged container %q", c.Name))
+		}
+		for _, p := range c.Ports {
+			if p.HostPort != 0 {
+				violations = append(violations, fmt.Sprintf("hostPort of container %q", c.Name))
+				break
+			}
+		}
+		if sc.ProcMount != nil && *sc.ProcMount != corev1.DefaultProcMount {
+			violations = append(violations, fmt.Sprintf("procMount of container %q", c.Name))
+		}
+		if pod.Annotations[corev1.SeccompContainerAnnotationKeyPrefix+c.Name] == "unconfined" {
+			violations = append(violations, fmt.Sprintf("unconfined seccomp profile of container %q", c.Name))
+		}
+
+		dropAll := false
+		if caps := sc.Capabilities; caps != nil {
+			for _, capability := range caps.Add {
+				if !baselineCapabilities[capability] || (restricted && capability != "NET_BIND_SERVICE") {
+					violations = append(violations, fmt.Sprintf("capability %s of container %q", capability, c.Name))
+				}
+			}
+			for _, capability := range caps.Drop {
+				dropAll = dropAll || capability == "ALL"
+			}
+		}
+		if !restricted {
+			continue
+		}
+
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			violations = append(violations, fmt.Sprintf("privilege escalation of container %q", c.Name))
+		}
+		if !dropAll {
+			violations = append(violations, fmt.Sprintf("container %q does not drop all capabilities", c.Name))
+		}
+		//Unconfined profiles are reported above for both levels
+		switch profile := getSeccompProfile(pod, c.Name); {
+		case profile == "":
+			violations = append(violations, fmt.Sprintf("container %q has no seccomp profile", c.Name))
+		case profile != "unconfined" && !allowedSeccompProfile(profile):
+			violations = append(violations, fmt.Sprintf("seccomp profile %s of container %q", profile, c.Name))
+		}
+		nonRoot, root := podNonRoot, podRoot
+		if sc.RunAsNonRoot != nil {
+			nonRoot = *sc.RunAsNonRoot
+		}
+		if sc.RunAsUser != nil {
+			root = *sc.RunAsUser == 0
+		}
+		if !nonRoot || root {
+			violations = append(violations, fmt.Sprintf("container %q may run as root", c.Name))
+		}
+	}
+	return violations
+}
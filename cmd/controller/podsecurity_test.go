@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func int64Ptr(i int64) *int64 { return &i }
+
+//newRestrictedPod returns a pod complying with the restricted level
+func newRestrictedPod(name, namespace string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{corev1.SeccompPodAnnotationKey: corev1.SeccompProfileRuntimeDefault},
+		},
+		Spec: corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: boolPtr(true)},
+			Containers: []corev1.Container{{
+				Name: "app",
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: boolPtr(false),
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			}},
+			Volumes: []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestPodSecurityViolations(t *testing.T) {
+	privileged := newRestrictedPod("privileged", "ns")
+	privileged.Spec.HostNetwork = true
+	privileged.Spec.Containers[0].SecurityContext.Privileged = boolPtr(true)
+
+	root := newRestrictedPod("root", "ns")
+	root.Spec.Containers[0].SecurityContext.RunAsUser = int64Ptr(0)
+	root.Spec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{"CHOWN"}
+
+	hostPath := newRestrictedPod("host-path", "ns")
+	hostPath.Spec.Volumes = append(hostPath.Spec.Volumes, corev1.Volume{
+		Name:         "docker",
+		VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"}},
+	})
+
+	noSeccomp := newRestrictedPod("no-seccomp", "ns")
+	noSeccomp.Annotations = nil
+
+	localhost := newRestrictedPod("localhost", "ns")
+	localhost.Annotations = map[string]string{corev1.SeccompContainerAnnotationKeyPrefix + "app": "localhost/profiles/app.json"}
+
+	unconfined := newRestrictedPod("unconfined", "ns")
+	unconfined.Annotations[corev1.SeccompContainerAnnotationKeyPrefix+"app"] = "unconfined"
+
+	tests := []struct {
+		name     string
+		level    aftouhv1.PodSecurityLevel
+		pod      *corev1.Pod
+		expected []string
+	}{
+		{name: "restricted pod", level: aftouhv1.PodSecurityRestricted, pod: newRestrictedPod("app", "ns")},
+		{name: "privileged level", level: aftouhv1.PodSecurityPrivileged, pod: privileged},
+		{
+			name:     "baseline privileged pod",
+			level:    aftouhv1.PodSecurityBaseline,
+			pod:      privileged,
+			expected: []string{"host namespaces", `privileged container "app"`},
+		},
+		{name: "baseline root pod", level: aftouhv1.PodSecurityBaseline, pod: root},
+		{
+			name:     "restricted root pod",
+			level:    aftouhv1.PodSecurityRestricted,
+			pod:      root,
+			expected: []string{`capability CHOWN of container "app"`, `container "app" may run as root`},
+		},
+		{
+			name:     "restricted pod without seccomp profile",
+			level:    aftouhv1.PodSecurityRestricted,
+			pod:      noSeccomp,
+			expected: []string{`container "app" has no seccomp profile`},
+		},
+		{name: "baseline pod without seccomp profile", level: aftouhv1.PodSecurityBaseline, pod: noSeccomp},
+		{name: "localhost seccomp profile", level: aftouhv1.PodSecurityRestricted, pod: localhost},
+		{
+			name:     "unconfined container overriding the pod seccomp profile",
+			level:    aftouhv1.PodSecurityRestricted,
+			pod:      unconfined,
+			expected: []string{`unconfined seccomp profile of container "app"`},
+		},
+		{
+			name:     "hostPath volume",
+			level:    aftouhv1.PodSecurityBaseline,
+			pod:      hostPath,
+			expected: []string{`hostPath volume "docker"`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := podSecurityViolations(test.level, test.pod)
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected violations %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetPodSecurityLabels(t *testing.T) {
+	ps := &aftouhv1.TeamPodSecurity{
+		Enforce:        aftouhv1.PodSecurityBaseline,
+		EnforceVersion: "v1.25",
+		Warn:           aftouhv1.PodSecurityRestricted,
+	}
+	expected := map[string]string{
+		"pod-security.kubernetes.io/enforce":         "baseline",
+		"pod-security.kubernetes.io/enforce-version": "v1.25",
+		"pod-security.kubernetes.io/warn":            "restricted",
+	}
+	if got := getPodSecurityLabels(ps); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected labels %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog"
+)
+
+//defaultServiceAccountName is the service account of the pods that do not set one
+const defaultServiceAccountName = "default"
+
+//pullSecretConfig lists the image pull secrets replicated from a platform namespace into the team namespaces
+type pullSecretConfig struct {
+	namespace string
+	names     []string
+}
+
+//newPullSecretConfig parses the -pull-secrets-namespace and -pull-secrets flags
+func newPullSecretConfig(namespace, names string) (pullSecretConfig, error) {
+	c := pullSecretConfig{namespace: namespace}
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			c.names = append(c.names, name)
+		}
+	}
+	if len(c.names) > 0 && namespace == "" {
+		return c, fmt.Errorf("missing namespace of the pull secrets %s", strings.Join(c.names, ","))
+	}
+	return c, nil
+}
+
+//enabled returns true if pull secrets are replicated into the team namespaces
+func (c pullSecretConfig) enabled() bool {
+	return len(c.names) > 0
+}
+
+//resources returns the pull secrets as synced resources. They are copied with the other synced resources
+func (c pullSecretConfig) resources() []syncedResource {
+	var resources []syncedResource
+	for _, name := range c.names {
+		resources = append(resources, syncedResource{Kind: kindSecret, Namespace: c.namespace, Name: name})
+	}
+	return resources
+}
+
+//syncPullSecrets references the copies of the pull secrets in the default service account of the namespace.
+//The copies themselves are synced by syncSyncedResources, the copy of a missing source secret stays referenced
+func (tc *TeamController) syncPullSecrets(namespaceName string) error {
+	var pullSecrets []string
+	for _, name := range tc.pullSecrets.names {
+		_, err := tc.secretLister.Secrets(tc.pullSecrets.namespace).Get(name)
+		if errors.IsNotFound(err) {
+			_, err = tc.secretLister.Secrets(namespaceName).Get(name)
+		}
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		pullSecrets = append(pullSecrets, name)
+	}
+	return tc.syncDefaultServiceAccount(namespaceName, pullSecrets)
+}
+
+//syncDefaultServiceAccount adds the missing pull secrets to the image pull secrets of the default service account and
+//removes the ones it added that are no longer replicated. The references it added are listed in the
+//ManagedPullSecretsAnnotation, the references set by other tools are kept
+func (tc *TeamController) syncDefaultServiceAccount(namespaceName string, pullSecrets []string) error {
+	sa, err := tc.saLister.ServiceAccounts(namespaceName).Get(defaultServiceAccountName)
+	if errors.IsNotFound(err) {
+		//The service account controller has not created it yet. It is watched
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	expected := make(map[string]bool)
+	for _, name := range pullSecrets {
+		expected[name] = true
+	}
+	managed := make(map[string]bool)
+	for _, name := range strings.Split(sa.Annotations[aftouhv1.ManagedPullSecretsAnnotation], ",") {
+		managed[name] = true
+	}
+
+	changed := false
+	refs := make(map[string]bool)
+	var imagePullSecrets []corev1.LocalObjectReference
+	for _, ref := range sa.ImagePullSecrets {
+		if managed[ref.Name] && !expected[ref.Name] {
+			changed = true
+			continue
+		}
+		refs[ref.Name] = true
+		imagePullSecrets = append(imagePullSecrets, ref)
+	}
+	//A reference set by another tool before the controller stays unmanaged
+	added := make(map[string]string)
+	for _, name := range pullSecrets {
+		if refs[name] && !managed[name] {
+			continue
+		}
+		if !refs[name] {
+			imagePullSecrets = append(imagePullSecrets, corev1.LocalObjectReference{Name: name})
+			refs[name] = true
+			changed = true
+		}
+		added[name] = ""
+	}
+
+	if !changed && joinKeys(added) == sa.Annotations[aftouhv1.ManagedPullSecretsAnnotation] {
+		return nil
+	}
+
+	sa = sa.DeepCopy()
+	sa.ImagePullSecrets = imagePullSecrets
+	if len(added) > 0 {
+		sa.Annotations = mergeKeys(sa.Annotations, map[string]string{aftouhv1.ManagedPullSecretsAnnotation: joinKeys(added)})
+	} else {
+		delete(sa.Annotations, aftouhv1.ManagedPullSecretsAnnotation)
+	}
+	klog.V(2).Infof("Updating serviceaccount %s/%s", namespaceName, sa.Name)
+	_, err = tc.kClientSet.CoreV1().ServiceAccounts(namespaceName).Update(sa)
+	return err
+}
+
+//Default service accounts are watched to reference the pull secrets once created, other service accounts are only
+//watched when owned by a team
+
+func (tc *TeamController) addServiceAccount(obj interface{}) {
+	if sa := obj.(*corev1.ServiceAccount); sa.Name == defaultServiceAccountName && tc.pullSecrets.enabled() {
+		tc.enqueueNamespaceTeam(sa.Namespace)
+	}
+}
+
+func (tc *TeamController) updateServiceAccount(old, cur interface{}) {
+	curSa := cur.(*corev1.ServiceAccount)
+	if curSa.Name == defaultServiceAccountName {
+		if tc.pullSecrets.enabled() && old.(*corev1.ServiceAccount).ResourceVersion != curSa.ResourceVersion {
+			tc.enqueueNamespaceTeam(curSa.Namespace)
+		}
+		return
+	}
+	tc.updateObj(old, cur)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newPullSecretFixture returns a fixture of a synced team whose controller replicates the registry secret of the platform namespace
+func newPullSecretFixture(t *testing.T) (*fixture, *aftouhv1.Team, *corev1.Secret) {
+	f := newFixture(t)
+	f.pullSecrets = pullSecretConfig{namespace: "platform", names: []string{"registry"}}
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], ns.Name, nil)[0])
+
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "platform"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+	}
+	f.addObj(source)
+
+	return f, team, source
+}
+
+func newDefaultServiceAccount(namespace string, pullSecrets ...string) *corev1.ServiceAccount {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: defaultServiceAccountName, Namespace: namespace}}
+	for _, name := range pullSecrets {
+		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+	}
+	return sa
+}
+
+func TestNewPullSecretConfig(t *testing.T) {
+	c, err := newPullSecretConfig("platform", "registry, mirror,")
+	if err != nil || !reflect.DeepEqual(c.names, []string{"registry", "mirror"}) {
+		t.Errorf("expected the registry and mirror pull secrets, got %v, %v", c.names, err)
+	}
+
+	if c, _ := newPullSecretConfig("", ""); c.enabled() {
+		t.Errorf("expected pull secrets to be disabled without names")
+	}
+
+	if _, err := newPullSecretConfig("", "registry"); err == nil {
+		t.Errorf("expected pull secrets without namespace to be rejected")
+	}
+}
+
+func TestReplicatePullSecret(t *testing.T) {
+	f, team, source := newPullSecretFixture(t)
+	ns := defaultTeamNamespace(team, "dev")
+	//Image pull secrets set by other tools are kept
+	f.addObj(newDefaultServiceAccount(ns, "other"))
+
+	secret := newSyncedSecret(team, "dev", ns, nil, source)
+	if secret.Annotations[aftouhv1.ReplicatedFromAnnotation] != "platform/registry" {
+		t.Errorf("expected the copy to be annotated with its source, got %v", secret.Annotations)
+	}
+	f.expectCreateAction("secrets", secret)
+	sa := newDefaultServiceAccount(ns, "other", "registry")
+	sa.Annotations = map[string]string{aftouhv1.ManagedPullSecretsAnnotation: "registry"}
+	f.expectUpdateAction("serviceaccounts", sa)
+
+	f.run(team.Name)
+}
+
+func TestUpdatePullSecret(t *testing.T) {
+	f, team, source := newPullSecretFixture(t)
+	ns := defaultTeamNamespace(team, "dev")
+	f.addObj(newDefaultServiceAccount(ns, "registry"))
+
+	//The copy of the previous source data is updated
+	secret := newSyncedSecret(team, "dev", ns, nil, source)
+	secret.Data = map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"old":{}}}`)}
+	f.addObj(secret)
+
+	f.expectUpdateAction("secrets", newSyncedSecret(team, "dev", ns, nil, source))
+
+	f.run(team.Name)
+}
+
+func TestPrunePullSecret(t *testing.T) {
+	f, team, source := newPullSecretFixture(t)
+	ns := defaultTeamNamespace(team, "dev")
+	f.addObj(newDefaultServiceAccount(ns, "registry"))
+	f.addObj(newSyncedSecret(team, "dev", ns, nil, source))
+
+	//The copy of a secret no longer replicated is deleted
+	stale := newSyncedSecret(team, "dev", ns, nil, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mirror", Namespace: "platform"}})
+	f.addObj(stale)
+
+	f.expectDeleteAction("secrets", stale)
+
+	f.run(team.Name)
+}
+
+func TestRemoveStalePullSecretReference(t *testing.T) {
+	f, team, source := newPullSecretFixture(t)
+	ns := defaultTeamNamespace(team, "dev")
+	f.addObj(newSyncedSecret(team, "dev", ns, nil, source))
+
+	//The mirror reference was added by the controller, the other one by another tool
+	sa := newDefaultServiceAccount(ns, "other", "registry", "mirror")
+	sa.Annotations = map[string]string{aftouhv1.ManagedPullSecretsAnnotation: "mirror,registry"}
+	f.addObj(sa)
+
+	expected := newDefaultServiceAccount(ns, "other", "registry")
+	expected.Annotations = map[string]string{aftouhv1.ManagedPullSecretsAnnotation: "registry"}
+	f.expectUpdateAction("serviceaccounts", expected)
+
+	f.run(team.Name)
+}
+
+func TestRemovePullSecretReferenceWhenDisabled(t *testing.T) {
+	f := newFixture(t)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], ns.Name, nil)[0])
+
+	//Pull secrets are no longer replicated: only the reference added by the controller is removed
+	sa := newDefaultServiceAccount(ns.Name, "other", "registry")
+	sa.Annotations = map[string]string{aftouhv1.ManagedPullSecretsAnnotation: "registry"}
+	f.addObj(sa)
+
+	expected := newDefaultServiceAccount(ns.Name, "other")
+	expected.Annotations = map[string]string{}
+	f.expectUpdateAction("serviceaccounts", expected)
+
+	f.run(team.Name)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//quotaAlertHysteresis is the number of percentage points the usage must drop below a threshold to clear its alert,
+//so that the QuotaPressure condition does not flap when the usage oscillates around the threshold
+const quotaAlertHysteresis = 5
+
+//quotaPressureLevels are the QuotaPressure condition reasons by increasing severity
+var quotaPressureLevels = []string{"", reasonQuotaWarning, reasonQuotaCritical}
+
+//getResourceQuotaUsage returns the hard limits and usage reported by the resourcequota status, sorted by resource name
+func getResourceQuotaUsage(rq *corev1.ResourceQuota) aftouhv1.ResourceQuotaUsage {
+	usage := aftouhv1.ResourceQuotaUsage{Name: rq.Name}
+	for name, hard := range rq.Status.Hard {
+		used := rq.Status.Used[name]
+		usage.Resources = append(usage.Resources, aftouhv1.ResourceUsage{
+			Name:       name,
+			Hard:       hard.String(),
+			Used:       used.String(),
+			Percentage: usagePercentage(hard, used),
+		})
+	}
+	sort.Slice(usage.Resources, func(i, j int) bool {
+		return usage.Resources[i].Name < usage.Resources[j].Name
+	})
+	return usage
+}
+
+//usagePercentage returns the used share of the hard limit, rounded down.
+//Any usage of a resource limited to zero is reported as 100%
+func usagePercentage(hard, used resource.Quantity) int32 {
+	if hard.IsZero() {
+		if used.Sign() > 0 {
+			return 100
+		}
+		return 0
+	}
+	return int32(quantityFloat(used) * 100 / quantityFloat(hard))
+}
+
+//quantityFloat returns the quantity as a float without overflowing for large quantities
+func quantityFloat(q resource.Quantity) float64 {
+	f, _ := strconv.ParseFloat(q.AsDec().String(), 64)
+	return f
+}
+
+//getMostUsedResource returns the most used quota resource of the environments, as <environment>/<resource>, with its percentage
+func getMostUsedResource(environments []aftouhv1.EnvironmentStatus) (string, int32) {
+	mostUsed, percentage := "", int32(-1)
+	for _, es := range environments {
+		for _, quota := range es.QuotaUsage {
+			for _, r := range quota.Resources {
+				if r.Percentage > percentage {
+					mostUsed, percentage = es.Name+"/"+string(r.Name), r.Percentage
+				}
+			}
+		}
+	}
+	if mostUsed == "" {
+		return "", 0
+	}
+	return mostUsed, percentage
+}
+
+//getQuotaAlerts returns the quota alert thresholds of the team, completed by the controller defaults
+func (tc *TeamController) getQuotaAlerts(t *aftouhv1.Team) aftouhv1.TeamQuotaAlerts {
+	alerts := tc.defaults.QuotaAlerts
+	if qa := t.Spec.QuotaAlerts; qa != nil {
+		if qa.Warning > 0 {
+			alerts.Warning = qa.Warning
+		}
+		if qa.Critical > 0 {
+			alerts.Critical = qa.Critical
+		}
+	}
+	return alerts
+}
+
+//quotaPressureReason returns the alert raised by the usage percentage, given the alert previously raised.
+//A threshold of zero never raises its alert
+func quotaPressureReason(alerts aftouhv1.TeamQuotaAlerts, percentage int32, previous string) string {
+	reached := func(threshold int32, level string) bool {
+		if threshold <= 0 {
+			return false
+		}
+		if levelSeverity(previous) >= levelSeverity(level) {
+			return percentage >= threshold-quotaAlertHysteresis
+		}
+		return percentage >= threshold
+	}
+	switch {
+	case reached(alerts.Critical, reasonQuotaCritical):
+		return reasonQuotaCritical
+	case reached(alerts.Warning, reasonQuotaWarning):
+		return reasonQuotaWarning
+	}
+	return ""
+}
+
+func levelSeverity(reason string) int {
+	for i, level := range quotaPressureLevels {
+		if level == reason {
+			return i
+		}
+	}
+	return 0
+}
+
+//getQuotaPressureCondition returns the QuotaPressure condition of the new team status.
+//Warning events are recorded on the team and the namespaces above the threshold when the pressure increases
+func (tc *TeamController) getQuotaPressureCondition(t *aftouhv1.Team, ts aftouhv1.TeamStatus, now metav1.Time) aftouhv1.TeamCondition {
+	alerts := tc.getQuotaAlerts(t)
+	previous := ""
+	if c := getTeamCondition(t.Status, aftouhv1.TeamQuotaPressure); c != nil && c.Status == corev1.ConditionTrue {
+		previous = c.Reason
+	}
+	reason := quotaPressureReason(alerts, ts.MostUsedPercentage, previous)
+
+	cond := newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionFalse, "", "", now)
+	level, threshold := "warning", alerts.Warning
+	if reason == reasonQuotaCritical {
+		level, threshold = "critical", alerts.Critical
+	}
+	if reason != "" {
+		cond.Status, cond.Reason = corev1.ConditionTrue, reason
+		cond.Message = fmt.Sprintf("%s uses %d%% of its quota, the %s threshold is %d%%", ts.MostUsedResource, ts.MostUsedPercentage, level, threshold)
+	}
+
+	switch {
+	case levelSeverity(reason) > levelSeverity(previous):
+		tc.recorder.Event(t, corev1.EventTypeWarning, reasonQuotaThresholdExceeded, cond.Message)
+		tc.recordNamespaceQuotaPressure(ts, level, threshold)
+	case levelSeverity(reason) < levelSeverity(previous) && reason == "":
+		tc.recorder.Event(t, corev1.EventTypeNormal, reasonQuotaPressureRelieved, "Quota usage is back below the alert thresholds")
+	case levelSeverity(reason) < levelSeverity(previous):
+		tc.recorder.Event(t, corev1.EventTypeNormal, reasonQuotaPressureRelieved, "Quota usage is back below the critical threshold")
+	}
+	return cond
+}
+
+//recordNamespaceQuotaPressure records a warning event on the namespaces whose quota usage reached the threshold
+func (tc *TeamController) recordNamespaceQuotaPressure(ts aftouhv1.TeamStatus, level string, threshold int32) {
+	for _, es := range ts.Environments {
+		ns, err := tc.nLister.Get(es.Namespace)
+		if err != nil {
+			continue
+		}
+		for _, quota := range es.QuotaUsage {
+			for _, r := range quota.Resources {
+				if r.Percentage >= threshold {
+					tc.recorder.Eventf(ns, corev1.EventTypeWarning, reasonQuotaThresholdExceeded,
+						"ResourceQuota %s uses %d%% of %s, the %s threshold is %d%%", quota.Name, r.Percentage, r.Name, level, threshold)
+				}
+			}
+		}
+	}
+}
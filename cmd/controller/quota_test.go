@@ -0,0 +1,162 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestUsagePercentage(t *testing.T) {
+	tests := []struct {
+		hard, used string
+		expected   int32
+	}{
+		{"10", "8", 80},
+		{"2", "500m", 25},
+		{"1Gi", "1Gi", 100},
+		{"3", "1", 33},
+		{"1", "2", 200},
+		{"0", "0", 0},
+		{"0", "1", 100},
+		{"8Ei", "4Ei", 50},
+	}
+	for _, test := range tests {
+		if p := usagePercentage(resource.MustParse(test.hard), resource.MustParse(test.used)); p != test.expected {
+			t.Errorf("expected %s used out of %s to be %d%%, got %d%%", test.used, test.hard, test.expected, p)
+		}
+	}
+}
+
+func TestQuotaUsageStatus(t *testing.T) {
+	f := newFixture(t)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	f.addObj(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	rq := newResourceQuotas(team, getTeamEnvironments(team)[0], defaultTeamNamespace(team, "dev"), nil)[0]
+	rq.Status = corev1.ResourceQuotaStatus{
+		Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10"), corev1.ResourceRequestsCPU: resource.MustParse("2")},
+		Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("8"), corev1.ResourceRequestsCPU: resource.MustParse("500m")},
+	}
+	f.addObj(rq)
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.MostUsedResource = "dev/pods"
+	expectedTeam.Status.MostUsedPercentage = 80
+	expectedTeam.Status.Environments[0].QuotaUsage = []aftouhv1.ResourceQuotaUsage{{
+		Name: rqName,
+		Resources: []aftouhv1.ResourceUsage{
+			{Name: corev1.ResourcePods, Hard: "10", Used: "8", Percentage: 80},
+			{Name: corev1.ResourceRequestsCPU, Hard: "2", Used: "500m", Percentage: 25},
+		},
+	}}
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestMostUsedResource(t *testing.T) {
+	environments := []aftouhv1.EnvironmentStatus{
+		{Name: "dev", QuotaUsage: []aftouhv1.ResourceQuotaUsage{{Name: rqName, Resources: []aftouhv1.ResourceUsage{{Name: corev1.ResourcePods, Percentage: 40}}}}},
+		{Name: "prod", QuotaUsage: []aftouhv1.ResourceQuotaUsage{{Name: rqName, Resources: []aftouhv1.ResourceUsage{
+			{Name: corev1.ResourcePods, Percentage: 40},
+			{Name: corev1.ResourceRequestsMemory, Percentage: 90},
+		}}}},
+	}
+	if mostUsed, p := getMostUsedResource(environments); mostUsed != "prod/requests.memory" || p != 90 {
+		t.Errorf("expected prod/requests.memory used at 90%%, got %s used at %d%%", mostUsed, p)
+	}
+	if mostUsed, p := getMostUsedResource(nil); mostUsed != "" || p != 0 {
+		t.Errorf("expected no most used resource, got %s used at %d%%", mostUsed, p)
+	}
+}
+
+func TestQuotaPressureReason(t *testing.T) {
+	alerts := aftouhv1.TeamQuotaAlerts{Warning: 80, Critical: 95}
+	tests := []struct {
+		percentage int32
+		previous   string
+		expected   string
+	}{
+		{50, "", ""},
+		{80, "", reasonQuotaWarning},
+		{96, "", reasonQuotaCritical},
+		{96, reasonQuotaWarning, reasonQuotaCritical},
+		//Alerts are kept until the usage drops below the threshold minus the hysteresis
+		{78, reasonQuotaWarning, reasonQuotaWarning},
+		{75, reasonQuotaWarning, reasonQuotaWarning},
+		{74, reasonQuotaWarning, ""},
+		{92, reasonQuotaCritical, reasonQuotaCritical},
+		{89, reasonQuotaCritical, reasonQuotaWarning},
+		{78, reasonQuotaCritical, reasonQuotaWarning},
+		{70, reasonQuotaCritical, ""},
+	}
+	for _, test := range tests {
+		if reason := quotaPressureReason(alerts, test.percentage, test.previous); reason != test.expected {
+			t.Errorf("expected %d%% with previous alert %q to raise %q, got %q", test.percentage, test.previous, test.expected, reason)
+		}
+	}
+
+	if reason := quotaPressureReason(aftouhv1.TeamQuotaAlerts{Critical: 90}, 85, ""); reason != "" {
+		t.Errorf("expected disabled warning threshold not to raise an alert, got %q", reason)
+	}
+}
+
+func TestQuotaPressureCondition(t *testing.T) {
+	f := newFixture(t)
+	f.defaults.QuotaAlerts = aftouhv1.TeamQuotaAlerts{Warning: 80, Critical: 95}
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.QuotaAlerts = &aftouhv1.TeamQuotaAlerts{Warning: 70}
+	team.Status = readyStatus(team)
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	f.addObj(ns)
+	tc, _, _ := f.newTeamController()
+	recorder := record.NewFakeRecorder(10)
+	tc.recorder = recorder
+
+	ts := *team.Status.DeepCopy()
+	ts.MostUsedResource, ts.MostUsedPercentage = "dev/pods", 72
+	ts.Environments[0].QuotaUsage = []aftouhv1.ResourceQuotaUsage{{Name: rqName, Resources: []aftouhv1.ResourceUsage{
+		{Name: corev1.ResourcePods, Percentage: 72},
+		{Name: corev1.ResourceRequestsCPU, Percentage: 10},
+	}}}
+
+	//The warning threshold comes from the team, the critical one from the controller defaults
+	cond := tc.getQuotaPressureCondition(team, ts, testTime)
+	expected := newTeamCondition(aftouhv1.TeamQuotaPressure, corev1.ConditionTrue, reasonQuotaWarning,
+		"dev/pods uses 72% of its quota, the warning threshold is 70%", testTime)
+	if !reflect.DeepEqual(cond, expected) {
+		t.Errorf("expected condition %+v, got %+v", expected, cond)
+	}
+	expectEvents(t, recorder,
+		"Warning QuotaThresholdExceeded dev/pods uses 72% of its quota, the warning threshold is 70%",
+		"Warning QuotaThresholdExceeded ResourceQuota team-default-rq uses 72% of pods, the warning threshold is 70%")
+
+	//An unchanged alert does not record events again
+	setTeamCondition(&team.Status, cond)
+	tc.getQuotaPressureCondition(team, ts, testTime)
+	expectEvents(t, recorder)
+
+	ts.MostUsedPercentage = 40
+	if cond := tc.getQuotaPressureCondition(team, ts, testTime); cond.Status != corev1.ConditionFalse {
+		t.Errorf("expected quota pressure to be relieved, got %+v", cond)
+	}
+	expectEvents(t, recorder, "Normal QuotaPressureRelieved Quota usage is back below the alert thresholds")
+}
+
+//expectEvents checks the events recorded since the last call
+func expectEvents(t *testing.T, recorder *record.FakeRecorder, expected ...string) {
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected events %q, got %q", expected, events)
+	}
+}
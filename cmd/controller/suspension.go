@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+)
+
+const (
+	kindStatefulSet             = "StatefulSet"
+	kindHorizontalPodAutoscaler = "HorizontalPodAutoscaler"
+)
+
+//isFrozen returns true if the team workloads must be scaled to zero
+func isFrozen(t *aftouhv1.Team) bool {
+	return t.Spec.State == aftouhv1.TeamStateSuspended || t.Spec.State == aftouhv1.TeamStateArchived
+}
+
+//needsResume returns true if an active team has not restored the replicas saved by a previous suspension
+func needsResume(t *aftouhv1.Team) bool {
+	return !isFrozen(t) && t.Status.Phase != "" && t.Status.Phase != aftouhv1.TeamPhaseActive
+}
+
+//getTeamPhase returns the phase of the team once synced. A failed sync leaves the suspension or the resume in progress,
+//the suspension also lasts until the pods of the team namespaces are terminated
+func getTeamPhase(t *aftouhv1.Team, syncErr error, runningPods int) aftouhv1.TeamPhase {
+	switch {
+	case isFrozen(t) && (syncErr != nil || runningPods > 0):
+		return aftouhv1.TeamPhaseSuspending
+	case isFrozen(t):
+		return aftouhv1.TeamPhase(t.Spec.State)
+	case needsResume(t) && syncErr != nil:
+		return aftouhv1.TeamPhaseResuming
+	}
+	return aftouhv1.TeamPhaseActive
+}
+
+//countRunningPods returns the number of pods of the namespace that are not terminated
+func (tc *TeamController) countRunningPods(namespace string) (int, error) {
+	pods, err := tc.podLister.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return 0, fmt.Errorf("Unable to list pods of namespace %q from cache: %v", namespace, err)
+	}
+	running := 0
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			running++
+		}
+	}
+	return running, nil
+}
+
+//suspendNamespace scales the deployments and statefulsets of the namespace to zero.
+//Their replicas are saved in the SuspendedReplicasAnnotation to be restored when the team resumes.
+//The horizontalpodautoscalers are paused first so that they do not scale the workloads up again
+func (tc *TeamController) suspendNamespace(t *aftouhv1.Team, namespace string) error {
+	var errs []error
+	zero := int32(0)
+
+	if err := tc.pauseAutoscalers(t, namespace); err != nil {
+		errs = append(errs, err)
+	}
+
+	deployments, err := tc.deployLister.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, d := range deployments {
+		d := d.DeepCopy()
+		replicas, ok := saveReplicas(d, d.Spec.Replicas)
+		if !ok {
+			continue
+		}
+		d.Spec.Replicas = &zero
+		klog.V(2).Infof("Scaling down deployment %s/%s", namespace, d.Name)
+		if _, err := tc.kClientSet.AppsV1().Deployments(namespace).Update(d); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonWorkloadScaledDown, "Scaled %s %q of namespace %q down from %d replicas", kindDeployment, d.Name, namespace, replicas)
+	}
+
+	statefulSets, err := tc.ssLister.StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, s := range statefulSets {
+		s := s.DeepCopy()
+		replicas, ok := saveReplicas(s, s.Spec.Replicas)
+		if !ok {
+			continue
+		}
+		s.Spec.Replicas = &zero
+		klog.V(2).Infof("Scaling down statefulset %s/%s", namespace, s.Name)
+		if _, err := tc.kClientSet.AppsV1().StatefulSets(namespace).Update(s); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonWorkloadScaledDown, "Scaled %s %q of namespace %q down from %d replicas", kindStatefulSet, s.Name, namespace, replicas)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+//resumeNamespace restores the replicas of the deployments and statefulsets scaled down by suspendNamespace
+func (tc *TeamController) resumeNamespace(t *aftouhv1.Team, namespace string) error {
+	var errs []error
+
+	deployments, err := tc.deployLister.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, d := range deployments {
+		d := d.DeepCopy()
+		replicas, ok, err := restoreReplicas(d, d.Spec.Replicas)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Deployment %s/%s: %v", namespace, d.Name, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		d.Spec.Replicas = &replicas
+		klog.V(2).Infof("Restoring deployment %s/%s replicas", namespace, d.Name)
+		if _, err := tc.kClientSet.AppsV1().Deployments(namespace).Update(d); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonWorkloadRestored, "Restored %d replicas of %s %q in namespace %q", replicas, kindDeployment, d.Name, namespace)
+	}
+
+	statefulSets, err := tc.ssLister.StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, s := range statefulSets {
+		s := s.DeepCopy()
+		replicas, ok, err := restoreReplicas(s, s.Spec.Replicas)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("StatefulSet %s/%s: %v", namespace, s.Name, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		s.Spec.Replicas = &replicas
+		klog.V(2).Infof("Restoring statefulset %s/%s replicas", namespace, s.Name)
+		if _, err := tc.kClientSet.AppsV1().StatefulSets(namespace).Update(s); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonWorkloadRestored, "Restored %d replicas of %s %q in namespace %q", replicas, kindStatefulSet, s.Name, namespace)
+	}
+
+	if err := tc.resumeAutoscalers(t, namespace); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+//pauseAutoscalers raises to 1 the minReplicas of the horizontalpodautoscalers allowed to scale their target to zero.
+//An autoscaler does not scale a target without replicas unless its minReplicas is 0.
+//The minReplicas are saved in the SuspendedMinReplicasAnnotation to be restored when the team resumes
+func (tc *TeamController) pauseAutoscalers(t *aftouhv1.Team, namespace string) error {
+	var errs []error
+	one := int32(1)
+
+	hpas, err := tc.hpaLister.HorizontalPodAutoscalers(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, hpa := range hpas {
+		if hpa.Spec.MinReplicas == nil || *hpa.Spec.MinReplicas != 0 {
+			continue
+		}
+		hpa := hpa.DeepCopy()
+		hpa.Annotations = mergeKeys(hpa.Annotations, map[string]string{aftouhv1.SuspendedMinReplicasAnnotation: "0"})
+		hpa.Spec.MinReplicas = &one
+		klog.V(2).Infof("Pausing horizontalpodautoscaler %s/%s", namespace, hpa.Name)
+		if _, err := tc.kClientSet.AutoscalingV1().HorizontalPodAutoscalers(namespace).Update(hpa); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonWorkloadScaledDown, "Paused %s %q of namespace %q scaling %s %q from zero", kindHorizontalPodAutoscaler, hpa.Name, namespace, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+//resumeAutoscalers restores the minReplicas of the horizontalpodautoscalers paused by pauseAutoscalers.
+//A minReplicas changed since the suspension is kept
+func (tc *TeamController) resumeAutoscalers(t *aftouhv1.Team, namespace string) error {
+	var errs []error
+
+	hpas, err := tc.hpaLister.HorizontalPodAutoscalers(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, hpa := range hpas {
+		saved, ok := hpa.Annotations[aftouhv1.SuspendedMinReplicasAnnotation]
+		if !ok {
+			continue
+		}
+		hpa := hpa.DeepCopy()
+		if hpa.Spec.MinReplicas != nil && *hpa.Spec.MinReplicas == 1 {
+			n, err := strconv.ParseInt(saved, 10, 32)
+			if err != nil || n < 0 {
+				errs = append(errs, fmt.Errorf("HorizontalPodAutoscaler %s/%s: invalid %s annotation %q", namespace, hpa.Name, aftouhv1.SuspendedMinReplicasAnnotation, saved))
+				continue
+			}
+			minReplicas := int32(n)
+			hpa.Spec.MinReplicas = &minReplicas
+		}
+		delete(hpa.Annotations, aftouhv1.SuspendedMinReplicasAnnotation)
+		klog.V(2).Infof("Resuming horizontalpodautoscaler %s/%s", namespace, hpa.Name)
+		if _, err := tc.kClientSet.AutoscalingV1().HorizontalPodAutoscalers(namespace).Update(hpa); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tc.recorder.Eventf(t, corev1.EventTypeNormal, reasonWorkloadRestored, "Resumed %s %q of namespace %q", kindHorizontalPodAutoscaler, hpa.Name, namespace)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+//Deployments and statefulsets are watched to scale down the workloads started or scaled up in the namespaces of a
+//frozen team. Status updates are ignored, only the replicas and the saved ones matter
+
+func (tc *TeamController) addWorkload(obj interface{}) {
+	tc.enqueueNamespaceTeam(obj.(metav1.Object).GetNamespace())
+}
+
+func (tc *TeamController) updateWorkload(old, cur interface{}) {
+	oldObj, curObj := old.(metav1.Object), cur.(metav1.Object)
+	if oldObj.GetGeneration() == curObj.GetGeneration() && reflect.DeepEqual(oldObj.GetAnnotations(), curObj.GetAnnotations()) {
+		return
+	}
+	tc.enqueueNamespaceTeam(curObj.GetNamespace())
+}
+
+//updateAutoscaler ignores the status updates the autoscaler makes at each metrics evaluation. The autoscaling/v1
+//api also reports the current metrics in annotations, only the saved minReplicas one matters
+func (tc *TeamController) updateAutoscaler(old, cur interface{}) {
+	oldHpa, curHpa := old.(*autoscalingv1.HorizontalPodAutoscaler), cur.(*autoscalingv1.HorizontalPodAutoscaler)
+	if reflect.DeepEqual(oldHpa.Spec, curHpa.Spec) &&
+		oldHpa.Annotations[aftouhv1.SuspendedMinReplicasAnnotation] == curHpa.Annotations[aftouhv1.SuspendedMinReplicasAnnotation] {
+		return
+	}
+	tc.enqueueNamespaceTeam(curHpa.Namespace)
+}
+
+//saveReplicas saves the replicas of a running workload in its annotations and returns them.
+//It returns false if the workload is already scaled to zero. Replicas saved by a previous suspension are kept
+func saveReplicas(obj metav1.Object, replicas *int32) (int32, bool) {
+	current := int32(1)
+	if replicas != nil {
+		current = *replicas
+	}
+	if current == 0 {
+		return 0, false
+	}
+	if _, ok := obj.GetAnnotations()[aftouhv1.SuspendedReplicasAnnotation]; !ok {
+		obj.SetAnnotations(mergeKeys(obj.GetAnnotations(), map[string]string{aftouhv1.SuspendedReplicasAnnotation: strconv.Itoa(int(current))}))
+	}
+	return current, true
+}
+
+//restoreReplicas removes the saved replicas from the workload annotations and returns them.
+//It returns false if the workload was not scaled down by a suspension. Replicas changed since the suspension are kept
+func restoreReplicas(obj metav1.Object, replicas *int32) (int32, bool, error) {
+	saved, ok := obj.GetAnnotations()[aftouhv1.SuspendedReplicasAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+
+	restored := int32(1)
+	switch {
+	case replicas != nil && *replicas == 0:
+		n, err := strconv.ParseInt(saved, 10, 32)
+		if err != nil || n < 0 {
+			return 0, false, fmt.Errorf("invalid %s annotation %q", aftouhv1.SuspendedReplicasAnnotation, saved)
+		}
+		restored = int32(n)
+	case replicas != nil:
+		restored = *replicas
+	}
+	delete(obj.GetAnnotations(), aftouhv1.SuspendedReplicasAnnotation)
+	return restored, true, nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newSuspensionFixture returns a fixture of a ready team whose resourcequota was synced in the given state.
+// Its dev namespace runs the web deployment and the db statefulset with the given replicas
+func newSuspensionFixture(t *testing.T, state aftouhv1.TeamState, replicas int32, annotations map[string]string) (*fixture, *aftouhv1.Team) {
+	f := newFixture(t)
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Status = readyStatus(team)
+	team.Spec.State = state
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], ns.Name, nil)[0])
+	team.Spec.State = ""
+
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: ns.Name, Annotations: mergeKeys(nil, annotations)}
+	}
+	f.addObj(&appsv1.Deployment{ObjectMeta: meta("web"), Spec: appsv1.DeploymentSpec{Replicas: &replicas}})
+	f.addObj(&appsv1.StatefulSet{ObjectMeta: meta("db"), Spec: appsv1.StatefulSetSpec{Replicas: &replicas}})
+	return f, team
+}
+
+func TestSuspendTeam(t *testing.T) {
+	f, team := newSuspensionFixture(t, aftouhv1.TeamStateActive, 3, nil)
+	team.Spec.State = aftouhv1.TeamStateSuspended
+	ns := defaultTeamNamespace(team, "dev")
+	zero := int32(0)
+	saved := map[string]string{aftouhv1.SuspendedReplicasAnnotation: "3"}
+
+	//No new pod can start
+	rq := newResourceQuotas(team, getTeamEnvironments(team)[0], ns, nil)[0]
+	rq.Spec.Hard = corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(0, resource.DecimalSI)}
+	f.expectUpdateResourceQuotaAction(rq)
+	f.expectUpdateAction("deployments", &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns, Annotations: saved},
+		Spec:       appsv1.DeploymentSpec{Replicas: &zero},
+	})
+	f.expectUpdateAction("statefulsets", &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: ns, Annotations: saved},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &zero},
+	})
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Phase = aftouhv1.TeamPhaseSuspended
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestResumeTeam(t *testing.T) {
+	f, team := newSuspensionFixture(t, aftouhv1.TeamStateSuspended, 0, map[string]string{aftouhv1.SuspendedReplicasAnnotation: "3"})
+	team.Status.Phase = aftouhv1.TeamPhaseSuspended
+	ns := defaultTeamNamespace(team, "dev")
+	replicas := int32(3)
+
+	//The pods limit is lifted before the workloads scale up
+	f.expectUpdateResourceQuotaAction(newResourceQuotas(team, getTeamEnvironments(team)[0], ns, nil)[0])
+	f.expectUpdateAction("deployments", &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns, Annotations: map[string]string{}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+	f.expectUpdateAction("statefulsets", &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: ns, Annotations: map[string]string{}},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	})
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Phase = aftouhv1.TeamPhaseActive
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestSuspendingTeamWithRunningPods(t *testing.T) {
+	saved := map[string]string{aftouhv1.SuspendedReplicasAnnotation: "3"}
+	f, team := newSuspensionFixture(t, aftouhv1.TeamStateSuspended, 0, saved)
+	team.Spec.State = aftouhv1.TeamStateSuspended
+	ns := defaultTeamNamespace(team, "dev")
+
+	//The workloads are scaled down but one of their pods is still terminating, completed pods do not count
+	f.addObj(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: ns}, Status: corev1.PodStatus{Phase: corev1.PodRunning}})
+	f.addObj(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "job-1", Namespace: ns}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}})
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Phase = aftouhv1.TeamPhaseSuspending
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+// newAutoscaler returns a horizontalpodautoscaler of the web deployment
+func newAutoscaler(namespace string, minReplicas int32, annotations map[string]string) *autoscalingv1.HorizontalPodAutoscaler {
+	return &autoscalingv1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace, Annotations: annotations},
+		Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: kindDeployment, Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    5,
+		},
+	}
+}
+
+func TestSuspendTeamPausesAutoscaler(t *testing.T) {
+	saved := map[string]string{aftouhv1.SuspendedReplicasAnnotation: "3"}
+	f, team := newSuspensionFixture(t, aftouhv1.TeamStateSuspended, 0, saved)
+	team.Spec.State = aftouhv1.TeamStateSuspended
+	team.Status.Phase = aftouhv1.TeamPhaseSuspended
+	ns := defaultTeamNamespace(team, "dev")
+
+	//An autoscaler allowed to scale to zero would start the deployment again
+	f.addObj(newAutoscaler(ns, 0, nil))
+	other := newAutoscaler(ns, 2, nil)
+	other.Name = "api"
+	f.addObj(other)
+
+	f.expectUpdateAction("horizontalpodautoscalers", newAutoscaler(ns, 1, map[string]string{aftouhv1.SuspendedMinReplicasAnnotation: "0"}))
+
+	f.run(team.Name)
+}
+
+func TestResumeTeamResumesAutoscaler(t *testing.T) {
+	f, team := newSuspensionFixture(t, aftouhv1.TeamStateActive, 3, nil)
+	team.Status.Phase = aftouhv1.TeamPhaseSuspended
+	ns := defaultTeamNamespace(team, "dev")
+	f.addObj(newAutoscaler(ns, 1, map[string]string{aftouhv1.SuspendedMinReplicasAnnotation: "0"}))
+
+	f.expectUpdateAction("horizontalpodautoscalers", newAutoscaler(ns, 0, map[string]string{}))
+
+	expectedTeam := team.DeepCopy()
+	expectedTeam.Status.Phase = aftouhv1.TeamPhaseActive
+	f.expectUpdateTeamStatus(expectedTeam)
+
+	f.run(team.Name)
+}
+
+func TestUpdateAutoscaler(t *testing.T) {
+	f, team := newSuspensionFixture(t, aftouhv1.TeamStateSuspended, 0, nil)
+	tc, _, _ := f.newTeamController()
+	old := newAutoscaler(defaultTeamNamespace(team, "dev"), 0, nil)
+
+	//The current metrics reported in the annotations do not enqueue the team
+	cur := old.DeepCopy()
+	cur.Annotations = map[string]string{"autoscaling.alpha.kubernetes.io/current-metrics": "[]"}
+	cur.Status.CurrentReplicas = 1
+	tc.updateAutoscaler(old, cur)
+	if tc.queue.Len() != 0 {
+		t.Fatalf("expected no team to be enqueued, got %d", tc.queue.Len())
+	}
+
+	cur.Spec.MaxReplicas++
+	tc.updateAutoscaler(old, cur)
+	if tc.queue.Len() != 1 {
+		t.Fatalf("expected 1 team to be enqueued, got %d", tc.queue.Len())
+	}
+}
+
+func TestUpdateWorkload(t *testing.T) {
+	f, team := newSuspensionFixture(t, aftouhv1.TeamStateSuspended, 0, nil)
+	tc, _, _ := f.newTeamController()
+	old := f.deployLister[0]
+
+	//Status updates do not enqueue the team
+	cur := old.DeepCopy()
+	cur.Status.Replicas = 1
+	tc.updateWorkload(old, cur)
+	if tc.queue.Len() != 0 {
+		t.Fatalf("expected no team to be enqueued, got %d", tc.queue.Len())
+	}
+
+	cur.Generation++
+	tc.updateWorkload(old, cur)
+	if tc.queue.Len() != 1 {
+		t.Fatalf("expected 1 team to be enqueued, got %d", tc.queue.Len())
+	}
+	if key, _ := tc.queue.Get(); key != team.Name {
+		t.Errorf("expected team %q to be enqueued, got %v", team.Name, key)
+	}
+}
+
+func TestSaveReplicas(t *testing.T) {
+	zero, two := int32(0), int32(2)
+	d := &appsv1.Deployment{}
+
+	if _, ok := saveReplicas(d, &zero); ok || len(d.Annotations) != 0 {
+		t.Errorf("expected a stopped workload not to be scaled down, got annotations %v", d.Annotations)
+	}
+	if replicas, ok := saveReplicas(d, nil); !ok || replicas != 1 || d.Annotations[aftouhv1.SuspendedReplicasAnnotation] != "1" {
+		t.Errorf("expected the default single replica to be saved, got %d and annotations %v", replicas, d.Annotations)
+	}
+
+	//A workload scaled up during a suspension keeps the replicas saved first
+	if _, ok := saveReplicas(d, &two); !ok || d.Annotations[aftouhv1.SuspendedReplicasAnnotation] != "1" {
+		t.Errorf("expected saved replicas to be kept, got annotations %v", d.Annotations)
+	}
+}
+
+func TestRestoreReplicas(t *testing.T) {
+	zero, two := int32(0), int32(2)
+	annotated := func(saved string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{aftouhv1.SuspendedReplicasAnnotation: saved}}}
+	}
+
+	if _, ok, err := restoreReplicas(&appsv1.Deployment{}, &zero); ok || err != nil {
+		t.Errorf("expected a workload without saved replicas to be left unchanged, got %v, %v", ok, err)
+	}
+
+	d := annotated("3")
+	if replicas, ok, err := restoreReplicas(d, &zero); !ok || err != nil || replicas != 3 || len(d.Annotations) != 0 {
+		t.Errorf("expected 3 replicas to be restored, got %d, %v, %v and annotations %v", replicas, ok, err, d.Annotations)
+	}
+
+	//Replicas changed since the suspension are kept
+	if replicas, ok, _ := restoreReplicas(annotated("3"), &two); !ok || replicas != 2 {
+		t.Errorf("expected current replicas to be kept, got %d", replicas)
+	}
+
+	if _, _, err := restoreReplicas(annotated("many"), &zero); err == nil {
+		t.Error("expected an invalid annotation to fail")
+	}
+}
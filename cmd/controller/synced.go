@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog"
+)
+
+//parseSyncedResources parses the -synced-resources flag, a comma separated list of <kind>/<namespace>/<name>
+func parseSyncedResources(s string) ([]aftouhv1.TeamSyncedResource, error) {
+	var resources []aftouhv1.TeamSyncedResource
+	for _, ref := range strings.Split(s, ",") {
+		if ref = strings.TrimSpace(ref); ref == "" {
+			continue
+		}
+		parts := strings.Split(ref, "/")
+		if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid synced resource %q, expected <kind>/<namespace>/<name>", ref)
+		}
+		if parts[0] != aftouhv1.SyncedResourceKindConfigMap && parts[0] != aftouhv1.SyncedResourceKindSecret {
+			return nil, fmt.Errorf("invalid synced resource kind %q, expected %s or %s",
+				parts[0], aftouhv1.SyncedResourceKindConfigMap, aftouhv1.SyncedResourceKindSecret)
+		}
+		resources = append(resources, aftouhv1.TeamSyncedResource{Kind: parts[0], Namespace: parts[1], Name: parts[2]})
+	}
+	return resources, nil
+}
+
+//parseNamespaces parses a comma separated list of namespaces
+func parseNamespaces(s string) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(s, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+//syncedResource is a source configmap or secret copied into the team namespaces under the same name
+type syncedResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+//toSyncedResources converts the synced resources of the api
+func toSyncedResources(resources []aftouhv1.TeamSyncedResource) []syncedResource {
+	var out []syncedResource
+	for _, r := range resources {
+		out = append(out, syncedResource(r))
+	}
+	return out
+}
+
+//getSyncedResources returns the controller synced resources, the pull secrets and the team synced resources.
+//The copies keep the source name: a team resource cannot replace a copy of the controller ones
+func (tc *TeamController) getSyncedResources(t *aftouhv1.Team) []syncedResource {
+	var resources []syncedResource
+	names := make(map[string]string)
+	all := append(append(toSyncedResources(tc.defaults.SyncedResources), tc.pullSecrets.resources()...), tc.getTeamSyncedResources(t)...)
+	for _, r := range all {
+		key := r.Kind + "/" + r.Name
+		if from, ok := names[key]; ok {
+			if from != getReplicatedFrom(r) {
+				klog.Warningf("Ignoring synced %s %s of team %q, %s is already synced under this name", r.Kind, getReplicatedFrom(r), t.Name, from)
+			}
+			continue
+		}
+		names[key] = getReplicatedFrom(r)
+		resources = append(resources, r)
+	}
+	return resources
+}
+
+//getTeamSyncedResources returns the team synced resources of the allowed namespaces. The webhook rejects the other
+//ones, they are ignored for the teams created without the webhook
+func (tc *TeamController) getTeamSyncedResources(t *aftouhv1.Team) []syncedResource {
+	var resources []syncedResource
+	for _, r := range t.Spec.SyncedResources {
+		if !tc.isSyncedNamespace(r.Namespace) {
+			tc.recorder.Eventf(t, corev1.EventTypeWarning, reasonSyncForbidden,
+				"%s %s/%s is not synced, resources of namespace %q may not be synced", r.Kind, r.Namespace, r.Name, r.Namespace)
+			continue
+		}
+		resources = append(resources, syncedResource(r))
+	}
+	return resources
+}
+
+//isSyncedNamespace returns true if the teams may sync the resources of the namespace
+func (tc *TeamController) isSyncedNamespace(namespace string) bool {
+	for _, ns := range tc.syncedNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+//isSyncedResource returns true if the object is synced into the team namespaces
+func isSyncedResource(resources []syncedResource, kind string, obj metav1.Object) bool {
+	for _, r := range resources {
+		if r.Kind == kind && r.Namespace == obj.GetNamespace() && r.Name == obj.GetName() {
+			return true
+		}
+	}
+	return false
+}
+
+//getReplicatedFrom returns the value of the ReplicatedFromAnnotation of the copies of a synced resource
+func getReplicatedFrom(r syncedResource) string {
+	return r.Namespace + "/" + r.Name
+}
+
+//newSyncedObjectMeta returns the metadata of the copy of a synced resource in a team namespace
+func newSyncedObjectMeta(t *aftouhv1.Team, env, namespace string, class *aftouhv1.TeamClass, r syncedResource) metav1.ObjectMeta {
+	annotations := getObjectAnnotations(t, env, class)
+	annotations[aftouhv1.ReplicatedFromAnnotation] = getReplicatedFrom(r)
+	return metav1.ObjectMeta{
+		Name:        r.Name,
+		Namespace:   namespace,
+		Labels:      getObjectLabels(t, env, class),
+		Annotations: annotations,
+		OwnerReferences: []metav1.OwnerReference{
+			*metav1.NewControllerRef(t, aftouhv1.SchemeGroupVersion.WithKind("Team")),
+		},
+	}
+}
+
+func newSyncedConfigMap(t *aftouhv1.Team, env, namespace string, class *aftouhv1.TeamClass, source *corev1.ConfigMap) *corev1.ConfigMap {
+	r := syncedResource{Kind: kindConfigMap, Namespace: source.Namespace, Name: source.Name}
+	return &corev1.ConfigMap{
+		ObjectMeta: newSyncedObjectMeta(t, env, namespace, class, r),
+		Data:       source.Data,
+		BinaryData: source.BinaryData,
+	}
+}
+
+func newSyncedSecret(t *aftouhv1.Team, env, namespace string, class *aftouhv1.TeamClass, source *corev1.Secret) *corev1.Secret {
+	r := syncedResource{Kind: kindSecret, Namespace: source.Namespace, Name: source.Name}
+	return &corev1.Secret{
+		ObjectMeta: newSyncedObjectMeta(t, env, namespace, class, r),
+		Type:       source.Type,
+		Data:       source.Data,
+	}
+}
+
+//syncSyncedResources copies the synced resources into the namespace and prunes the copies of the resources no longer synced.
+//The copies of a missing source are kept until it is created again
+func (tc *TeamController) syncSyncedResources(t *aftouhv1.Team, env, namespaceName string, class *aftouhv1.TeamClass) error {
+	var errs []error
+	expected := map[string]map[string]bool{
+		kindConfigMap: {},
+		kindSecret:    {},
+	}
+	for _, r := range tc.getSyncedResources(t) {
+		expected[r.Kind][getReplicatedFrom(r)] = true
+		//The source lives in the team namespace
+		if r.Namespace == namespaceName {
+			continue
+		}
+
+		var err error
+		switch r.Kind {
+		case kindConfigMap:
+			var source *corev1.ConfigMap
+			if source, err = tc.cmLister.ConfigMaps(r.Namespace).Get(r.Name); err == nil {
+				err = tc.syncConfigMapCopy(t, newSyncedConfigMap(t, env, namespaceName, class, source))
+			}
+		case kindSecret:
+			var source *corev1.Secret
+			if source, err = tc.secretLister.Secrets(r.Namespace).Get(r.Name); err == nil {
+				err = tc.syncSecretCopy(t, newSyncedSecret(t, env, namespaceName, class, source))
+			}
+		}
+		if errors.IsNotFound(err) {
+			klog.Warningf("Synced %s %s of team %q not found", r.Kind, getReplicatedFrom(r), t.Name)
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	cms, err := tc.cmLister.ConfigMaps(namespaceName).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, cm := range cms {
+		if isStaleCopy(t, cm, expected[kindConfigMap]) {
+			klog.V(2).Infof("Deleting configmap %s/%s", namespaceName, cm.Name)
+			err := tc.kClientSet.CoreV1().ConfigMaps(namespaceName).Delete(cm.Name, &metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	secrets, err := tc.secretLister.Secrets(namespaceName).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets {
+		if isStaleCopy(t, secret, expected[kindSecret]) {
+			klog.V(2).Infof("Deleting secret %s/%s", namespaceName, secret.Name)
+			err := tc.kClientSet.CoreV1().Secrets(namespaceName).Delete(secret.Name, &metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+//isStaleCopy returns true if the object is a copy made for the team of a resource no longer synced
+func isStaleCopy(t *aftouhv1.Team, obj metav1.Object, expected map[string]bool) bool {
+	from, ok := obj.GetAnnotations()[aftouhv1.ReplicatedFromAnnotation]
+	return ok && !expected[from] && metav1.IsControlledBy(obj, t)
+}
+
+func (tc *TeamController) syncConfigMapCopy(t *aftouhv1.Team, expectedCm *corev1.ConfigMap) error {
+	cm, err := tc.cmLister.ConfigMaps(expectedCm.Namespace).Get(expectedCm.Name)
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("Creating configmap %s/%s", expectedCm.Namespace, expectedCm.Name)
+		_, err = tc.kClientSet.CoreV1().ConfigMaps(expectedCm.Namespace).Create(expectedCm)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(cm, t) {
+		msg := fmt.Sprintf(messageResourceExists, cm.Name)
+		tc.recorder.Event(t, corev1.EventTypeWarning, errResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+
+	//Check of source or external modification
+	if !reflect.DeepEqual(cm.Data, expectedCm.Data) || !reflect.DeepEqual(cm.BinaryData, expectedCm.BinaryData) ||
+		missingLabels(cm, expectedCm.Labels) || missingAnnotations(cm, expectedCm.Annotations) {
+		cm = cm.DeepCopy()
+		cm.Data = expectedCm.Data
+		cm.BinaryData = expectedCm.BinaryData
+		mergeLabels(cm, expectedCm.Labels)
+		mergeAnnotations(cm, expectedCm.Annotations)
+		klog.V(2).Infof("Updating configmap %s/%s", cm.Namespace, cm.Name)
+		_, err = tc.kClientSet.CoreV1().ConfigMaps(cm.Namespace).Update(cm)
+	}
+	return err
+}
+
+func (tc *TeamController) syncSecretCopy(t *aftouhv1.Team, expectedSecret *corev1.Secret) error {
+	secret, err := tc.secretLister.Secrets(expectedSecret.Namespace).Get(expectedSecret.Name)
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("Creating secret %s/%s", expectedSecret.Namespace, expectedSecret.Name)
+		_, err = tc.kClientSet.CoreV1().Secrets(expectedSecret.Namespace).Create(expectedSecret)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if !metav1.IsControlledBy(secret, t) {
+		msg := fmt.Sprintf(messageResourceExists, secret.Name)
+		tc.recorder.Event(t, corev1.EventTypeWarning, errResourceExists, msg)
+		return fmt.Errorf(msg)
+	}
+
+	//The secret type is immutable: the copy is recreated when the source type changes
+	if secret.Type != expectedSecret.Type {
+		klog.V(2).Infof("Deleting secret %s/%s", secret.Namespace, secret.Name)
+		if err := tc.kClientSet.CoreV1().Secrets(secret.Namespace).Delete(secret.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		klog.V(2).Infof("Creating secret %s/%s", expectedSecret.Namespace, expectedSecret.Name)
+		_, err = tc.kClientSet.CoreV1().Secrets(expectedSecret.Namespace).Create(expectedSecret)
+		return err
+	}
+
+	//Check of source or external modification
+	if !reflect.DeepEqual(secret.Data, expectedSecret.Data) ||
+		missingLabels(secret, expectedSecret.Labels) || missingAnnotations(secret, expectedSecret.Annotations) {
+		secret = secret.DeepCopy()
+		secret.Data = expectedSecret.Data
+		mergeLabels(secret, expectedSecret.Labels)
+		mergeAnnotations(secret, expectedSecret.Annotations)
+		klog.V(2).Infof("Updating secret %s/%s", secret.Namespace, secret.Name)
+		_, err = tc.kClientSet.CoreV1().Secrets(secret.Namespace).Update(secret)
+	}
+	return err
+}
+
+//Source configmaps and secrets are watched so that their changes reach every copy. The copies are watched as
+//objects owned by a team. The copies of a deleted source are kept
+
+func (tc *TeamController) addConfigMap(obj interface{}) {
+	tc.enqueueSourceTeams(kindConfigMap, obj.(*corev1.ConfigMap))
+}
+
+func (tc *TeamController) updateConfigMap(old, cur interface{}) {
+	curCm := cur.(*corev1.ConfigMap)
+	if old.(*corev1.ConfigMap).ResourceVersion != curCm.ResourceVersion {
+		tc.enqueueSourceTeams(kindConfigMap, curCm)
+	}
+	tc.updateObj(old, cur)
+}
+
+func (tc *TeamController) addSecret(obj interface{}) {
+	tc.enqueueSourceTeams(kindSecret, obj.(*corev1.Secret))
+}
+
+func (tc *TeamController) updateSecret(old, cur interface{}) {
+	curSecret := cur.(*corev1.Secret)
+	if old.(*corev1.Secret).ResourceVersion != curSecret.ResourceVersion {
+		tc.enqueueSourceTeams(kindSecret, curSecret)
+	}
+	tc.updateObj(old, cur)
+}
+
+//enqueueSourceTeams enqueues the teams syncing the object. Every team syncs the controller resources and the pull secrets,
+//the team resources are only synced from the allowed namespaces
+func (tc *TeamController) enqueueSourceTeams(kind string, obj metav1.Object) {
+	all := isSyncedResource(toSyncedResources(tc.defaults.SyncedResources), kind, obj) || isSyncedResource(tc.pullSecrets.resources(), kind, obj)
+	if !all && !tc.isSyncedNamespace(obj.GetNamespace()) {
+		return
+	}
+
+	teams, err := tc.tLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("Couldn't list teams: %v", err))
+		return
+	}
+	for _, t := range teams {
+		if all || isSyncedResource(toSyncedResources(t.Spec.SyncedResources), kind, obj) {
+			tc.enqueue(t)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	testCABundle = aftouhv1.TeamSyncedResource{Kind: aftouhv1.SyncedResourceKindConfigMap, Namespace: "platform", Name: "ca-bundle"}
+	testProxy    = aftouhv1.TeamSyncedResource{Kind: aftouhv1.SyncedResourceKindSecret, Namespace: "platform", Name: "proxy"}
+)
+
+// newSyncedFixture returns a fixture of a synced team syncing the ca-bundle configmap of the platform namespace
+func newSyncedFixture(t *testing.T) (*fixture, *aftouhv1.Team, *corev1.ConfigMap) {
+	f := newFixture(t)
+	f.syncedNamespaces = []string{"platform"}
+
+	team := newTeam("test", "test desciption", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.SyncedResources = []aftouhv1.TeamSyncedResource{testCABundle}
+	team.Status = readyStatus(team)
+	f.addObj(team)
+
+	ns := newNamespace(team, "dev", defaultTeamNamespace(team, "dev"), nil)
+	ns.Status.Phase = corev1.NamespaceActive
+	f.addObj(ns)
+	f.addObj(newResourceQuotas(team, getTeamEnvironments(team)[0], ns.Name, nil)[0])
+
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "platform"},
+		Data:       map[string]string{"ca.crt": "new"},
+	}
+	f.addObj(source)
+
+	return f, team, source
+}
+
+func TestParseSyncedResources(t *testing.T) {
+	resources, err := parseSyncedResources("ConfigMap/platform/ca-bundle, Secret/platform/proxy")
+	if err != nil || !reflect.DeepEqual(resources, []aftouhv1.TeamSyncedResource{testCABundle, testProxy}) {
+		t.Errorf("expected the ca-bundle and proxy resources, got %+v, %v", resources, err)
+	}
+
+	for _, s := range []string{"Deployment/platform/proxy", "ConfigMap/ca-bundle", "Secret//proxy"} {
+		if _, err := parseSyncedResources(s); err == nil {
+			t.Errorf("expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestGetSyncedResources(t *testing.T) {
+	f := newFixture(t)
+	f.defaults.SyncedResources = []aftouhv1.TeamSyncedResource{testProxy}
+	f.syncedNamespaces = []string{"platform", "network"}
+	tc, _, _ := f.newTeamController()
+
+	//The copy of the controller proxy secret is not replaced by the team one
+	team := newTeam("test", "", "dev", corev1.ResourceQuotaSpec{})
+	team.Spec.SyncedResources = []aftouhv1.TeamSyncedResource{
+		testCABundle,
+		{Kind: aftouhv1.SyncedResourceKindSecret, Namespace: "network", Name: "proxy"},
+		testProxy,
+	}
+
+	expected := []syncedResource{syncedResource(testProxy), syncedResource(testCABundle)}
+	if resources := tc.getSyncedResources(team); !reflect.DeepEqual(resources, expected) {
+		t.Errorf("expected synced resources %+v, got %+v", expected, resources)
+	}
+}
+
+func TestCreateSyncedResources(t *testing.T) {
+	f, team, source := newSyncedFixture(t)
+	f.defaults.SyncedResources = []aftouhv1.TeamSyncedResource{testProxy}
+	ns := defaultTeamNamespace(team, "dev")
+
+	proxy := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "proxy", Namespace: "platform"},
+		Data:       map[string][]byte{"HTTPS_PROXY": []byte("http://proxy:3128")},
+	}
+	f.addObj(proxy)
+
+	f.expectCreateAction("secrets", newSyncedSecret(team, "dev", ns, nil, proxy))
+	f.expectCreateAction("configmaps", newSyncedConfigMap(team, "dev", ns, nil, source))
+
+	f.run(team.Name)
+}
+
+func TestOverwriteSyncedConfigMap(t *testing.T) {
+	f, team, source := newSyncedFixture(t)
+	ns := defaultTeamNamespace(team, "dev")
+
+	cm := newSyncedConfigMap(team, "dev", ns, nil, source)
+	cm.Data = map[string]string{"ca.crt": "old"}
+	f.addObj(cm)
+
+	f.expectUpdateAction("configmaps", newSyncedConfigMap(team, "dev", ns, nil, source))
+
+	f.run(team.Name)
+}
+
+func TestPruneSyncedConfigMap(t *testing.T) {
+	f, team, source := newSyncedFixture(t)
+	ns := defaultTeamNamespace(team, "dev")
+	f.addObj(newSyncedConfigMap(team, "dev", ns, nil, source))
+
+	stale := newSyncedConfigMap(team, "dev", ns, nil, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "flags", Namespace: "platform"}})
+	f.addObj(stale)
+
+	//Configmaps not owned by the team are kept
+	other := stale.DeepCopy()
+	other.Name = "other"
+	other.OwnerReferences = nil
+	f.addObj(other)
+
+	f.expectDeleteAction("configmaps", stale)
+
+	f.run(team.Name)
+}
+
+func TestForbiddenSyncedResource(t *testing.T) {
+	f, team, source := newSyncedFixture(t)
+	ns := defaultTeamNamespace(team, "dev")
+	f.addObj(newSyncedConfigMap(team, "dev", ns, nil, source))
+
+	//Secrets of the namespaces not allowed are not copied
+	token := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin-token", Namespace: "kube-system"},
+		Data:       map[string][]byte{"token": []byte("secret")},
+	}
+	f.addObj(token)
+	team.Spec.SyncedResources = append(team.Spec.SyncedResources,
+		aftouhv1.TeamSyncedResource{Kind: aftouhv1.SyncedResourceKindSecret, Namespace: "kube-system", Name: "admin-token"})
+
+	f.run(team.Name)
+}
+
+func TestEnqueueSourceTeams(t *testing.T) {
+	f := newFixture(t)
+	f.syncedNamespaces = []string{"platform"}
+	withSynced := newTeam("with-synced", "", "dev", corev1.ResourceQuotaSpec{})
+	withSynced.Spec.SyncedResources = []aftouhv1.TeamSyncedResource{testCABundle}
+	f.addObj(withSynced)
+	f.addObj(newTeam("without-synced", "", "dev", corev1.ResourceQuotaSpec{}))
+
+	tc, _, _ := f.newTeamController()
+	tc.addConfigMap(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "platform"}})
+
+	if tc.queue.Len() != 1 {
+		t.Fatalf("expected 1 team to be enqueued, got %d", tc.queue.Len())
+	}
+	if key, _ := tc.queue.Get(); key != "with-synced" {
+		t.Errorf("expected team %q to be enqueued, got %v", "with-synced", key)
+	}
+}
@@ -1,39 +1,155 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
+	"sort"
+	"strings"
 
 	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 )
 
 const (
 	rqName = "team-default-rq"
+	//rqNameFormat is the name format of the resourcequota objects. rqName is the name of the default one
+	rqNameFormat = "team-%s-rq"
+	lrName       = "team-default-lr"
+	//Baseline networkpolicies
+	npDenyIngress          = "team-default-deny-ingress"
+	npAllowSameNamespace   = "team-allow-same-namespace"
+	npAllowTeamNamespaces  = "team-allow-team-namespaces"
+	npAllowOtherNamespaces = "team-allow-namespaces"
+
+	//rbPrefix is the prefix of the rolebindings managed for each member role
+	rbPrefix = "team-"
+
+	//Team condition reasons
+	reasonSynced               = "Synced"
+	reasonSyncFailed           = "SyncFailed"
+	reasonNotFound             = "NotFound"
+	reasonNamespaceActive      = "NamespaceActive"
+	reasonNamespaceNotActive   = "NamespaceNotActive"
+	reasonResourceQuotaCreated = "ResourceQuotaCreated"
+	reasonLimitRangeCreated    = "LimitRangeCreated"
+	reasonNotRequired          = "NotRequired"
+	reasonInvalidNamespaceName = "InvalidNamespaceName"
+	reasonPodSecurityCompliant = "Compliant"
+	reasonPodSecurityViolated  = "PodSecurityViolations"
+	reasonNamespaceTerminating = "NamespaceTerminating"
+	reasonMigrationPending     = "MigrationPending"
+	reasonCopyingResources     = "CopyingResources"
+	reasonUncopiedResources    = "UncopiedResources"
+	reasonQuotaWarning         = "QuotaWarning"
+	reasonQuotaCritical        = "QuotaCritical"
+	reasonBudgetExceeded       = "BudgetExceeded"
+	reasonWithinBudget         = "WithinBudget"
+	reasonParentNotFound       = "ParentNotFound"
+	reasonExpiryScheduled      = "ExpiryScheduled"
+	reasonExpiresSoon          = "ExpiresSoon"
+
+	//Team event reasons
+	reasonNamespaceMigrationPending = "NamespaceMigrationPending"
+	reasonNamespaceReleased         = "NamespaceReleased"
+	reasonResourceCopied            = "ResourceCopied"
+	reasonNamespaceRetired          = "NamespaceRetired"
+	reasonNamespacePruned           = "NamespacePruned"
+	reasonQuotaThresholdExceeded    = "QuotaThresholdExceeded"
+	reasonQuotaPressureRelieved     = "QuotaPressureRelieved"
+	reasonWorkloadScaledDown        = "WorkloadScaledDown"
+	reasonWorkloadRestored          = "WorkloadRestored"
+	reasonTeamExpired               = "TeamExpired"
+	reasonSyncForbidden             = "SyncForbidden"
+	reasonRoleForbidden             = "RoleForbidden"
 )
 
-func newResourceQuota(t *aftouhv1.Team) *corev1.ResourceQuota {
+//newResourceQuotas returns the resourcequotas of a team environment, the default one first
+func newResourceQuotas(t *aftouhv1.Team, env aftouhv1.TeamEnvironment, namespace string, class *aftouhv1.TeamClass) []*corev1.ResourceQuota {
+	var rqs []*corev1.ResourceQuota
+	for _, quota := range env.GetResourceQuotas() {
+		rqs = append(rqs, newResourceQuota(t, env.Name, namespace, quota, class))
+	}
+	return rqs
+}
+
+//newResourceQuota returns the resourcequota of a team quota. The class resourcequota only applies to the default quota
+func newResourceQuota(t *aftouhv1.Team, env, namespace string, quota aftouhv1.TeamResourceQuota, class *aftouhv1.TeamClass) *corev1.ResourceQuota {
+	spec := *quota.ResourceQuotaSpec.DeepCopy()
+	if class != nil && quota.Name == aftouhv1.DefaultResourceQuotaName {
+		spec = mergeResourceQuotaSpec(class.Spec.ResourceQuotaSpec, quota.ResourceQuotaSpec)
+	}
+	//No pod can start in the namespaces of a suspended or archived team
+	if isFrozen(t) && quota.Name == aftouhv1.DefaultResourceQuotaName {
+		if spec.Hard == nil {
+			spec.Hard = corev1.ResourceList{}
+		}
+		spec.Hard[corev1.ResourcePods] = *resource.NewQuantity(0, resource.DecimalSI)
+	}
+
 	return &corev1.ResourceQuota{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      rqName,
-			Namespace: getTeamNamespace(t),
-			Labels:    getTeamLabels(t),
+			Name:        getResourceQuotaName(quota.Name),
+			Namespace:   namespace,
+			Labels:      getObjectLabels(t, env, class),
+			Annotations: getObjectAnnotations(t, env, class),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(t, aftouhv1.SchemeGroupVersion.WithKind("Team")),
+			},
+		},
+		Spec: spec,
+	}
+}
+
+//newLimitRange returns the limitrange of a team environment or nil if neither the team nor its class define one
+func newLimitRange(t *aftouhv1.Team, env, namespace string, class *aftouhv1.TeamClass) *corev1.LimitRange {
+	spec := getTeamLimitRange(t, class)
+	if spec == nil {
+		return nil
+	}
+
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        lrName,
+			Namespace:   namespace,
+			Labels:      getObjectLabels(t, env, class),
+			Annotations: getObjectAnnotations(t, env, class),
 			OwnerReferences: []metav1.OwnerReference{
 				*metav1.NewControllerRef(t, aftouhv1.SchemeGroupVersion.WithKind("Team")),
 			},
 		},
-		Spec: t.Spec.ResourceQuotaSpec,
+		Spec: *spec.DeepCopy(),
+	}
+}
+
+//getTeamLimitRange returns the team limitrange spec, falling back to the class one
+func getTeamLimitRange(t *aftouhv1.Team, class *aftouhv1.TeamClass) *corev1.LimitRangeSpec {
+	if t.Spec.LimitRange != nil {
+		return t.Spec.LimitRange
+	}
+	if class != nil {
+		return class.Spec.LimitRange
 	}
+	return nil
 }
 
-func newNamespace(t *aftouhv1.Team) *corev1.Namespace {
+func newNamespace(t *aftouhv1.Team, env, namespace string, class *aftouhv1.TeamClass) *corev1.Namespace {
+	//Pod security labels are only set on namespaces
+	labels := getObjectLabels(t, env, class)
+	for k, v := range getPodSecurityLabels(t.Spec.GetPodSecurity(env)) {
+		labels[k] = v
+	}
+	annotations := getObjectAnnotations(t, env, class)
+	annotations[aftouhv1.ManagedLabelsAnnotation] = joinKeys(labels)
+
 	return &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   getTeamNamespace(t),
-			Labels: getTeamLabels(t),
+			Name:        namespace,
+			Labels:      labels,
+			Annotations: annotations,
 			OwnerReferences: []metav1.OwnerReference{
 				*metav1.NewControllerRef(t, aftouhv1.SchemeGroupVersion.WithKind("Team")),
 			},
@@ -42,11 +158,134 @@ func newNamespace(t *aftouhv1.Team) *corev1.Namespace {
 	}
 }
 
+//newNetworkPolicies returns the baseline networkpolicies of a team environment for the team networkpolicy mode
+func newNetworkPolicies(t *aftouhv1.Team, env, namespace string, class *aftouhv1.TeamClass) []*networkingv1.NetworkPolicy {
+	np := t.Spec.NetworkPolicy
+	if np == nil || np.Mode == "" || np.Mode == aftouhv1.NetworkPolicyOpen {
+		return nil
+	}
+
+	newPolicy := func(name string, ingress []networkingv1.NetworkPolicyIngressRule) *networkingv1.NetworkPolicy {
+		return &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      getObjectLabels(t, env, class),
+				Annotations: getObjectAnnotations(t, env, class),
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(t, aftouhv1.SchemeGroupVersion.WithKind("Team")),
+				},
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{},
+				Ingress:     ingress,
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		}
+	}
+	fromPeers := func(peers ...networkingv1.NetworkPolicyPeer) []networkingv1.NetworkPolicyIngressRule {
+		return []networkingv1.NetworkPolicyIngressRule{{From: peers}}
+	}
+
+	policies := []*networkingv1.NetworkPolicy{
+		newPolicy(npDenyIngress, nil),
+		newPolicy(npAllowSameNamespace, fromPeers(networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}})),
+	}
+
+	//Namespaces of the same team are selected by the team label
+	if np.Mode == aftouhv1.NetworkPolicyTeamIsolated {
+		teamSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"team": getTeamLabels(t, env)["team"]}}
+		policies = append(policies, newPolicy(npAllowTeamNamespaces, fromPeers(networkingv1.NetworkPolicyPeer{NamespaceSelector: teamSelector})))
+	}
+
+	if len(np.AllowedNamespaces) > 0 {
+		var peers []networkingv1.NetworkPolicyPeer
+		for i := range np.AllowedNamespaces {
+			peers = append(peers, networkingv1.NetworkPolicyPeer{NamespaceSelector: np.AllowedNamespaces[i].DeepCopy()})
+		}
+		policies = append(policies, newPolicy(npAllowOtherNamespaces, fromPeers(peers...)))
+	}
+
+	return policies
+}
+
+//newRoleBindings returns the rolebindings of a team environment indexed by name.
+//Members sharing the same role are bound by a single rolebinding
+func newRoleBindings(t *aftouhv1.Team, env, namespaceName string, class *aftouhv1.TeamClass) map[string]*rbacv1.RoleBinding {
+	rbs := make(map[string]*rbacv1.RoleBinding)
+	for _, m := range t.Spec.Members {
+		name := rbPrefix + m.Role
+		rb, ok := rbs[name]
+		if !ok {
+			rb = &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        name,
+					Namespace:   namespaceName,
+					Labels:      getObjectLabels(t, env, class),
+					Annotations: getObjectAnnotations(t, env, class),
+					OwnerReferences: []metav1.OwnerReference{
+						*metav1.NewControllerRef(t, aftouhv1.SchemeGroupVersion.WithKind("Team")),
+					},
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.GroupName,
+					Kind:     "ClusterRole",
+					Name:     m.Role,
+				},
+			}
+			rbs[name] = rb
+		}
+		rb.Subjects = append(rb.Subjects, newSubject(m, namespaceName))
+	}
+	return rbs
+}
+
+//sortedRoleBindingNames returns the rolebinding names in a stable order
+func sortedRoleBindingNames(rbs map[string]*rbacv1.RoleBinding) []string {
+	keys := make([]string, 0, len(rbs))
+	for k := range rbs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//newSubject returns the rbac subject of a team member.
+//Service accounts without namespace belong to the team namespace
+func newSubject(m aftouhv1.TeamMember, namespace string) rbacv1.Subject {
+	if m.Kind == aftouhv1.MemberKindServiceAccount {
+		if m.Namespace != "" {
+			namespace = m.Namespace
+		}
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: m.Name, Namespace: namespace}
+	}
+	return rbacv1.Subject{Kind: m.Kind, APIGroup: rbacv1.GroupName, Name: m.Name}
+}
+
+//mergeResourceQuotaSpec returns the class resourcequota overridden by the team one
+func mergeResourceQuotaSpec(class, team corev1.ResourceQuotaSpec) corev1.ResourceQuotaSpec {
+	spec := *class.DeepCopy()
+	if len(team.Hard) > 0 && spec.Hard == nil {
+		spec.Hard = corev1.ResourceList{}
+	}
+	for name, q := range team.Hard {
+		spec.Hard[name] = q.DeepCopy()
+	}
+	if len(team.Scopes) > 0 {
+		spec.Scopes = append([]corev1.ResourceQuotaScope(nil), team.Scopes...)
+	}
+	if team.ScopeSelector != nil {
+		spec.ScopeSelector = team.ScopeSelector.DeepCopy()
+	}
+	return spec
+}
+
 func newTeam(name, description, environment string, rqSpec corev1.ResourceQuotaSpec) *aftouhv1.Team {
 	return &aftouhv1.Team{
 		TypeMeta: metav1.TypeMeta{APIVersion: aftouhv1.SchemeGroupVersion.String()},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name:       name,
+			Finalizers: []string{aftouhv1.TeamFinalizer},
 		},
 		Spec: aftouhv1.TeamSpec{
 			Name:              name,
@@ -57,84 +296,410 @@ func newTeam(name, description, environment string, rqSpec corev1.ResourceQuotaS
 	}
 }
 
-func getTeamNamespace(t *aftouhv1.Team) string {
-	namespaceFormat := "team-%s-%s"
-	return fmt.Sprintf(namespaceFormat, t.Spec.Name, t.Spec.Environment)
+//getTeamEnvironments returns the team environments.
+//Teams without spec.environments have a single environment defined by spec.environment
+func getTeamEnvironments(t *aftouhv1.Team) []aftouhv1.TeamEnvironment {
+	return t.Spec.GetEnvironments()
+}
+
+func getResourceQuotaName(quota string) string {
+	return fmt.Sprintf(rqNameFormat, quota)
 }
 
-func getTeamLabels(t *aftouhv1.Team) map[string]string {
+func getTeamLabels(t *aftouhv1.Team, env string) map[string]string {
 	return map[string]string{
 		"team": t.Spec.Name,
-		"env":  t.Spec.Environment,
+		"env":  env,
 	}
 }
 
-func missingLabels(t *aftouhv1.Team, obj metav1.Object) bool {
+//getObjectLabels returns the labels of the objects managed for a team environment.
+//Team labels take precedence over the class ones
+func getObjectLabels(t *aftouhv1.Team, env string, class *aftouhv1.TeamClass) map[string]string {
+	labels := make(map[string]string)
+	if class != nil {
+		for k, v := range class.Spec.Labels {
+			labels[k] = v
+		}
+	}
+	if md := t.Spec.NamespaceMetadata; md != nil {
+		for k, v := range md.Labels {
+			labels[k] = v
+		}
+	}
+	for k, v := range getTeamLabels(t, env) {
+		labels[k] = v
+	}
+	return labels
+}
+
+//getObjectAnnotations returns the annotations of the objects managed for a team environment.
+//They also record the label and annotation keys set by the controller, so that the keys no longer
+//defined by the team or its class can be removed without touching the ones set by other tools
+func getObjectAnnotations(t *aftouhv1.Team, env string, class *aftouhv1.TeamClass) map[string]string {
+	annotations := make(map[string]string)
+	if class != nil {
+		for k, v := range class.Spec.Annotations {
+			annotations[k] = v
+		}
+	}
+	if md := t.Spec.NamespaceMetadata; md != nil {
+		for k, v := range md.Annotations {
+			annotations[k] = v
+		}
+	}
+	annotations[aftouhv1.ManagedLabelsAnnotation] = joinKeys(getObjectLabels(t, env, class))
+	annotations[aftouhv1.ManagedAnnotationsAnnotation] = joinKeys(annotations)
+	return annotations
+}
+
+//missingLabels returns true if one of the expected labels is missing or if a label previously set by the controller is no longer expected
+func missingLabels(obj metav1.Object, expected map[string]string) bool {
+	return missingKeys(obj.GetLabels(), expected) ||
+		len(staleKeys(obj.GetLabels(), obj.GetAnnotations()[aftouhv1.ManagedLabelsAnnotation], expected)) > 0
+}
+
+//mergeLabels removes the stale labels of the object and sets the expected ones
+func mergeLabels(obj metav1.Object, expected map[string]string) {
 	labels := obj.GetLabels()
-	if labels == nil {
-		return true
+	for _, k := range staleKeys(labels, obj.GetAnnotations()[aftouhv1.ManagedLabelsAnnotation], expected) {
+		delete(labels, k)
+	}
+	obj.SetLabels(mergeKeys(labels, expected))
+}
+
+func missingAnnotations(obj metav1.Object, expected map[string]string) bool {
+	annotations := obj.GetAnnotations()
+	return missingKeys(annotations, expected) ||
+		len(staleKeys(annotations, annotations[aftouhv1.ManagedAnnotationsAnnotation], expected)) > 0
+}
+
+func mergeAnnotations(obj metav1.Object, expected map[string]string) {
+	annotations := obj.GetAnnotations()
+	for _, k := range staleKeys(annotations, annotations[aftouhv1.ManagedAnnotationsAnnotation], expected) {
+		delete(annotations, k)
 	}
-	for k, v := range getTeamLabels(t) {
-		v2, ok := labels[k]
+	obj.SetAnnotations(mergeKeys(annotations, expected))
+}
+
+//staleKeys returns the keys of the managed list that are still set but no longer expected
+func staleKeys(current map[string]string, managed string, expected map[string]string) []string {
+	var stale []string
+	for _, k := range strings.Split(managed, ",") {
+		if _, ok := current[k]; !ok {
+			continue
+		}
+		if _, ok := expected[k]; !ok {
+			stale = append(stale, k)
+		}
+	}
+	return stale
+}
+
+//joinKeys returns the sorted keys of the map as a comma separated list
+func joinKeys(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+//missingKeys returns true if one of the expected keys is missing or has a different value
+func missingKeys(current, expected map[string]string) bool {
+	for k, v := range expected {
+		v2, ok := current[k]
 		if !ok || (v != v2) {
 			return true
-
 		}
 	}
 	return false
 }
 
-func mergeLabels(t *aftouhv1.Team, obj metav1.Object) {
-	labels := obj.GetLabels()
-	if labels == nil {
-		labels = make(map[string]string)
+func mergeKeys(current, expected map[string]string) map[string]string {
+	if len(expected) == 0 {
+		return current
 	}
-	for k, v := range getTeamLabels(t) {
-		labels[k] = v
+	if current == nil {
+		current = make(map[string]string)
 	}
-	obj.SetLabels(labels)
+	for k, v := range expected {
+		current[k] = v
+	}
+	return current
 }
 
-func (tc *TeamController) calculateTeamStatus(t *aftouhv1.Team) (aftouhv1.TeamStatus, error) {
-	var ts aftouhv1.TeamStatus
-	//Get namespace
-	allNS, err := tc.nLister.List(labels.Everything())
-	if err != nil {
-		return ts, err
+func (tc *TeamController) calculateTeamStatus(t *aftouhv1.Team, migrations map[string]migrationProgress, syncErr error) (aftouhv1.TeamStatus, error) {
+	ts := aftouhv1.TeamStatus{
+		ObservedGeneration: t.Generation,
+		Conditions:         append([]aftouhv1.TeamCondition(nil), t.Status.Conditions...),
 	}
-	var ownedNS []*corev1.Namespace
-	buf := new(bytes.Buffer)
-	for _, ns := range allNS {
-		if !metav1.IsControlledBy(ns, t) {
-			continue
-		}
-		if len(ownedNS) > 0 {
-			buf.WriteByte(',')
+	now := metav1.NewTime(tc.clock.Now())
+
+	//A missing class is reported by the sync error. The team limitrange is expected in that case
+	class, _ := tc.getTeamClass(t)
+	expectLimitRange := getTeamLimitRange(t, class) != nil
+
+	nsCond := newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionTrue, reasonNamespaceActive, "", now)
+	rqCond := newTeamCondition(aftouhv1.TeamResourceQuotaReady, corev1.ConditionTrue, reasonResourceQuotaCreated, "", now)
+	lrCond := newTeamCondition(aftouhv1.TeamLimitRangeReady, corev1.ConditionTrue, reasonLimitRangeCreated, "", now)
+	if !expectLimitRange {
+		lrCond.Reason = reasonNotRequired
+	}
+	conflictCond := newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionFalse, "", "", now)
+	migratingCond := newTeamCondition(aftouhv1.TeamMigrating, corev1.ConditionFalse, "", "", now)
+	var pending, copying, uncopied, approvals []string
+	//Namespaces without enforced pod security level are compliant
+	psCond := newTeamCondition(aftouhv1.TeamPodSecurityCompliant, corev1.ConditionTrue, reasonNotRequired, "", now)
+	violatingPods := 0
+	//The pods still running in the namespaces of a frozen team
+	runningPods := 0
+
+	namespaces, namespacesErr := tc.getTeamNamespaces(t, class)
+
+	//notReady sets the condition to false. The reason and message of the first failure are kept
+	notReady := func(cond *aftouhv1.TeamCondition, reason, msg string) {
+		if cond.Status == corev1.ConditionTrue {
+			cond.Status, cond.Reason, cond.Message = corev1.ConditionFalse, reason, msg
 		}
-		buf.WriteString(ns.Name)
-		ownedNS = append(ownedNS, ns)
 	}
 
-	switch len(ownedNS) {
-	case 0:
-		return ts, nil
-	case 1:
-		ts.Namespace = ownedNS[0].Name
-		rq, err := tc.rqLister.ResourceQuotas(ts.Namespace).Get(rqName)
+	for _, env := range getTeamEnvironments(t) {
+		es := aftouhv1.EnvironmentStatus{Name: env.Name}
+		if namespacesErr != nil {
+			notReady(&nsCond, reasonInvalidNamespaceName, namespacesErr.Error())
+			notReady(&rqCond, reasonNotFound, "")
+			if expectLimitRange {
+				notReady(&lrCond, reasonNotFound, "")
+			}
+			ts.Environments = append(ts.Environments, es)
+			continue
+		}
+		namespaceName := namespaces[env.Name].Name
+		es.PendingNamespace = namespaces[env.Name].Pending
+		es.MigratingFrom = namespaces[env.Name].MigrateFrom
+		if es.PendingNamespace != "" {
+			pending = append(pending, es.PendingNamespace)
+		}
+		if from := es.MigratingFrom; from != "" {
+			progress := migrations[from]
+			switch {
+			case len(progress.uncopied) > 0:
+				uncopied = append(uncopied, fmt.Sprintf("namespace %q keeps %s", from, summarizeResources(progress.uncopied)))
+				approvals = append(approvals, namespaceName)
+			case progress.unavailable != "":
+				copying = append(copying, fmt.Sprintf("%s (waiting for deployment %q)", from, progress.unavailable))
+			default:
+				copying = append(copying, from)
+			}
+		}
 
+		ns, err := tc.nLister.Get(namespaceName)
 		switch {
 		case errors.IsNotFound(err):
-			ts.ResourceQuota = ""
+			notReady(&nsCond, reasonNotFound, fmt.Sprintf("Namespace %q does not exist", namespaceName))
+			notReady(&rqCond, reasonNotFound, "")
+			if expectLimitRange {
+				notReady(&lrCond, reasonNotFound, "")
+			}
 		case err != nil:
-			return ts, fmt.Errorf("Unable to get ResourceQuota %s/%s from cache: %v", ts.Namespace, rq.Name, err)
-		case !metav1.IsControlledBy(rq, t):
-			ts.ResourceQuota = ""
+			return ts, fmt.Errorf("Unable to retrieve namespace %q from store: %v", namespaceName, err)
+		case !metav1.IsControlledBy(ns, t):
+			msg := fmt.Sprintf(messageResourceExists, ns.Name)
+			notReady(&nsCond, errResourceExists, msg)
+			notReady(&rqCond, reasonNotFound, "")
+			if expectLimitRange {
+				notReady(&lrCond, reasonNotFound, "")
+			}
+			conflictCond.Status, conflictCond.Reason, conflictCond.Message = corev1.ConditionTrue, errResourceExists, msg
 		default:
-			ts.ResourceQuota = rqName
+			es.Namespace = ns.Name
+			if ns.Status.Phase != corev1.NamespaceActive {
+				notReady(&nsCond, reasonNamespaceNotActive, fmt.Sprintf("Namespace %q is in phase %q", ns.Name, ns.Status.Phase))
+			}
+
+			for _, quota := range env.GetResourceQuotas() {
+				name := getResourceQuotaName(quota.Name)
+				rq, err := tc.rqLister.ResourceQuotas(namespaceName).Get(name)
+				switch {
+				case errors.IsNotFound(err):
+					notReady(&rqCond, reasonNotFound, fmt.Sprintf("ResourceQuota %s/%s does not exist", namespaceName, name))
+				case err != nil:
+					return ts, fmt.Errorf("Unable to get ResourceQuota %s/%s from cache: %v", namespaceName, name, err)
+				case !metav1.IsControlledBy(rq, t):
+					msg := fmt.Sprintf(messageResourceExists, rq.Name)
+					notReady(&rqCond, errResourceExists, msg)
+					conflictCond.Status, conflictCond.Reason, conflictCond.Message = corev1.ConditionTrue, errResourceExists, msg
+				default:
+					es.ResourceQuotas = append(es.ResourceQuotas, name)
+					//The quota controller fills the status once it has computed the usage
+					if len(rq.Status.Hard) > 0 {
+						es.QuotaUsage = append(es.QuotaUsage, getResourceQuotaUsage(rq))
+					}
+				}
+			}
+
+			if expectLimitRange {
+				lr, err := tc.lrLister.LimitRanges(namespaceName).Get(lrName)
+				switch {
+				case errors.IsNotFound(err):
+					notReady(&lrCond, reasonNotFound, fmt.Sprintf("LimitRange %s/%s does not exist", namespaceName, lrName))
+				case err != nil:
+					return ts, fmt.Errorf("Unable to get LimitRange %s/%s from cache: %v", namespaceName, lrName, err)
+				case !metav1.IsControlledBy(lr, t):
+					msg := fmt.Sprintf(messageResourceExists, lr.Name)
+					notReady(&lrCond, errResourceExists, msg)
+					conflictCond.Status, conflictCond.Reason, conflictCond.Message = corev1.ConditionTrue, errResourceExists, msg
+				default:
+					es.LimitRange = lrName
+				}
+			}
+
+			if ps := t.Spec.GetPodSecurity(env.Name); ps != nil && ps.Enforce != "" {
+				psCond.Reason = reasonPodSecurityCompliant
+				violations, err := tc.getPodSecurityViolations(namespaceName, ps.Enforce)
+				if err != nil {
+					return ts, err
+				}
+				es.PodSecurityViolations = violations
+				violatingPods += len(violations)
+			}
+
+			if isFrozen(t) {
+				running, err := tc.countRunningPods(namespaceName)
+				if err != nil {
+					return ts, err
+				}
+				runningPods += running
+			}
 		}
-	default:
-		return ts, fmt.Errorf("Team %q owns more than one namespace: %v", t.Name, string(buf.Bytes()))
+
+		ts.Environments = append(ts.Environments, es)
+	}
+
+	ts.Phase = getTeamPhase(t, syncErr, runningPods)
+
+	//Single environment teams keep reporting their namespace at the top level
+	if len(ts.Environments) == 1 {
+		ts.Namespace = ts.Environments[0].Namespace
+		ts.ResourceQuotas = ts.Environments[0].ResourceQuotas
+	}
+	ts.MostUsedResource, ts.MostUsedPercentage = getMostUsedResource(ts.Environments)
+	if tc.ci.enabled() {
+		ts.CIServiceAccount = ciName
+	}
+
+	budget, err := tc.getTeamBudgetStatus(t)
+	if err != nil {
+		return ts, fmt.Errorf("Failed allocating the team budget: %v", err)
+	}
+	ts.Budget = budget
+
+	readyCond := newTeamCondition(aftouhv1.TeamReady, corev1.ConditionTrue, reasonSynced, "", now)
+	switch {
+	case syncErr != nil:
+		readyCond.Status, readyCond.Reason, readyCond.Message = corev1.ConditionFalse, reasonSyncFailed, syncErr.Error()
+	case nsCond.Status != corev1.ConditionTrue:
+		readyCond.Status, readyCond.Reason, readyCond.Message = corev1.ConditionFalse, nsCond.Reason, nsCond.Message
+	case rqCond.Status != corev1.ConditionTrue:
+		readyCond.Status, readyCond.Reason, readyCond.Message = corev1.ConditionFalse, rqCond.Reason, rqCond.Message
+	case lrCond.Status != corev1.ConditionTrue:
+		readyCond.Status, readyCond.Reason, readyCond.Message = corev1.ConditionFalse, lrCond.Reason, lrCond.Message
+	}
+
+	setTeamCondition(&ts, readyCond)
+	setTeamCondition(&ts, nsCond)
+	setTeamCondition(&ts, rqCond)
+	setTeamCondition(&ts, lrCond)
+	setTeamCondition(&ts, conflictCond)
+
+	if violatingPods > 0 {
+		psCond.Status, psCond.Reason = corev1.ConditionFalse, reasonPodSecurityViolated
+		psCond.Message = fmt.Sprintf("%d pods violate the enforced pod security level", violatingPods)
+	}
+	setTeamCondition(&ts, psCond)
+
+	switch {
+	case len(pending) > 0:
+		migratingCond.Status, migratingCond.Reason = corev1.ConditionTrue, reasonMigrationPending
+		migratingCond.Message = fmt.Sprintf("Namespaces %s are waiting for the %s=%s annotation",
+			strings.Join(pending, ", "), aftouhv1.MigrateNamespacesAnnotation, strings.Join(pending, ","))
+	case len(uncopied) > 0:
+		migratingCond.Status, migratingCond.Reason = corev1.ConditionTrue, reasonUncopiedResources
+		migratingCond.Message = fmt.Sprintf("Resources are not copied: %s. Set the %s=%s annotation to delete them",
+			strings.Join(uncopied, ", "), aftouhv1.MigrateNamespacesAnnotation, strings.Join(approvals, ","))
+	case len(copying) > 0:
+		migratingCond.Status, migratingCond.Reason = corev1.ConditionTrue, reasonCopyingResources
+		migratingCond.Message = fmt.Sprintf("Copying resources of namespaces %s", strings.Join(copying, ", "))
+	}
+	setTeamCondition(&ts, migratingCond)
+	setTeamCondition(&ts, tc.getQuotaPressureCondition(t, ts, now))
+
+	allocation, allocationErr := tc.getTeamAllocation(t)
+	setTeamCondition(&ts, getOverBudgetCondition(t, allocation, allocationErr, now))
+
+	expiry := getTeamExpiry(t)
+	if expiry != nil {
+		ts.ExpiresAt = expiry
+		ts.TimeLeft, _ = getTimeLeft(expiry.Time, now.Time)
 	}
+	setTeamCondition(&ts, tc.getExpiringCondition(t, expiry, now))
 
 	return ts, nil
 }
+
+//parseResourceList parses a comma separated list of resource=quantity
+func parseResourceList(s string) (corev1.ResourceList, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	rl := corev1.ResourceList{}
+	for _, item := range strings.Split(s, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid resource %q, expected <resource>=<quantity>", item)
+		}
+		q, err := resource.ParseQuantity(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity of resource %q: %v", kv[0], err)
+		}
+		rl[corev1.ResourceName(strings.TrimSpace(kv[0]))] = q
+	}
+	return rl, nil
+}
+
+func newTeamCondition(condType aftouhv1.TeamConditionType, status corev1.ConditionStatus, reason, message string, now metav1.Time) aftouhv1.TeamCondition {
+	return aftouhv1.TeamCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+//getTeamCondition returns the condition with the provided type
+func getTeamCondition(ts aftouhv1.TeamStatus, condType aftouhv1.TeamConditionType) *aftouhv1.TeamCondition {
+	for i := range ts.Conditions {
+		if ts.Conditions[i].Type == condType {
+			return &ts.Conditions[i]
+		}
+	}
+	return nil
+}
+
+//setTeamCondition adds or replaces the condition of the same type.
+//The last transition time is kept when the condition status does not change
+func setTeamCondition(ts *aftouhv1.TeamStatus, cond aftouhv1.TeamCondition) {
+	current := getTeamCondition(*ts, cond.Type)
+	if current == nil {
+		ts.Conditions = append(ts.Conditions, cond)
+		return
+	}
+	if current.Status == cond.Status {
+		cond.LastTransitionTime = current.LastTransitionTime
+	}
+	*current = cond
+}
@@ -1,16 +1,125 @@
 package main
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGetTeamNamespaceQ(t *testing.T) {
 	team := newTeam("team1", "", "dev", corev1.ResourceQuotaSpec{})
 	expected := "team-team1-dev"
-	got := getTeamNamespace(team)
+	namer, _ := newNamespaceNamer(defaultNamespaceTemplate)
+	got, err := namer.namespace(team, "dev", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 	if got != expected {
 		t.Errorf("expected namespace %q, got %q", expected, got)
 	}
 }
+
+func TestGetTeamEnvironments(t *testing.T) {
+	team := newTeam("team1", "", "dev", corev1.ResourceQuotaSpec{})
+	envs := getTeamEnvironments(team)
+	if len(envs) != 1 || envs[0].Name != "dev" {
+		t.Errorf("expected single dev environment, got %v", envs)
+	}
+
+	//spec.environments takes precedence over spec.environment
+	team.Spec.Environments = []aftouhv1.TeamEnvironment{{Name: "staging"}, {Name: "prod"}}
+	envs = getTeamEnvironments(team)
+	if len(envs) != 2 || envs[0].Name != "staging" || envs[1].Name != "prod" {
+		t.Errorf("expected staging and prod environments, got %v", envs)
+	}
+}
+
+func TestNewResourceQuotas(t *testing.T) {
+	pods := func(n int64) corev1.ResourceList {
+		return corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(n, resource.DecimalSI)}
+	}
+	class := &aftouhv1.TeamClass{Spec: aftouhv1.TeamClassSpec{ResourceQuotaSpec: corev1.ResourceQuotaSpec{Hard: pods(10)}}}
+	env := aftouhv1.TeamEnvironment{
+		Name: "dev",
+		ResourceQuotas: []aftouhv1.TeamResourceQuota{
+			{Name: "best-effort", ResourceQuotaSpec: corev1.ResourceQuotaSpec{
+				Hard:   pods(2),
+				Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort},
+			}},
+		},
+	}
+	team := newTeam("team1", "", "dev", corev1.ResourceQuotaSpec{})
+
+	rqs := newResourceQuotas(team, env, defaultTeamNamespace(team, env.Name), class)
+	if len(rqs) != 2 || rqs[0].Name != rqName || rqs[1].Name != "team-best-effort-rq" {
+		t.Fatalf("expected default and best-effort resourcequotas, got %v", rqs)
+	}
+	//The class only applies to the default resourcequota
+	if !reflect.DeepEqual(rqs[0].Spec.Hard, pods(10)) {
+		t.Errorf("expected default resourcequota from class, got %v", rqs[0].Spec)
+	}
+	if !reflect.DeepEqual(rqs[1].Spec, env.ResourceQuotas[0].ResourceQuotaSpec) {
+		t.Errorf("expected best-effort resourcequota %v, got %v", env.ResourceQuotas[0].ResourceQuotaSpec, rqs[1].Spec)
+	}
+
+	//A quota named default replaces spec.resourceQuota
+	env.ResourceQuotas = []aftouhv1.TeamResourceQuota{{Name: aftouhv1.DefaultResourceQuotaName, ResourceQuotaSpec: corev1.ResourceQuotaSpec{Hard: pods(4)}}}
+	env.ResourceQuotaSpec = corev1.ResourceQuotaSpec{Hard: pods(1)}
+	rqs = newResourceQuotas(team, env, defaultTeamNamespace(team, env.Name), nil)
+	if len(rqs) != 1 || !reflect.DeepEqual(rqs[0].Spec.Hard, pods(4)) {
+		t.Errorf("expected a single default resourcequota of 4 pods, got %v", rqs)
+	}
+}
+
+func TestSetTeamCondition(t *testing.T) {
+	before := metav1.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC)
+	now := metav1.Date(2020, time.May, 1, 0, 0, 0, 0, time.UTC)
+	ts := aftouhv1.TeamStatus{
+		Conditions: []aftouhv1.TeamCondition{
+			newTeamCondition(aftouhv1.TeamReady, corev1.ConditionTrue, reasonSynced, "", before),
+			newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionFalse, "", "", before),
+		},
+	}
+
+	//Same status keeps the transition time
+	setTeamCondition(&ts, newTeamCondition(aftouhv1.TeamReady, corev1.ConditionTrue, reasonSynced, "", now))
+	if got := getTeamCondition(ts, aftouhv1.TeamReady).LastTransitionTime; !got.Equal(&before) {
+		t.Errorf("expected transition time %v, got %v", before, got)
+	}
+
+	//Status change updates the transition time
+	setTeamCondition(&ts, newTeamCondition(aftouhv1.TeamConflict, corev1.ConditionTrue, errResourceExists, "", now))
+	if got := getTeamCondition(ts, aftouhv1.TeamConflict).LastTransitionTime; !got.Equal(&now) {
+		t.Errorf("expected transition time %v, got %v", now, got)
+	}
+
+	//New condition is appended
+	setTeamCondition(&ts, newTeamCondition(aftouhv1.TeamNamespaceReady, corev1.ConditionTrue, reasonNamespaceActive, "", now))
+	if len(ts.Conditions) != 3 || ts.Conditions[2].Type != aftouhv1.TeamNamespaceReady {
+		t.Errorf("expected NamespaceReady condition to be appended, got %v", ts.Conditions)
+	}
+}
+
+func TestParseResourceList(t *testing.T) {
+	rl, err := parseResourceList("pods=10, requests.cpu=500m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := rl[corev1.ResourcePods]; q.Value() != 10 {
+		t.Errorf("expected 10 pods, got %s", q.String())
+	}
+	if q := rl[corev1.ResourceRequestsCPU]; q.MilliValue() != 500 {
+		t.Errorf("expected 500m requests.cpu, got %s", q.String())
+	}
+
+	for _, invalid := range []string{"pods", "pods=ten"} {
+		if _, err := parseResourceList(invalid); err == nil {
+			t.Errorf("expected error parsing %q", invalid)
+		}
+	}
+}
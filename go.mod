@@ -4,8 +4,10 @@ go 1.14
 
 require (
 	k8s.io/api v0.17.5
+	k8s.io/apiextensions-apiserver v0.17.5
 	k8s.io/apimachinery v0.17.5
 	k8s.io/client-go v0.17.5
 	k8s.io/code-generator v0.17.5
 	k8s.io/klog v1.0.0
+	sigs.k8s.io/yaml v1.1.0
 )
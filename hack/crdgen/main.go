@@ -0,0 +1,248 @@
+//crdgen generates the CustomResourceDefinitions of the aftouh.io resources with the OpenAPI schema of each version
+//derived from the api types. It is run by hack/update-codegen.sh from the repository root
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+
+	teamv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	teamv2 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+)
+
+const header = `# Code generated by hack/crdgen from the types of pkg/apis/team. DO NOT EDIT.
+`
+
+//teamHeader also documents the conversion webhook of the Team CRD
+const teamHeader = header + `# The conversion webhook caBundle must be set to the CA that signed the webhook certificate
+`
+
+var (
+	root   = flag.String("root", ".", "Path to the repository root")
+	output = flag.String("output", "config", "Directory of the generated CRDs, relative to the repository root")
+)
+
+//crds lists the generator of each CRD manifest and its file name
+var crds = []struct {
+	file     string
+	generate func(root string) ([]byte, error)
+}{
+	{file: "300-teams-crd.yaml", generate: generateTeamCRD},
+	{file: "301-teamclasses-crd.yaml", generate: generateTeamClassCRD},
+	{file: "302-departments-crd.yaml", generate: generateDepartmentCRD},
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	for _, c := range crds {
+		crd, err := c.generate(*root)
+		if err != nil {
+			klog.Fatalf("failed generating %s: %s", c.file, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(*root, *output, c.file), crd, 0644); err != nil {
+			klog.Fatalf("failed writing %s: %s", c.file, err)
+		}
+	}
+}
+
+//readyColumn shows the status of the Ready condition
+var readyColumn = apiextensionsv1.CustomResourceColumnDefinition{
+	Name: "Ready", Type: "string", JSONPath: `.status.conditions[?(@.type=="Ready")].status`,
+}
+
+//phaseColumn shows whether the team workloads are running or suspended
+var phaseColumn = apiextensionsv1.CustomResourceColumnDefinition{
+	Name: "Phase", Type: "string", Description: "Active, Suspending, Suspended, Archived or Resuming", JSONPath: ".status.phase",
+}
+
+//usageColumns show the most used quota resource of the team and, in the wide output, the time left before it expires
+var usageColumns = []apiextensionsv1.CustomResourceColumnDefinition{
+	{Name: "Most Used", Type: "string", Description: "Most used quota resource, as <environment>/<resource>", JSONPath: ".status.mostUsedResource"},
+	{Name: "Usage", Type: "integer", Description: "Percentage of the hard limit used by the most used quota resource", JSONPath: ".status.mostUsedPercentage"},
+	{Name: "Time Left", Type: "string", Description: "Time left before the team expires", JSONPath: ".status.timeLeft", Priority: 1},
+	{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+}
+
+//generateTeamCRD returns the yaml manifest of the Team CRD
+func generateTeamCRD(root string) ([]byte, error) {
+	v1Schema, err := newSchemaGenerator(filepath.Join(root, "pkg/apis/team/v1"), reflect.TypeOf(teamv1.Team{}).PkgPath())
+	if err != nil {
+		return nil, err
+	}
+	v2Schema, err := newSchemaGenerator(filepath.Join(root, "pkg/apis/team/v2"), reflect.TypeOf(teamv2.Team{}).PkgPath())
+	if err != nil {
+		return nil, err
+	}
+
+	crd := apiextensionsv1.CustomResourceDefinition{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "CustomResourceDefinition"},
+		ObjectMeta: metav1.ObjectMeta{Name: "teams." + teamv1.SchemeGroupVersion.Group},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: teamv1.SchemeGroupVersion.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:       "Team",
+				ListKind:   "TeamList",
+				Plural:     "teams",
+				Singular:   "team",
+				ShortNames: []string{"tm"},
+				Categories: []string{"all-teams"},
+			},
+			Scope: apiextensionsv1.ClusterScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:    teamv2.SchemeGroupVersion.Version,
+					Served:  true,
+					Storage: true,
+					Schema:  &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: v2Schema.rootSchema(reflect.TypeOf(teamv2.Team{}))},
+					//v2 teams only have environments, the columns show the first one
+					AdditionalPrinterColumns: append([]apiextensionsv1.CustomResourceColumnDefinition{
+						{Name: "Name", Type: "string", Description: "Team name", JSONPath: ".spec.name"},
+						{Name: "Environment", Type: "string", Description: "First environment of the team", JSONPath: ".spec.environments[0].name"},
+						{Name: "Namespace", Type: "string", Description: "Namespace of the first environment", JSONPath: ".status.environments[0].namespace"},
+						readyColumn,
+						phaseColumn,
+					}, usageColumns...),
+					Subresources: &apiextensionsv1.CustomResourceSubresources{Status: &apiextensionsv1.CustomResourceSubresourceStatus{}},
+				},
+				{
+					Name:    teamv1.SchemeGroupVersion.Version,
+					Served:  true,
+					Storage: false,
+					Schema:  &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: v1Schema.rootSchema(reflect.TypeOf(teamv1.Team{}))},
+					AdditionalPrinterColumns: append([]apiextensionsv1.CustomResourceColumnDefinition{
+						{Name: "Name", Type: "string", Description: "Team name", JSONPath: ".spec.name"},
+						{Name: "Environment", Type: "string", Description: "Environment of single environment teams", JSONPath: ".spec.environment"},
+						{Name: "Namespace", Type: "string", Description: "Namespace of single environment teams", JSONPath: ".status.namespace"},
+						readyColumn,
+						phaseColumn,
+					}, usageColumns...),
+					Subresources: &apiextensionsv1.CustomResourceSubresources{Status: &apiextensionsv1.CustomResourceSubresourceStatus{}},
+				},
+			},
+			Conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ConversionReviewVersions: []string{"v1beta1"},
+					ClientConfig: &apiextensionsv1.WebhookClientConfig{
+						Service: &apiextensionsv1.ServiceReference{
+							Namespace: "aftouh-teams",
+							Name:      "aftouh-teams-webhook",
+							Path:      stringPtr("/convert"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return marshalCRD(crd, teamHeader)
+}
+
+//generateTeamClassCRD returns the yaml manifest of the TeamClass CRD
+func generateTeamClassCRD(root string) ([]byte, error) {
+	v1Schema, err := newSchemaGenerator(filepath.Join(root, "pkg/apis/team/v1"), reflect.TypeOf(teamv1.TeamClass{}).PkgPath())
+	if err != nil {
+		return nil, err
+	}
+
+	crd := apiextensionsv1.CustomResourceDefinition{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "CustomResourceDefinition"},
+		ObjectMeta: metav1.ObjectMeta{Name: "teamclasses." + teamv1.SchemeGroupVersion.Group},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: teamv1.SchemeGroupVersion.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:     "TeamClass",
+				ListKind: "TeamClassList",
+				Plural:   "teamclasses",
+				Singular: "teamclass",
+			},
+			Scope: apiextensionsv1.ClusterScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:    teamv1.SchemeGroupVersion.Version,
+					Served:  true,
+					Storage: true,
+					Schema:  &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: v1Schema.rootSchema(reflect.TypeOf(teamv1.TeamClass{}))},
+					AdditionalPrinterColumns: []apiextensionsv1.CustomResourceColumnDefinition{
+						{Name: "Namespace Template", Type: "string", Description: "Template of the team namespace names", JSONPath: ".spec.namespaceTemplate"},
+						{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+					},
+				},
+			},
+		},
+	}
+	return marshalCRD(crd, header)
+}
+
+//generateDepartmentCRD returns the yaml manifest of the Department CRD.
+//The controller writes the department budget status through the status subresource
+func generateDepartmentCRD(root string) ([]byte, error) {
+	v1Schema, err := newSchemaGenerator(filepath.Join(root, "pkg/apis/team/v1"), reflect.TypeOf(teamv1.Department{}).PkgPath())
+	if err != nil {
+		return nil, err
+	}
+
+	crd := apiextensionsv1.CustomResourceDefinition{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "CustomResourceDefinition"},
+		ObjectMeta: metav1.ObjectMeta{Name: "departments." + teamv1.SchemeGroupVersion.Group},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: teamv1.SchemeGroupVersion.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:     "Department",
+				ListKind: "DepartmentList",
+				Plural:   "departments",
+				Singular: "department",
+			},
+			Scope: apiextensionsv1.ClusterScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:    teamv1.SchemeGroupVersion.Version,
+					Served:  true,
+					Storage: true,
+					Schema:  &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: v1Schema.rootSchema(reflect.TypeOf(teamv1.Department{}))},
+					AdditionalPrinterColumns: []apiextensionsv1.CustomResourceColumnDefinition{
+						{Name: "Description", Type: "string", JSONPath: ".spec.description"},
+						{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+					},
+					Subresources: &apiextensionsv1.CustomResourceSubresources{Status: &apiextensionsv1.CustomResourceSubresourceStatus{}},
+				},
+			},
+		},
+	}
+	return marshalCRD(crd, header)
+}
+
+//marshalCRD returns the yaml manifest of the CRD after the header comment.
+//The status and the empty metadata fields are not part of the manifest
+func marshalCRD(crd apiextensionsv1.CustomResourceDefinition, header string) ([]byte, error) {
+	b, err := json.Marshal(crd)
+	if err != nil {
+		return nil, err
+	}
+	var manifest map[string]interface{}
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, err
+	}
+	delete(manifest, "status")
+	delete(manifest["metadata"].(map[string]interface{}), "creationTimestamp")
+
+	out, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed marshalling CRD: %v", err)
+	}
+	return append([]byte(header), out...), nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
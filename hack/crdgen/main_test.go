@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCRDsUpToDate(t *testing.T) {
+	for _, c := range crds {
+		generated, err := c.generate("../..")
+		if err != nil {
+			t.Fatalf("failed generating %s: %v", c.file, err)
+		}
+		current, err := ioutil.ReadFile(filepath.Join("../../config", c.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(generated, current) {
+			t.Errorf("config/%s is out of date, run hack/update-codegen.sh", c.file)
+		}
+	}
+}
+
+//pastTimestampPaths are the timestamps date columns may show. kubectl prints the age of a date column, a future
+//timestamp like the expiry of a team is printed as <invalid>
+var pastTimestampPaths = map[string]bool{".metadata.creationTimestamp": true}
+
+func TestDateColumns(t *testing.T) {
+	for _, g := range crds {
+		generated, err := g.generate("../..")
+		if err != nil {
+			t.Fatalf("failed generating %s: %v", g.file, err)
+		}
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := yaml.Unmarshal(generated, &crd); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range crd.Spec.Versions {
+			for _, c := range v.AdditionalPrinterColumns {
+				if c.Type == "date" && !pastTimestampPaths[c.JSONPath] {
+					t.Errorf("%s column %q of version %s shows %s, date columns may only show past timestamps", crd.Name, c.Name, v.Name, c.JSONPath)
+				}
+			}
+		}
+	}
+}
+
+func TestStatusSubresources(t *testing.T) {
+	for _, g := range crds {
+		generated, err := g.generate("../..")
+		if err != nil {
+			t.Fatalf("failed generating %s: %v", g.file, err)
+		}
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := yaml.Unmarshal(generated, &crd); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range crd.Spec.Versions {
+			_, hasStatus := v.Schema.OpenAPIV3Schema.Properties["status"]
+			if hasStatus && (v.Subresources == nil || v.Subresources.Status == nil) {
+				t.Errorf("version %s of %s has a status without the status subresource", v.Name, crd.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	timeType        = reflect.TypeOf(metav1.Time{})
+	durationType    = reflect.TypeOf(metav1.Duration{})
+	objectMetaType  = reflect.TypeOf(metav1.ObjectMeta{})
+	quantityType    = reflect.TypeOf(resource.Quantity{})
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+)
+
+//schemaGenerator derives structural OpenAPI schemas from go types.
+//Types of the api package are documented with their go doc comments
+type schemaGenerator struct {
+	pkgPath string
+	//docs holds the comments of the api package types, indexed by type name and by <type name>.<field name>
+	docs map[string]string
+}
+
+func newSchemaGenerator(dir, pkgPath string) (*schemaGenerator, error) {
+	docs, err := parseDocs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &schemaGenerator{pkgPath: pkgPath, docs: docs}, nil
+}
+
+//parseDocs returns the comments of the types and struct fields declared in the package directory
+func parseDocs(dir string) (map[string]string, error) {
+	fset := token.NewFileSet()
+	notTest := func(fi os.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(fset, dir, notTest, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make(map[string]string)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					doc := ts.Doc
+					if doc == nil && len(gd.Specs) == 1 {
+						doc = gd.Doc
+					}
+					docs[ts.Name.Name] = cleanDoc(doc)
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					for _, field := range st.Fields.List {
+						for _, name := range field.Names {
+							docs[ts.Name.Name+"."+name.Name] = cleanDoc(field.Doc)
+						}
+					}
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+//cleanDoc joins the comment lines, without the code generation tags
+func cleanDoc(doc *ast.CommentGroup) string {
+	var lines []string
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "+") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+//rootSchema returns the schema of a resource type. The object metadata is validated by the api server
+func (g *schemaGenerator) rootSchema(t reflect.Type) *apiextensionsv1.JSONSchemaProps {
+	s := g.schema(t)
+	s.Description = g.docs[t.Name()]
+	return &s
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) apiextensionsv1.JSONSchemaProps {
+	switch t {
+	case timeType:
+		return apiextensionsv1.JSONSchemaProps{Type: "string", Format: "date-time"}
+	case durationType:
+		return apiextensionsv1.JSONSchemaProps{Type: "string"}
+	case objectMetaType:
+		return apiextensionsv1.JSONSchemaProps{Type: "object"}
+	case quantityType:
+		return apiextensionsv1.JSONSchemaProps{
+			AnyOf:        []apiextensionsv1.JSONSchemaProps{{Type: "integer"}, {Type: "string"}},
+			Pattern:      `^(\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))))?$`,
+			XIntOrString: true,
+		}
+	case intOrStringType:
+		return apiextensionsv1.JSONSchemaProps{
+			AnyOf:        []apiextensionsv1.JSONSchemaProps{{Type: "integer"}, {Type: "string"}},
+			XIntOrString: true,
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return apiextensionsv1.JSONSchemaProps{Type: "string"}
+	case reflect.Bool:
+		return apiextensionsv1.JSONSchemaProps{Type: "boolean"}
+	case reflect.Int32, reflect.Uint32:
+		return apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint64:
+		return apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return apiextensionsv1.JSONSchemaProps{Type: "number"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return apiextensionsv1.JSONSchemaProps{Type: "string", Format: "byte"}
+		}
+		items := g.schema(t.Elem())
+		return apiextensionsv1.JSONSchemaProps{Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &items}}
+	case reflect.Map:
+		values := g.schema(t.Elem())
+		return apiextensionsv1.JSONSchemaProps{Type: "object", AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Allows: true, Schema: &values}}
+	case reflect.Struct:
+		s := apiextensionsv1.JSONSchemaProps{Type: "object", Properties: make(map[string]apiextensionsv1.JSONSchemaProps)}
+		g.addProperties(&s, t)
+		return s
+	}
+	return apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: boolPtr(true)}
+}
+
+//addProperties adds the json fields of the struct to the object schema. Embedded structs are inlined
+func (g *schemaGenerator) addProperties(s *apiextensionsv1.JSONSchemaProps, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		if name == "" && f.Anonymous {
+			g.addProperties(s, f.Type)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := g.schema(f.Type)
+		if doc := g.fieldDoc(t, f); doc != "" {
+			prop.Description = doc
+		}
+		s.Properties[name] = prop
+	}
+}
+
+//fieldDoc returns the comment of the field, or of its type when the field is not documented
+func (g *schemaGenerator) fieldDoc(t reflect.Type, f reflect.StructField) string {
+	if t.PkgPath() != g.pkgPath {
+		return ""
+	}
+	if doc := g.docs[t.Name()+"."+f.Name]; doc != "" {
+		return doc
+	}
+	ft := f.Type
+	for ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice {
+		ft = ft.Elem()
+	}
+	if ft.PkgPath() == g.pkgPath {
+		return g.docs[ft.Name()]
+	}
+	return ""
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -7,6 +7,9 @@ echo $CODEGEN_PKG
 
 bash vendor/k8s.io/code-generator/generate-groups.sh "deepcopy,client,informer,lister" \
   ${PROJECT_PATH}/pkg/client ${PROJECT_PATH}/pkg/apis \
-  team:v1 \
+  team:v1,v2 \
   --go-header-file "${SCRIPT_ROOT}"/hack/boilerplate.go.txt \
   --output-base "$(dirname "${BASH_SOURCE[0]}")/../../../.."
+
+# The team CRD schema is generated from the api types
+(cd "${SCRIPT_ROOT}" && go run ./hack/crdgen)
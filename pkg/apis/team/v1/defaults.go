@@ -0,0 +1,64 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TeamDefaults holds the cluster-wide default values of teams
+// +k8s:deepcopy-gen=false
+type TeamDefaults struct {
+	// Environment is the default spec.environment of single environment teams
+	Environment string
+	// ResourceQuotaSpec is the default resourcequota of environments without hard limits.
+	// It is not applied to teams having a class
+	ResourceQuotaSpec corev1.ResourceQuotaSpec
+	// NetworkPolicyMode is the default networkpolicy mode of teams. Teams without mode are open
+	NetworkPolicyMode NetworkPolicyMode
+	// QuotaAlerts are the thresholds of teams not setting them. They are resolved by the controller
+	// rather than written to the team spec so that changing them applies to every team
+	QuotaAlerts TeamQuotaAlerts
+	// SyncedResources are copied into the namespaces of every team, together with the team spec.syncedResources.
+	// Like the quota alerts, they are not written to the team spec
+	SyncedResources []TeamSyncedResource
+}
+
+// SetDefaults sets the empty team fields to their default value
+func (d *TeamDefaults) SetDefaults(t *Team) {
+	if t.Spec.Name == "" {
+		t.Spec.Name = t.Name
+	}
+
+	if len(t.Spec.Environments) == 0 && t.Spec.Environment == "" {
+		t.Spec.Environment = d.Environment
+	}
+
+	if d.NetworkPolicyMode != "" {
+		if t.Spec.NetworkPolicy == nil {
+			t.Spec.NetworkPolicy = &TeamNetworkPolicy{}
+		}
+		if t.Spec.NetworkPolicy.Mode == "" {
+			t.Spec.NetworkPolicy.Mode = d.NetworkPolicyMode
+		}
+	}
+
+	//Default resourcequota of teams having a class is provided by the class
+	if t.Spec.ClassName != "" {
+		return
+	}
+
+	if len(t.Spec.Environments) == 0 {
+		d.setResourceQuotaDefaults(&t.Spec.ResourceQuotaSpec)
+		return
+	}
+
+	for i := range t.Spec.Environments {
+		d.setResourceQuotaDefaults(&t.Spec.Environments[i].ResourceQuotaSpec)
+	}
+}
+
+// setResourceQuotaDefaults replaces a resourcequota that limits nothing by the default one
+func (d *TeamDefaults) setResourceQuotaDefaults(spec *corev1.ResourceQuotaSpec) {
+	if len(spec.Hard) == 0 && len(d.ResourceQuotaSpec.Hard) > 0 {
+		d.ResourceQuotaSpec.DeepCopyInto(spec)
+	}
+}
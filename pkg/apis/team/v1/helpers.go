@@ -0,0 +1,36 @@
+package v1
+
+// GetEnvironments returns the team environments.
+// Teams without spec.environments have a single environment defined by spec.environment
+func (s *TeamSpec) GetEnvironments() []TeamEnvironment {
+	if len(s.Environments) > 0 {
+		return s.Environments
+	}
+	return []TeamEnvironment{{
+		Name:              s.Environment,
+		ResourceQuotaSpec: s.ResourceQuotaSpec,
+		ResourceQuotas:    s.ResourceQuotas,
+	}}
+}
+
+// GetResourceQuotas returns all the resourcequotas of the environment.
+// The first one is the default resourcequota defined by spec.resourceQuota unless a quota named default replaces it
+func (e *TeamEnvironment) GetResourceQuotas() []TeamResourceQuota {
+	for _, q := range e.ResourceQuotas {
+		if q.Name == DefaultResourceQuotaName {
+			return e.ResourceQuotas
+		}
+	}
+	quotas := []TeamResourceQuota{{Name: DefaultResourceQuotaName, ResourceQuotaSpec: e.ResourceQuotaSpec}}
+	return append(quotas, e.ResourceQuotas...)
+}
+
+// GetPodSecurity returns the pod security levels of the environment, the team ones unless the environment overrides them
+func (s *TeamSpec) GetPodSecurity(env string) *TeamPodSecurity {
+	for _, e := range s.Environments {
+		if e.Name == env && e.PodSecurity != nil {
+			return e.PodSecurity
+		}
+	}
+	return s.PodSecurity
+}
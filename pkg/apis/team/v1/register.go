@@ -31,6 +31,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Team{},
 		&TeamList{},
+		&TeamClass{},
+		&TeamClassList{},
+		&Department{},
+		&DepartmentList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
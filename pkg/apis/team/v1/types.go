@@ -9,7 +9,7 @@ import (
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
-//Team defines team resource structure
+// Team defines team resource structure
 type Team struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -20,16 +20,371 @@ type Team struct {
 
 // TeamSpec is the spec for a team resource
 type TeamSpec struct {
-	Name              string                   `json:"name"`
-	Environment       string                   `json:"environment"`
+	Name string `json:"name"`
+	// Environment and ResourceQuotaSpec define a single environment team.
+	// They are ignored when Environments is set
+	Environment       string                   `json:"environment,omitempty"`
 	Description       string                   `json:"description"`
+	ResourceQuotaSpec corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+	// ResourceQuotas are the additional resourcequotas of a single environment team
+	ResourceQuotas []TeamResourceQuota `json:"resourceQuotas,omitempty"`
+	// Environments lists the team environments. Each environment gets its own namespace
+	Environments []TeamEnvironment `json:"environments,omitempty"`
+	// ClassName is the name of the TeamClass providing the team default values
+	ClassName string `json:"className,omitempty"`
+	// Members lists the users, groups and service accounts of the team
+	Members []TeamMember `json:"members,omitempty"`
+	// LimitRange is the limitrange of the team namespaces. Defaults to the class one
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
+	// NetworkPolicy defines the ingress traffic allowed into the team namespaces
+	NetworkPolicy *TeamNetworkPolicy `json:"networkPolicy,omitempty"`
+	// NamespaceMetadata holds the labels and annotations of the team namespaces and of every other object managed for the team
+	NamespaceMetadata *TeamMetadata `json:"namespaceMetadata,omitempty"`
+	// PodSecurity sets the pod security admission levels of the team namespaces
+	PodSecurity *TeamPodSecurity `json:"podSecurity,omitempty"`
+	// DeletionPolicy is what happens to the team namespaces when the team is deleted. Defaults to Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// NamespaceMigration defines how environments move to their new namespace when its generated name changes
+	NamespaceMigration *TeamNamespaceMigration `json:"namespaceMigration,omitempty"`
+	// QuotaAlerts are the quota usage percentages raising the QuotaPressure condition.
+	// Missing thresholds are set by the controller
+	QuotaAlerts *TeamQuotaAlerts `json:"quotaAlerts,omitempty"`
+	// Parent is the team or department whose budget caps the quotas of the team
+	Parent *TeamParent `json:"parent,omitempty"`
+	// Budget caps the sum of the resourcequota hard limits of the child teams
+	Budget corev1.ResourceList `json:"budget,omitempty"`
+	// State is Active, Suspended or Archived. Defaults to Active
+	State TeamState `json:"state,omitempty"`
+	// ExpiresAt is the time the team is deleted at
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// TTL is the lifetime of the team from its creation. It cannot be set with ExpiresAt
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// SyncedResources lists the configmaps and secrets copied into the team namespaces,
+	// in addition to the ones every team gets from the controller
+	SyncedResources []TeamSyncedResource `json:"syncedResources,omitempty"`
+}
+
+// TeamParent references the parent of a team
+type TeamParent struct {
+	// Kind is Team or Department
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+const (
+	// ParentKindTeam is the kind of parent teams
+	ParentKindTeam = "Team"
+	// ParentKindDepartment is the kind of parent departments
+	ParentKindDepartment = "Department"
+)
+
+// TeamSyncedResource references a source configmap or secret copied into the team namespaces under the same name
+type TeamSyncedResource struct {
+	// Kind is ConfigMap or Secret
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+const (
+	// SyncedResourceKindConfigMap is the kind of synced configmaps
+	SyncedResourceKindConfigMap = "ConfigMap"
+	// SyncedResourceKindSecret is the kind of synced secrets
+	SyncedResourceKindSecret = "Secret"
+)
+
+// BudgetStatus reports the allocation of a budget to the child teams
+type BudgetStatus struct {
+	// Allocated is the sum of the hard limits of the budgeted resources given to the children
+	Allocated corev1.ResourceList `json:"allocated,omitempty"`
+	// Children lists the child teams
+	Children []string `json:"children,omitempty"`
+	// OverBudget lists the child teams whose quotas do not fit in the remaining budget
+	OverBudget []string `json:"overBudget,omitempty"`
+}
+
+// TeamState is one of Active, Suspended or Archived
+type TeamState string
+
+const (
+	// TeamStateActive runs the team workloads
+	TeamStateActive TeamState = "Active"
+	// TeamStateSuspended scales the team deployments and statefulsets to zero and prevents new pods from starting
+	TeamStateSuspended TeamState = "Suspended"
+	// TeamStateArchived freezes the team like Suspended, for teams that are not expected to resume
+	TeamStateArchived TeamState = "Archived"
+)
+
+// TeamPhase is the lifecycle phase of a team
+type TeamPhase string
+
+const (
+	TeamPhaseActive TeamPhase = "Active"
+	// TeamPhaseSuspending means the team workloads are not all scaled to zero yet or some of their pods still run
+	TeamPhaseSuspending TeamPhase = "Suspending"
+	TeamPhaseSuspended  TeamPhase = "Suspended"
+	TeamPhaseArchived   TeamPhase = "Archived"
+	// TeamPhaseResuming means the replicas of the team workloads are not all restored yet
+	TeamPhaseResuming TeamPhase = "Resuming"
+)
+
+// NamespaceMigrationPolicy is one of Confirm or Copy
+type NamespaceMigrationPolicy string
+
+const (
+	// NamespaceMigrationConfirm keeps the current namespace until MigrateNamespacesAnnotation approves the new one
+	NamespaceMigrationConfirm NamespaceMigrationPolicy = "Confirm"
+	// NamespaceMigrationCopy copies the resources of the current namespace into the new one, then deletes the current namespace
+	NamespaceMigrationCopy NamespaceMigrationPolicy = "Copy"
+)
+
+// TeamNamespaceMigration defines how environments move to their new namespace
+type TeamNamespaceMigration struct {
+	// Policy defaults to Confirm
+	Policy NamespaceMigrationPolicy `json:"policy,omitempty"`
+	// Resources lists the kinds copied by the Copy policy among ConfigMap, Secret, Deployment and Service.
+	// Defaults to all of them
+	Resources []string `json:"resources,omitempty"`
+}
+
+// NamespaceMigrationResources are the kinds the Copy policy supports, all copied when Resources is empty
+var NamespaceMigrationResources = []string{"ConfigMap", "Secret", "Deployment", "Service"}
+
+// TeamQuotaAlerts are the usage percentages of the most used quota resource raising an alert
+type TeamQuotaAlerts struct {
+	Warning  int32 `json:"warning,omitempty"`
+	Critical int32 `json:"critical,omitempty"`
+}
+
+// DeletionPolicy is one of Delete, Retain or Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the team namespaces with the team
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain keeps the team namespaces and the objects managed in them
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyOrphan keeps the team namespaces but deletes the objects managed in them
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// PodSecurityLevel is a level of the pod security standards
+type PodSecurityLevel string
+
+const (
+	// PodSecurityPrivileged is the unrestricted pod security level
+	PodSecurityPrivileged PodSecurityLevel = "privileged"
+	// PodSecurityBaseline prevents the known privilege escalations
+	PodSecurityBaseline PodSecurityLevel = "baseline"
+	// PodSecurityRestricted enforces the pod hardening best practices
+	PodSecurityRestricted PodSecurityLevel = "restricted"
+)
+
+// TeamPodSecurity defines the pod security admission levels and versions of the team namespaces.
+// Versions are like v1.25 and default to latest
+type TeamPodSecurity struct {
+	// Enforce is the level above which pods are rejected
+	Enforce        PodSecurityLevel `json:"enforce,omitempty"`
+	EnforceVersion string           `json:"enforceVersion,omitempty"`
+	// Audit is the level above which pods are reported in the audit log
+	Audit        PodSecurityLevel `json:"audit,omitempty"`
+	AuditVersion string           `json:"auditVersion,omitempty"`
+	// Warn is the level above which users get a warning
+	Warn        PodSecurityLevel `json:"warn,omitempty"`
+	WarnVersion string           `json:"warnVersion,omitempty"`
+}
+
+// TeamMetadata holds labels and annotations propagated to the objects managed for the team
+type TeamMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// NetworkPolicyMode selects the baseline networkpolicies of the team namespaces
+type NetworkPolicyMode string
+
+const (
+	// NetworkPolicyOpen allows all the ingress traffic
+	NetworkPolicyOpen NetworkPolicyMode = "open"
+	// NetworkPolicyIsolated only allows the ingress traffic from the same namespace
+	NetworkPolicyIsolated NetworkPolicyMode = "isolated"
+	// NetworkPolicyTeamIsolated only allows the ingress traffic from the namespaces of the same team
+	NetworkPolicyTeamIsolated NetworkPolicyMode = "team-isolated"
+)
+
+// TeamNetworkPolicy defines the ingress traffic allowed into the team namespaces
+type TeamNetworkPolicy struct {
+	// Mode is one of open, isolated or team-isolated. Defaults to open
+	Mode NetworkPolicyMode `json:"mode,omitempty"`
+	// AllowedNamespaces selects other namespaces allowed to reach isolated team namespaces
+	AllowedNamespaces []metav1.LabelSelector `json:"allowedNamespaces,omitempty"`
+}
+
+// TeamMember is a subject that belongs to the team
+type TeamMember struct {
+	// Kind is one of User, Group or ServiceAccount
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Namespace of the service account. Defaults to the team namespace. Ignored for users and groups
+	Namespace string `json:"namespace,omitempty"`
+	// Role is the name of the cluster role given to the member in the team namespaces, like admin, edit or view
+	Role string `json:"role"`
+}
+
+const (
+	// MemberKindUser is the kind of user members
+	MemberKindUser = "User"
+	// MemberKindGroup is the kind of group members
+	MemberKindGroup = "Group"
+	// MemberKindServiceAccount is the kind of service account members
+	MemberKindServiceAccount = "ServiceAccount"
+)
+
+// TeamEnvironment defines a team environment and its resourcequotas
+type TeamEnvironment struct {
+	Name string `json:"name"`
+	// ResourceQuotaSpec is the spec of the default resourcequota
 	ResourceQuotaSpec corev1.ResourceQuotaSpec `json:"resourceQuota"`
+	// ResourceQuotas are the additional resourcequotas of the environment
+	ResourceQuotas []TeamResourceQuota `json:"resourceQuotas,omitempty"`
+	// PodSecurity overrides the team pod security levels in the environment namespace
+	PodSecurity *TeamPodSecurity `json:"podSecurity,omitempty"`
+}
+
+// MigrateNamespacesAnnotation approves the migration of the team environments to their new generated namespace.
+// Its value is the comma separated list of the approved namespaces
+const MigrateNamespacesAnnotation = "aftouh.io/migrate-namespaces"
+
+const (
+	// ManagedLabelsAnnotation lists the label keys set by the controller on a managed object
+	ManagedLabelsAnnotation = "aftouh.io/managed-labels"
+	// ManagedAnnotationsAnnotation lists the annotation keys set by the controller on a managed object
+	ManagedAnnotationsAnnotation = "aftouh.io/managed-annotations"
+	// ManagedPullSecretsAnnotation lists the image pull secrets referenced by the controller in a default service account
+	ManagedPullSecretsAnnotation = "aftouh.io/managed-pull-secrets"
+)
+
+const (
+	// TeamFinalizer lets the controller apply the team deletion policy before the team is deleted
+	TeamFinalizer = "aftouh.io/team-protection"
+	// FormerlyOwnedByAnnotation is set on the objects released by a deleted team, to the team name
+	FormerlyOwnedByAnnotation = "aftouh.io/formerly-owned-by"
+	// MigratedFromAnnotation is set on the resources copied into a new team namespace, to the namespace they come from
+	MigratedFromAnnotation = "aftouh.io/migrated-from"
+	// ReplicatedFromAnnotation is set on the configmaps and secrets synced into the team namespaces, to their source namespace/name
+	ReplicatedFromAnnotation = "aftouh.io/replicated-from"
+	// SuspendedReplicasAnnotation is set on the workloads scaled to zero by a team suspension, to their previous replicas
+	SuspendedReplicasAnnotation = "aftouh.io/suspended-replicas"
+	// SuspendedMinReplicasAnnotation is set on the horizontalpodautoscalers paused by a team suspension, to their previous minReplicas
+	SuspendedMinReplicasAnnotation = "aftouh.io/suspended-min-replicas"
+	// ExtendExpiryAnnotation postpones the expiry of a team by a duration, e.g. 48h
+	ExtendExpiryAnnotation = "aftouh.io/extend-expiry"
+)
+
+// DefaultResourceQuotaName is the name of the resourcequota defined by spec.resourceQuota
+const DefaultResourceQuotaName = "default"
+
+// TeamResourceQuota is a named resourcequota of a team environment
+type TeamResourceQuota struct {
+	// Name of the quota. A quota named default replaces the one defined by spec.resourceQuota
+	Name                     string `json:"name"`
+	corev1.ResourceQuotaSpec `json:",inline"`
 }
 
 // TeamStatus is the status for a Team resource
 type TeamStatus struct {
-	Namespace     string `json:"namespace"`
-	ResourceQuota string `json:"resourcequota"`
+	// ObservedGeneration is the most recent generation observed by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// MostUsedResource is the most used quota resource of the team, as <environment>/<resource>
+	MostUsedResource string `json:"mostUsedResource,omitempty"`
+	// MostUsedPercentage is the percentage of the hard limit used by MostUsedResource
+	MostUsedPercentage int32 `json:"mostUsedPercentage,omitempty"`
+	// Phase is Active, Suspending, Suspended, Archived or Resuming
+	Phase TeamPhase `json:"phase,omitempty"`
+	// ExpiresAt is the time the team is deleted at, extended by the aftouh.io/extend-expiry annotation
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// TimeLeft is the time left before the team expires, rounded up to the hour and to the minute in the last hour,
+	// e.g. 5h or 2d
+	TimeLeft string `json:"timeLeft,omitempty"`
+	// CIServiceAccount is the deploy service account of the team pipelines in each team namespace
+	CIServiceAccount string `json:"ciServiceAccount,omitempty"`
+	// Budget reports the allocation of the team budget to its child teams
+	Budget *BudgetStatus `json:"budget,omitempty"`
+	// Namespace and ResourceQuotas are only set for single environment teams
+	Namespace      string              `json:"namespace"`
+	ResourceQuotas []string            `json:"resourcequotas,omitempty"`
+	Environments   []EnvironmentStatus `json:"environments,omitempty"`
+	Conditions     []TeamCondition     `json:"conditions,omitempty"`
+}
+
+// EnvironmentStatus is the status of a team environment
+type EnvironmentStatus struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// ResourceQuotas lists the resourcequotas of the environment
+	ResourceQuotas []string `json:"resourcequotas,omitempty"`
+	LimitRange     string   `json:"limitrange,omitempty"`
+	// PendingNamespace is the new generated namespace name the environment is not migrated to yet
+	PendingNamespace string `json:"pendingNamespace,omitempty"`
+	// PodSecurityViolations lists the running pods of the namespace that violate the enforced pod security level
+	PodSecurityViolations []string `json:"podSecurityViolations,omitempty"`
+	// MigratingFrom is the namespace whose resources are copied into the environment namespace
+	MigratingFrom string `json:"migratingFrom,omitempty"`
+	// QuotaUsage reports the hard limits and usage of the environment resourcequotas
+	QuotaUsage []ResourceQuotaUsage `json:"quotaUsage,omitempty"`
+}
+
+// ResourceQuotaUsage is the usage of a resourcequota of the environment
+type ResourceQuotaUsage struct {
+	Name      string          `json:"name"`
+	Resources []ResourceUsage `json:"resources,omitempty"`
+}
+
+// ResourceUsage is the hard limit and the usage of a resource of a resourcequota
+type ResourceUsage struct {
+	Name corev1.ResourceName `json:"name"`
+	Hard string              `json:"hard"`
+	Used string              `json:"used"`
+	// Percentage is the used share of the hard limit, rounded down
+	Percentage int32 `json:"percentage"`
+}
+
+// TeamConditionType is a valid value for TeamCondition.Type
+type TeamConditionType string
+
+const (
+	// TeamReady means the team namespaces and resourcequotas are up to date
+	TeamReady TeamConditionType = "Ready"
+	// TeamNamespaceReady means the namespaces of all team environments exist, are active and are owned by the team
+	TeamNamespaceReady TeamConditionType = "NamespaceReady"
+	// TeamResourceQuotaReady means the resourcequotas of all team environments exist and are owned by the team
+	TeamResourceQuotaReady TeamConditionType = "ResourceQuotaReady"
+	// TeamLimitRangeReady means the limitranges of all team environments exist and are owned by the team
+	TeamLimitRangeReady TeamConditionType = "LimitRangeReady"
+	// TeamConflict means a resource the team should manage already exists and is owned by someone else
+	TeamConflict TeamConditionType = "Conflict"
+	// TeamPodSecurityCompliant means no running pod of the team namespaces violates the enforced pod security level
+	TeamPodSecurityCompliant TeamConditionType = "PodSecurityCompliant"
+	// TeamTerminating means the team is deleted and waits for its namespaces to terminate
+	TeamTerminating TeamConditionType = "Terminating"
+	// TeamMigrating means an environment is not migrated to its new namespace yet
+	TeamMigrating TeamConditionType = "Migrating"
+	// TeamQuotaPressure means a quota resource of the team is used above the warning or critical threshold
+	TeamQuotaPressure TeamConditionType = "QuotaPressure"
+	// TeamOverBudget means the team quotas exceed the remaining budget of its parent.
+	// The team keeps its current resourcequotas until they fit
+	TeamOverBudget TeamConditionType = "OverBudget"
+	// TeamExpiring means the team expires within a day
+	TeamExpiring TeamConditionType = "Expiring"
+)
+
+// TeamCondition describes the state of a team at a certain point
+type TeamCondition struct {
+	Type               TeamConditionType      `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
 }
 
 // +genclient:nonNamespaced
@@ -42,3 +397,79 @@ type TeamList struct {
 
 	Items []Team `json:"items"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TeamClass is a cluster-wide template providing default values to the teams referencing it
+type TeamClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TeamClassSpec `json:"spec"`
+}
+
+// TeamClassSpec is the spec for a team class resource
+type TeamClassSpec struct {
+	// ResourceQuotaSpec is the default resourcequota of the team environments.
+	// Hard limits set by the team override the class ones
+	ResourceQuotaSpec corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+	// Labels are added to the objects managed for the team
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are added to the objects managed for the team
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// LimitRange is the default limitrange of the team namespaces
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
+	// NamespaceTemplate generates the namespace names of the team environments, e.g. "{{.Environment}}-{{.Name}}".
+	// Defaults to the controller one
+	NamespaceTemplate string `json:"namespaceTemplate,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TeamClassList is a list of TeamClass resources
+type TeamClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []TeamClass `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Department is a cluster-wide group of teams sharing a quota budget
+type Department struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DepartmentSpec   `json:"spec"`
+	Status DepartmentStatus `json:"status"`
+}
+
+// DepartmentSpec is the spec for a department resource
+type DepartmentSpec struct {
+	Description string `json:"description,omitempty"`
+	// Budget caps the sum of the resourcequota hard limits of the teams of the department
+	Budget corev1.ResourceList `json:"budget,omitempty"`
+}
+
+// DepartmentStatus is the status for a department resource
+type DepartmentStatus struct {
+	// ObservedGeneration is the most recent generation observed by the controller
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	Budget             BudgetStatus `json:"budget,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DepartmentList is a list of Department resources
+type DepartmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Department `json:"items"`
+}
@@ -5,16 +5,222 @@
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BudgetStatus) DeepCopyInto(out *BudgetStatus) {
+	*out = *in
+	if in.Allocated != nil {
+		in, out := &in.Allocated, &out.Allocated
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Children != nil {
+		in, out := &in.Children, &out.Children
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OverBudget != nil {
+		in, out := &in.OverBudget, &out.OverBudget
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BudgetStatus.
+func (in *BudgetStatus) DeepCopy() *BudgetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BudgetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Department) DeepCopyInto(out *Department) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Department.
+func (in *Department) DeepCopy() *Department {
+	if in == nil {
+		return nil
+	}
+	out := new(Department)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Department) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DepartmentList) DeepCopyInto(out *DepartmentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Department, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DepartmentList.
+func (in *DepartmentList) DeepCopy() *DepartmentList {
+	if in == nil {
+		return nil
+	}
+	out := new(DepartmentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DepartmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DepartmentSpec) DeepCopyInto(out *DepartmentSpec) {
+	*out = *in
+	if in.Budget != nil {
+		in, out := &in.Budget, &out.Budget
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DepartmentSpec.
+func (in *DepartmentSpec) DeepCopy() *DepartmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DepartmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DepartmentStatus) DeepCopyInto(out *DepartmentStatus) {
+	*out = *in
+	in.Budget.DeepCopyInto(&out.Budget)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DepartmentStatus.
+func (in *DepartmentStatus) DeepCopy() *DepartmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DepartmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentStatus) DeepCopyInto(out *EnvironmentStatus) {
+	*out = *in
+	if in.ResourceQuotas != nil {
+		in, out := &in.ResourceQuotas, &out.ResourceQuotas
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodSecurityViolations != nil {
+		in, out := &in.PodSecurityViolations, &out.PodSecurityViolations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.QuotaUsage != nil {
+		in, out := &in.QuotaUsage, &out.QuotaUsage
+		*out = make([]ResourceQuotaUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentStatus.
+func (in *EnvironmentStatus) DeepCopy() *EnvironmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceQuotaUsage) DeepCopyInto(out *ResourceQuotaUsage) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceUsage, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceQuotaUsage.
+func (in *ResourceQuotaUsage) DeepCopy() *ResourceQuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceQuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceUsage) DeepCopyInto(out *ResourceUsage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceUsage.
+func (in *ResourceUsage) DeepCopy() *ResourceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Team) DeepCopyInto(out *Team) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -36,6 +242,148 @@ func (in *Team) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamClass) DeepCopyInto(out *TeamClass) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamClass.
+func (in *TeamClass) DeepCopy() *TeamClass {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TeamClass) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamClassList) DeepCopyInto(out *TeamClassList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TeamClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamClassList.
+func (in *TeamClassList) DeepCopy() *TeamClassList {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamClassList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TeamClassList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamClassSpec) DeepCopyInto(out *TeamClassSpec) {
+	*out = *in
+	in.ResourceQuotaSpec.DeepCopyInto(&out.ResourceQuotaSpec)
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LimitRange != nil {
+		in, out := &in.LimitRange, &out.LimitRange
+		*out = new(corev1.LimitRangeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamClassSpec.
+func (in *TeamClassSpec) DeepCopy() *TeamClassSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamClassSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamCondition) DeepCopyInto(out *TeamCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamCondition.
+func (in *TeamCondition) DeepCopy() *TeamCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamEnvironment) DeepCopyInto(out *TeamEnvironment) {
+	*out = *in
+	in.ResourceQuotaSpec.DeepCopyInto(&out.ResourceQuotaSpec)
+	if in.ResourceQuotas != nil {
+		in, out := &in.ResourceQuotas, &out.ResourceQuotas
+		*out = make([]TeamResourceQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodSecurity != nil {
+		in, out := &in.PodSecurity, &out.PodSecurity
+		*out = new(TeamPodSecurity)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamEnvironment.
+func (in *TeamEnvironment) DeepCopy() *TeamEnvironment {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamEnvironment)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TeamList) DeepCopyInto(out *TeamList) {
 	*out = *in
@@ -69,10 +417,240 @@ func (in *TeamList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamMember) DeepCopyInto(out *TeamMember) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamMember.
+func (in *TeamMember) DeepCopy() *TeamMember {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamMetadata) DeepCopyInto(out *TeamMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamMetadata.
+func (in *TeamMetadata) DeepCopy() *TeamMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamNamespaceMigration) DeepCopyInto(out *TeamNamespaceMigration) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamNamespaceMigration.
+func (in *TeamNamespaceMigration) DeepCopy() *TeamNamespaceMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamNamespaceMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamNetworkPolicy) DeepCopyInto(out *TeamNetworkPolicy) {
+	*out = *in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]metav1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamNetworkPolicy.
+func (in *TeamNetworkPolicy) DeepCopy() *TeamNetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamNetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamParent) DeepCopyInto(out *TeamParent) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamParent.
+func (in *TeamParent) DeepCopy() *TeamParent {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamParent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamPodSecurity) DeepCopyInto(out *TeamPodSecurity) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamPodSecurity.
+func (in *TeamPodSecurity) DeepCopy() *TeamPodSecurity {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamPodSecurity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamQuotaAlerts) DeepCopyInto(out *TeamQuotaAlerts) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamQuotaAlerts.
+func (in *TeamQuotaAlerts) DeepCopy() *TeamQuotaAlerts {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamQuotaAlerts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamResourceQuota) DeepCopyInto(out *TeamResourceQuota) {
+	*out = *in
+	in.ResourceQuotaSpec.DeepCopyInto(&out.ResourceQuotaSpec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamResourceQuota.
+func (in *TeamResourceQuota) DeepCopy() *TeamResourceQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamResourceQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TeamSpec) DeepCopyInto(out *TeamSpec) {
 	*out = *in
 	in.ResourceQuotaSpec.DeepCopyInto(&out.ResourceQuotaSpec)
+	if in.ResourceQuotas != nil {
+		in, out := &in.ResourceQuotas, &out.ResourceQuotas
+		*out = make([]TeamResourceQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]TeamEnvironment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]TeamMember, len(*in))
+		copy(*out, *in)
+	}
+	if in.LimitRange != nil {
+		in, out := &in.LimitRange, &out.LimitRange
+		*out = new(corev1.LimitRangeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(TeamNetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceMetadata != nil {
+		in, out := &in.NamespaceMetadata, &out.NamespaceMetadata
+		*out = new(TeamMetadata)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSecurity != nil {
+		in, out := &in.PodSecurity, &out.PodSecurity
+		*out = new(TeamPodSecurity)
+		**out = **in
+	}
+	if in.NamespaceMigration != nil {
+		in, out := &in.NamespaceMigration, &out.NamespaceMigration
+		*out = new(TeamNamespaceMigration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QuotaAlerts != nil {
+		in, out := &in.QuotaAlerts, &out.QuotaAlerts
+		*out = new(TeamQuotaAlerts)
+		**out = **in
+	}
+	if in.Parent != nil {
+		in, out := &in.Parent, &out.Parent
+		*out = new(TeamParent)
+		**out = **in
+	}
+	if in.Budget != nil {
+		in, out := &in.Budget, &out.Budget
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.SyncedResources != nil {
+		in, out := &in.SyncedResources, &out.SyncedResources
+		*out = make([]TeamSyncedResource, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -89,6 +667,34 @@ func (in *TeamSpec) DeepCopy() *TeamSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TeamStatus) DeepCopyInto(out *TeamStatus) {
 	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Budget != nil {
+		in, out := &in.Budget, &out.Budget
+		*out = new(BudgetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceQuotas != nil {
+		in, out := &in.ResourceQuotas, &out.ResourceQuotas
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]EnvironmentStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]TeamCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -101,3 +707,19 @@ func (in *TeamStatus) DeepCopy() *TeamStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamSyncedResource) DeepCopyInto(out *TeamSyncedResource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamSyncedResource.
+func (in *TeamSyncedResource) DeepCopy() *TeamSyncedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamSyncedResource)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,416 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	v1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// v1DataAnnotation stores on v2 objects the v1 fields that do not exist in v2
+	v1DataAnnotation = "aftouh.io/v1-conversion-data"
+	// v2DataAnnotation stores on v1 objects the v2 fields that do not exist in v1
+	v2DataAnnotation = "aftouh.io/v2-conversion-data"
+)
+
+// v1Data holds the v1 team fields that can not be represented in v2
+// +k8s:deepcopy-gen=false
+type v1Data struct {
+	// SingleEnvironment is true when the v1 team uses spec.environment instead of spec.environments
+	SingleEnvironment bool `json:"singleEnvironment,omitempty"`
+	// Environment, ResourceQuotaSpec and ResourceQuotas are ignored v1 fields set along with spec.environments
+	Environment       string                    `json:"environment,omitempty"`
+	ResourceQuotaSpec *corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+	ResourceQuotas    []v1.TeamResourceQuota    `json:"resourceQuotas,omitempty"`
+}
+
+// v2Data holds the v2 team fields that can not be represented in v1
+// +k8s:deepcopy-gen=false
+type v2Data struct {
+	PolicyRefs []PolicyReference `json:"policyRefs,omitempty"`
+	// EnvironmentPodSecurity is the pod security of the environment of single environment teams
+	EnvironmentPodSecurity *TeamPodSecurity `json:"environmentPodSecurity,omitempty"`
+}
+
+// ConvertV1ToV2 converts a v1 team to a v2 team.
+// v1 fields that do not exist in v2 are kept in an annotation so that converting back to v1 does not lose data
+func ConvertV1ToV2(in *v1.Team, out *Team) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	out.Spec = TeamSpec{
+		Name:           in.Spec.Name,
+		Description:    in.Spec.Description,
+		ClassName:      in.Spec.ClassName,
+		LimitRange:     in.Spec.LimitRange.DeepCopy(),
+		PodSecurity:    convertPodSecurityToV2(in.Spec.PodSecurity),
+		DeletionPolicy: DeletionPolicy(in.Spec.DeletionPolicy),
+		Budget:         in.Spec.Budget.DeepCopy(),
+		State:          TeamState(in.Spec.State),
+		ExpiresAt:      in.Spec.ExpiresAt.DeepCopy(),
+		TTL:            in.Spec.TTL.DeepCopy(),
+	}
+	if m := in.Spec.NamespaceMigration; m != nil {
+		out.Spec.NamespaceMigration = &TeamNamespaceMigration{
+			Policy:    NamespaceMigrationPolicy(m.Policy),
+			Resources: append([]string(nil), m.Resources...),
+		}
+	}
+	if qa := in.Spec.QuotaAlerts; qa != nil {
+		alerts := TeamQuotaAlerts(*qa)
+		out.Spec.QuotaAlerts = &alerts
+	}
+	if p := in.Spec.Parent; p != nil {
+		parent := TeamParent(*p)
+		out.Spec.Parent = &parent
+	}
+	for _, r := range in.Spec.SyncedResources {
+		out.Spec.SyncedResources = append(out.Spec.SyncedResources, TeamSyncedResource(r))
+	}
+	if np := in.Spec.NetworkPolicy; np != nil {
+		out.Spec.NetworkPolicy = &TeamNetworkPolicy{Mode: NetworkPolicyMode(np.Mode)}
+		for _, selector := range np.AllowedNamespaces {
+			out.Spec.NetworkPolicy.AllowedNamespaces = append(out.Spec.NetworkPolicy.AllowedNamespaces, *selector.DeepCopy())
+		}
+	}
+	if md := in.Spec.NamespaceMetadata; md != nil {
+		out.Spec.NamespaceMetadata = &TeamMetadata{Labels: copyMap(md.Labels), Annotations: copyMap(md.Annotations)}
+	}
+	for _, m := range in.Spec.Members {
+		out.Spec.Members = append(out.Spec.Members, TeamMember{
+			Kind:      m.Kind,
+			Name:      m.Name,
+			Namespace: m.Namespace,
+			Role:      m.Role,
+		})
+	}
+
+	var data v1Data
+	if len(in.Spec.Environments) == 0 {
+		data.SingleEnvironment = true
+		out.Spec.Environments = []TeamEnvironment{{
+			Name:              in.Spec.Environment,
+			ResourceQuotaSpec: *in.Spec.ResourceQuotaSpec.DeepCopy(),
+			ResourceQuotas:    convertResourceQuotasToV2(in.Spec.ResourceQuotas),
+		}}
+	} else {
+		for _, env := range in.Spec.Environments {
+			out.Spec.Environments = append(out.Spec.Environments, TeamEnvironment{
+				Name:              env.Name,
+				ResourceQuotaSpec: *env.ResourceQuotaSpec.DeepCopy(),
+				ResourceQuotas:    convertResourceQuotasToV2(env.ResourceQuotas),
+				PodSecurity:       convertPodSecurityToV2(env.PodSecurity),
+			})
+		}
+		data.Environment = in.Spec.Environment
+		if !reflect.DeepEqual(in.Spec.ResourceQuotaSpec, corev1.ResourceQuotaSpec{}) {
+			data.ResourceQuotaSpec = in.Spec.ResourceQuotaSpec.DeepCopy()
+		}
+		data.ResourceQuotas = in.Spec.ResourceQuotas
+	}
+
+	//Restore the v2 fields saved by a previous conversion to v1
+	var restored v2Data
+	if err := popAnnotation(&out.Annotations, v2DataAnnotation, &restored); err != nil {
+		return err
+	}
+	out.Spec.PolicyRefs = restored.PolicyRefs
+	if restored.EnvironmentPodSecurity != nil && len(out.Spec.Environments) == 1 {
+		out.Spec.Environments[0].PodSecurity = restored.EnvironmentPodSecurity
+	}
+
+	if err := pushAnnotation(&out.Annotations, v1DataAnnotation, data, v1Data{}); err != nil {
+		return err
+	}
+
+	out.Status = TeamStatus{
+		ObservedGeneration: in.Status.ObservedGeneration,
+		MostUsedResource:   in.Status.MostUsedResource,
+		MostUsedPercentage: in.Status.MostUsedPercentage,
+		Phase:              TeamPhase(in.Status.Phase),
+		ExpiresAt:          in.Status.ExpiresAt.DeepCopy(),
+		TimeLeft:           in.Status.TimeLeft,
+		CIServiceAccount:   in.Status.CIServiceAccount,
+	}
+	if b := in.Status.Budget; b != nil {
+		out.Status.Budget = &BudgetStatus{
+			Allocated:  b.Allocated.DeepCopy(),
+			Children:   append([]string(nil), b.Children...),
+			OverBudget: append([]string(nil), b.OverBudget...),
+		}
+	}
+	for _, es := range in.Status.Environments {
+		out.Status.Environments = append(out.Status.Environments, EnvironmentStatus{
+			Name:                  es.Name,
+			Namespace:             es.Namespace,
+			ResourceQuotas:        append([]string(nil), es.ResourceQuotas...),
+			LimitRange:            es.LimitRange,
+			PendingNamespace:      es.PendingNamespace,
+			PodSecurityViolations: append([]string(nil), es.PodSecurityViolations...),
+			MigratingFrom:         es.MigratingFrom,
+			QuotaUsage:            convertQuotaUsageToV2(es.QuotaUsage),
+		})
+	}
+	for _, c := range in.Status.Conditions {
+		out.Status.Conditions = append(out.Status.Conditions, TeamCondition{
+			Type:               TeamConditionType(c.Type),
+			Status:             c.Status,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		})
+	}
+
+	return nil
+}
+
+// ConvertV2ToV1 converts a v2 team to a v1 team.
+// v2 fields that do not exist in v1 are kept in an annotation so that converting back to v2 does not lose data
+func ConvertV2ToV1(in *Team, out *v1.Team) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = v1.SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	out.Spec = v1.TeamSpec{
+		Name:           in.Spec.Name,
+		Description:    in.Spec.Description,
+		ClassName:      in.Spec.ClassName,
+		LimitRange:     in.Spec.LimitRange.DeepCopy(),
+		PodSecurity:    convertPodSecurityToV1(in.Spec.PodSecurity),
+		DeletionPolicy: v1.DeletionPolicy(in.Spec.DeletionPolicy),
+		Budget:         in.Spec.Budget.DeepCopy(),
+		State:          v1.TeamState(in.Spec.State),
+		ExpiresAt:      in.Spec.ExpiresAt.DeepCopy(),
+		TTL:            in.Spec.TTL.DeepCopy(),
+	}
+	if m := in.Spec.NamespaceMigration; m != nil {
+		out.Spec.NamespaceMigration = &v1.TeamNamespaceMigration{
+			Policy:    v1.NamespaceMigrationPolicy(m.Policy),
+			Resources: append([]string(nil), m.Resources...),
+		}
+	}
+	if qa := in.Spec.QuotaAlerts; qa != nil {
+		alerts := v1.TeamQuotaAlerts(*qa)
+		out.Spec.QuotaAlerts = &alerts
+	}
+	if p := in.Spec.Parent; p != nil {
+		parent := v1.TeamParent(*p)
+		out.Spec.Parent = &parent
+	}
+	for _, r := range in.Spec.SyncedResources {
+		out.Spec.SyncedResources = append(out.Spec.SyncedResources, v1.TeamSyncedResource(r))
+	}
+	if np := in.Spec.NetworkPolicy; np != nil {
+		out.Spec.NetworkPolicy = &v1.TeamNetworkPolicy{Mode: v1.NetworkPolicyMode(np.Mode)}
+		for _, selector := range np.AllowedNamespaces {
+			out.Spec.NetworkPolicy.AllowedNamespaces = append(out.Spec.NetworkPolicy.AllowedNamespaces, *selector.DeepCopy())
+		}
+	}
+	if md := in.Spec.NamespaceMetadata; md != nil {
+		out.Spec.NamespaceMetadata = &v1.TeamMetadata{Labels: copyMap(md.Labels), Annotations: copyMap(md.Annotations)}
+	}
+	for _, m := range in.Spec.Members {
+		out.Spec.Members = append(out.Spec.Members, v1.TeamMember{
+			Kind:      m.Kind,
+			Name:      m.Name,
+			Namespace: m.Namespace,
+			Role:      m.Role,
+		})
+	}
+
+	//Restore the v1 fields saved by a previous conversion to v2
+	var restored v1Data
+	if err := popAnnotation(&out.Annotations, v1DataAnnotation, &restored); err != nil {
+		return err
+	}
+
+	if restored.SingleEnvironment && len(in.Spec.Environments) == 1 {
+		out.Spec.Environment = in.Spec.Environments[0].Name
+		out.Spec.ResourceQuotaSpec = *in.Spec.Environments[0].ResourceQuotaSpec.DeepCopy()
+		out.Spec.ResourceQuotas = convertResourceQuotasToV1(in.Spec.Environments[0].ResourceQuotas)
+	} else {
+		for _, env := range in.Spec.Environments {
+			out.Spec.Environments = append(out.Spec.Environments, v1.TeamEnvironment{
+				Name:              env.Name,
+				ResourceQuotaSpec: *env.ResourceQuotaSpec.DeepCopy(),
+				ResourceQuotas:    convertResourceQuotasToV1(env.ResourceQuotas),
+				PodSecurity:       convertPodSecurityToV1(env.PodSecurity),
+			})
+		}
+		out.Spec.Environment = restored.Environment
+		if restored.ResourceQuotaSpec != nil {
+			out.Spec.ResourceQuotaSpec = *restored.ResourceQuotaSpec
+		}
+		out.Spec.ResourceQuotas = restored.ResourceQuotas
+	}
+
+	data := v2Data{
+		PolicyRefs: in.Spec.PolicyRefs,
+	}
+	if restored.SingleEnvironment && len(in.Spec.Environments) == 1 {
+		data.EnvironmentPodSecurity = in.Spec.Environments[0].PodSecurity
+	}
+	if err := pushAnnotation(&out.Annotations, v2DataAnnotation, data, v2Data{}); err != nil {
+		return err
+	}
+
+	out.Status = v1.TeamStatus{
+		ObservedGeneration: in.Status.ObservedGeneration,
+		MostUsedResource:   in.Status.MostUsedResource,
+		MostUsedPercentage: in.Status.MostUsedPercentage,
+		Phase:              v1.TeamPhase(in.Status.Phase),
+		ExpiresAt:          in.Status.ExpiresAt.DeepCopy(),
+		TimeLeft:           in.Status.TimeLeft,
+		CIServiceAccount:   in.Status.CIServiceAccount,
+	}
+	if b := in.Status.Budget; b != nil {
+		out.Status.Budget = &v1.BudgetStatus{
+			Allocated:  b.Allocated.DeepCopy(),
+			Children:   append([]string(nil), b.Children...),
+			OverBudget: append([]string(nil), b.OverBudget...),
+		}
+	}
+	for _, es := range in.Status.Environments {
+		out.Status.Environments = append(out.Status.Environments, v1.EnvironmentStatus{
+			Name:                  es.Name,
+			Namespace:             es.Namespace,
+			ResourceQuotas:        append([]string(nil), es.ResourceQuotas...),
+			LimitRange:            es.LimitRange,
+			PendingNamespace:      es.PendingNamespace,
+			PodSecurityViolations: append([]string(nil), es.PodSecurityViolations...),
+			MigratingFrom:         es.MigratingFrom,
+			QuotaUsage:            convertQuotaUsageToV1(es.QuotaUsage),
+		})
+	}
+	//Single environment teams report their namespace at the top level of the v1 status
+	if len(out.Status.Environments) == 1 {
+		out.Status.Namespace = out.Status.Environments[0].Namespace
+		out.Status.ResourceQuotas = append([]string(nil), out.Status.Environments[0].ResourceQuotas...)
+	}
+	for _, c := range in.Status.Conditions {
+		out.Status.Conditions = append(out.Status.Conditions, v1.TeamCondition{
+			Type:               v1.TeamConditionType(c.Type),
+			Status:             c.Status,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		})
+	}
+
+	return nil
+}
+
+func convertResourceQuotasToV2(in []v1.TeamResourceQuota) []TeamResourceQuota {
+	var out []TeamResourceQuota
+	for _, q := range in {
+		out = append(out, TeamResourceQuota{Name: q.Name, ResourceQuotaSpec: *q.ResourceQuotaSpec.DeepCopy()})
+	}
+	return out
+}
+
+func convertResourceQuotasToV1(in []TeamResourceQuota) []v1.TeamResourceQuota {
+	var out []v1.TeamResourceQuota
+	for _, q := range in {
+		out = append(out, v1.TeamResourceQuota{Name: q.Name, ResourceQuotaSpec: *q.ResourceQuotaSpec.DeepCopy()})
+	}
+	return out
+}
+
+func convertQuotaUsageToV2(in []v1.ResourceQuotaUsage) []ResourceQuotaUsage {
+	var out []ResourceQuotaUsage
+	for _, u := range in {
+		usage := ResourceQuotaUsage{Name: u.Name}
+		for _, r := range u.Resources {
+			usage.Resources = append(usage.Resources, ResourceUsage(r))
+		}
+		out = append(out, usage)
+	}
+	return out
+}
+
+func convertQuotaUsageToV1(in []ResourceQuotaUsage) []v1.ResourceQuotaUsage {
+	var out []v1.ResourceQuotaUsage
+	for _, u := range in {
+		usage := v1.ResourceQuotaUsage{Name: u.Name}
+		for _, r := range u.Resources {
+			usage.Resources = append(usage.Resources, v1.ResourceUsage(r))
+		}
+		out = append(out, usage)
+	}
+	return out
+}
+
+func copyMap(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func convertPodSecurityToV2(in *v1.TeamPodSecurity) *TeamPodSecurity {
+	if in == nil {
+		return nil
+	}
+	return &TeamPodSecurity{
+		Enforce:        PodSecurityLevel(in.Enforce),
+		EnforceVersion: in.EnforceVersion,
+		Audit:          PodSecurityLevel(in.Audit),
+		AuditVersion:   in.AuditVersion,
+		Warn:           PodSecurityLevel(in.Warn),
+		WarnVersion:    in.WarnVersion,
+	}
+}
+
+func convertPodSecurityToV1(in *TeamPodSecurity) *v1.TeamPodSecurity {
+	if in == nil {
+		return nil
+	}
+	return &v1.TeamPodSecurity{
+		Enforce:        v1.PodSecurityLevel(in.Enforce),
+		EnforceVersion: in.EnforceVersion,
+		Audit:          v1.PodSecurityLevel(in.Audit),
+		AuditVersion:   in.AuditVersion,
+		Warn:           v1.PodSecurityLevel(in.Warn),
+		WarnVersion:    in.WarnVersion,
+	}
+}
+
+// popAnnotation decodes and removes the annotation if it exists
+func popAnnotation(annotations *map[string]string, key string, into interface{}) error {
+	raw, ok := (*annotations)[key]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), into); err != nil {
+		return fmt.Errorf("invalid %s annotation: %v", key, err)
+	}
+	delete(*annotations, key)
+	if len(*annotations) == 0 {
+		*annotations = nil
+	}
+	return nil
+}
+
+// pushAnnotation encodes data in the annotation unless it equals the zero value
+func pushAnnotation(annotations *map[string]string, key string, data, zero interface{}) error {
+	if reflect.DeepEqual(data, zero) {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if *annotations == nil {
+		*annotations = make(map[string]string)
+	}
+	(*annotations)[key] = string(raw)
+	return nil
+}
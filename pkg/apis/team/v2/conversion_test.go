@@ -0,0 +1,208 @@
+package v2
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+)
+
+var (
+	testTime = metav1.Date(2020, time.May, 1, 10, 0, 0, 0, time.UTC)
+	testRQ   = corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{
+			corev1.ResourcePods: *resource.NewQuantity(4, resource.DecimalSI),
+		},
+	}
+	testBestEffortRQ = corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{
+			corev1.ResourcePods: *resource.NewQuantity(2, resource.DecimalSI),
+		},
+		Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort},
+	}
+)
+
+func TestV1RoundTrip(t *testing.T) {
+	tests := map[string]*v1.Team{
+		"single environment": {
+			TypeMeta:   metav1.TypeMeta{APIVersion: "aftouh.io/v1", Kind: "Team"},
+			ObjectMeta: metav1.ObjectMeta{Name: "poc-dev", Generation: 2},
+			Spec: v1.TeamSpec{
+				Name:              "poc",
+				Environment:       "dev",
+				Description:       "poc team",
+				ResourceQuotaSpec: testRQ,
+				ResourceQuotas:    []v1.TeamResourceQuota{{Name: "best-effort", ResourceQuotaSpec: testBestEffortRQ}},
+				TTL:               &metav1.Duration{Duration: 72 * time.Hour},
+			},
+			Status: v1.TeamStatus{
+				ObservedGeneration: 2,
+				ExpiresAt:          &testTime,
+				TimeLeft:           "3d",
+				CIServiceAccount:   "team-ci",
+				Namespace:          "team-poc-dev",
+				ResourceQuotas:     []string{"team-default-rq"},
+				Environments:       []v1.EnvironmentStatus{{Name: "dev", Namespace: "team-poc-dev", ResourceQuotas: []string{"team-default-rq"}}},
+				Conditions: []v1.TeamCondition{
+					{Type: v1.TeamReady, Status: corev1.ConditionTrue, Reason: "Synced", LastTransitionTime: testTime},
+				},
+			},
+		},
+		"multiple environments": {
+			TypeMeta:   metav1.TypeMeta{APIVersion: "aftouh.io/v1", Kind: "Team"},
+			ObjectMeta: metav1.ObjectMeta{Name: "poc", Annotations: map[string]string{"owner": "aftouh"}},
+			Spec: v1.TeamSpec{
+				Name:      "poc",
+				ClassName: "standard",
+				Members:   []v1.TeamMember{{Kind: "Group", Name: "poc-developers", Role: "edit"}},
+				NetworkPolicy: &v1.TeamNetworkPolicy{
+					Mode:              v1.NetworkPolicyTeamIsolated,
+					AllowedNamespaces: []metav1.LabelSelector{{MatchLabels: map[string]string{"name": "monitoring"}}},
+				},
+				LimitRange: &corev1.LimitRangeSpec{
+					Limits: []corev1.LimitRangeItem{{Type: corev1.LimitTypeContainer, Default: testRQ.Hard}},
+				},
+				PodSecurity:        &v1.TeamPodSecurity{Enforce: v1.PodSecurityBaseline, EnforceVersion: "v1.25"},
+				DeletionPolicy:     v1.DeletionPolicyRetain,
+				NamespaceMigration: &v1.TeamNamespaceMigration{Policy: v1.NamespaceMigrationCopy, Resources: []string{"ConfigMap", "Deployment"}},
+				QuotaAlerts:        &v1.TeamQuotaAlerts{Warning: 70, Critical: 90},
+				Parent:             &v1.TeamParent{Kind: "Department", Name: "engineering"},
+				State:              v1.TeamStateSuspended,
+				ExpiresAt:          &testTime,
+				Budget:             testRQ.Hard,
+				SyncedResources:    []v1.TeamSyncedResource{{Kind: "ConfigMap", Namespace: "platform", Name: "ca-bundle"}},
+				NamespaceMetadata: &v1.TeamMetadata{
+					Labels:      map[string]string{"istio-injection": "enabled"},
+					Annotations: map[string]string{"owner": "alice"},
+				},
+				Environments: []v1.TeamEnvironment{
+					{Name: "dev", ResourceQuotaSpec: testRQ},
+					{
+						Name:           "prod",
+						ResourceQuotas: []v1.TeamResourceQuota{{Name: "best-effort", ResourceQuotaSpec: testBestEffortRQ}},
+						PodSecurity:    &v1.TeamPodSecurity{Enforce: v1.PodSecurityRestricted, Warn: v1.PodSecurityRestricted},
+					},
+				},
+			},
+			Status: v1.TeamStatus{
+				Phase:        v1.TeamPhaseSuspended,
+				Budget:       &v1.BudgetStatus{Allocated: testRQ.Hard, Children: []string{"poc-api", "poc-web"}, OverBudget: []string{"poc-web"}},
+				Environments: []v1.EnvironmentStatus{{Name: "dev", LimitRange: "team-default-lr", PendingNamespace: "dev-poc"}, {Name: "prod", MigratingFrom: "team-poc-production", PodSecurityViolations: []string{`debug: privileged container "app"`}}},
+			},
+		},
+		"ignored single environment fields": {
+			TypeMeta:   metav1.TypeMeta{APIVersion: "aftouh.io/v1", Kind: "Team"},
+			ObjectMeta: metav1.ObjectMeta{Name: "poc"},
+			Spec: v1.TeamSpec{
+				Name:              "poc",
+				Environment:       "dev",
+				ResourceQuotaSpec: testRQ,
+				ResourceQuotas:    []v1.TeamResourceQuota{{Name: "best-effort", ResourceQuotaSpec: testBestEffortRQ}},
+				Environments:      []v1.TeamEnvironment{{Name: "prod"}},
+			},
+		},
+	}
+
+	for name, in := range tests {
+		t.Run(name, func(t *testing.T) {
+			var hub Team
+			if err := ConvertV1ToV2(in.DeepCopy(), &hub); err != nil {
+				t.Fatalf("failed converting v1 to v2: %v", err)
+			}
+			if hub.APIVersion != "aftouh.io/v2" {
+				t.Errorf("expected apiVersion aftouh.io/v2, got %q", hub.APIVersion)
+			}
+
+			var out v1.Team
+			if err := ConvertV2ToV1(&hub, &out); err != nil {
+				t.Fatalf("failed converting v2 to v1: %v", err)
+			}
+			if !equality.Semantic.DeepEqual(in, &out) {
+				t.Errorf("round trip changed the team\nDiff:\n %s", diff.ObjectGoPrintSideBySide(in, &out))
+			}
+		})
+	}
+}
+
+func TestV2RoundTrip(t *testing.T) {
+	tests := map[string]*Team{
+		"members and policies": {
+			TypeMeta:   metav1.TypeMeta{APIVersion: "aftouh.io/v2", Kind: "Team"},
+			ObjectMeta: metav1.ObjectMeta{Name: "poc"},
+			Spec: TeamSpec{
+				Name:         "poc",
+				Environments: []TeamEnvironment{{Name: "dev", ResourceQuotaSpec: testRQ}},
+				Members: []TeamMember{
+					{Kind: "User", Name: "alice", Role: "admin"},
+					{Kind: "ServiceAccount", Name: "ci", Namespace: "ci", Role: "edit"},
+				},
+				PolicyRefs:        []PolicyReference{{APIGroup: "networking.k8s.io", Kind: "NetworkPolicy", Name: "deny-all"}},
+				NamespaceMetadata: &TeamMetadata{Labels: map[string]string{"cost-center": "platform"}},
+				Parent:            &TeamParent{Kind: "Team", Name: "platform"},
+				SyncedResources:   []TeamSyncedResource{{Kind: "Secret", Namespace: "platform", Name: "proxy"}},
+			},
+			Status: TeamStatus{
+				ObservedGeneration: 1,
+				MostUsedResource:   "dev/pods",
+				MostUsedPercentage: 80,
+				Environments: []EnvironmentStatus{{
+					Name:      "dev",
+					Namespace: "team-poc-dev",
+					QuotaUsage: []ResourceQuotaUsage{{
+						Name:      "team-default-rq",
+						Resources: []ResourceUsage{{Name: corev1.ResourcePods, Hard: "10", Used: "8", Percentage: 80}},
+					}},
+				}},
+				Conditions: []TeamCondition{
+					{Type: TeamReady, Status: corev1.ConditionFalse, Reason: "NotFound", LastTransitionTime: testTime},
+				},
+			},
+		},
+		"single v1 environment pod security": {
+			TypeMeta: metav1.TypeMeta{APIVersion: "aftouh.io/v2", Kind: "Team"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "poc-dev",
+				Annotations: map[string]string{v1DataAnnotation: `{"singleEnvironment":true}`},
+			},
+			Spec: TeamSpec{
+				Name:         "poc",
+				Environments: []TeamEnvironment{{Name: "dev", PodSecurity: &TeamPodSecurity{Enforce: "restricted"}}},
+				PodSecurity:  &TeamPodSecurity{Enforce: "baseline"},
+			},
+		},
+		"multiple environments": {
+			TypeMeta:   metav1.TypeMeta{APIVersion: "aftouh.io/v2", Kind: "Team"},
+			ObjectMeta: metav1.ObjectMeta{Name: "poc"},
+			Spec: TeamSpec{
+				Name:         "poc",
+				ClassName:    "standard",
+				Environments: []TeamEnvironment{{Name: "dev"}, {Name: "prod", ResourceQuotaSpec: testRQ}},
+			},
+		},
+	}
+
+	for name, in := range tests {
+		t.Run(name, func(t *testing.T) {
+			var spoke v1.Team
+			if err := ConvertV2ToV1(in.DeepCopy(), &spoke); err != nil {
+				t.Fatalf("failed converting v2 to v1: %v", err)
+			}
+			if spoke.APIVersion != "aftouh.io/v1" {
+				t.Errorf("expected apiVersion aftouh.io/v1, got %q", spoke.APIVersion)
+			}
+
+			var out Team
+			if err := ConvertV1ToV2(&spoke, &out); err != nil {
+				t.Fatalf("failed converting v1 to v2: %v", err)
+			}
+			if !equality.Semantic.DeepEqual(in, &out) {
+				t.Errorf("round trip changed the team\nDiff:\n %s", diff.ObjectGoPrintSideBySide(in, &out))
+			}
+		})
+	}
+}
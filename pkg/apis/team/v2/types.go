@@ -0,0 +1,287 @@
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Team defines team resource structure
+type Team struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TeamSpec   `json:"spec"`
+	Status TeamStatus `json:"status"`
+}
+
+// TeamSpec is the spec for a team resource
+type TeamSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Environments lists the team environments. Each environment gets its own namespace
+	Environments []TeamEnvironment `json:"environments"`
+	// Members lists the users, groups and service accounts of the team
+	Members []TeamMember `json:"members,omitempty"`
+	// PolicyRefs references the policies applied to the team namespaces
+	PolicyRefs []PolicyReference `json:"policyRefs,omitempty"`
+	// ClassName is the name of the TeamClass providing the team default values
+	ClassName string `json:"className,omitempty"`
+	// LimitRange is the limitrange of the team namespaces. Defaults to the class one
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
+	// NetworkPolicy defines the ingress traffic allowed into the team namespaces
+	NetworkPolicy *TeamNetworkPolicy `json:"networkPolicy,omitempty"`
+	// NamespaceMetadata holds the labels and annotations of the team namespaces and of every other object managed for the team
+	NamespaceMetadata *TeamMetadata `json:"namespaceMetadata,omitempty"`
+	// PodSecurity sets the pod security admission levels of the team namespaces
+	PodSecurity *TeamPodSecurity `json:"podSecurity,omitempty"`
+	// DeletionPolicy is what happens to the team namespaces when the team is deleted. Defaults to Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// NamespaceMigration defines how environments move to their new namespace when its generated name changes
+	NamespaceMigration *TeamNamespaceMigration `json:"namespaceMigration,omitempty"`
+	// QuotaAlerts are the quota usage percentages raising the QuotaPressure condition.
+	// Missing thresholds are set by the controller
+	QuotaAlerts *TeamQuotaAlerts `json:"quotaAlerts,omitempty"`
+	// Parent is the team or department whose budget caps the quotas of the team
+	Parent *TeamParent `json:"parent,omitempty"`
+	// Budget caps the sum of the resourcequota hard limits of the child teams
+	Budget corev1.ResourceList `json:"budget,omitempty"`
+	// State is Active, Suspended or Archived. Defaults to Active
+	State TeamState `json:"state,omitempty"`
+	// ExpiresAt is the time the team is deleted at
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// TTL is the lifetime of the team from its creation. It cannot be set with ExpiresAt
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// SyncedResources lists the configmaps and secrets copied into the team namespaces,
+	// in addition to the ones every team gets from the controller
+	SyncedResources []TeamSyncedResource `json:"syncedResources,omitempty"`
+}
+
+// TeamParent references the parent of a team
+type TeamParent struct {
+	// Kind is Team or Department
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// TeamSyncedResource references a source configmap or secret copied into the team namespaces under the same name
+type TeamSyncedResource struct {
+	// Kind is ConfigMap or Secret
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// BudgetStatus reports the allocation of a budget to the child teams
+type BudgetStatus struct {
+	// Allocated is the sum of the hard limits of the budgeted resources given to the children
+	Allocated corev1.ResourceList `json:"allocated,omitempty"`
+	// Children lists the child teams
+	Children []string `json:"children,omitempty"`
+	// OverBudget lists the child teams whose quotas do not fit in the remaining budget
+	OverBudget []string `json:"overBudget,omitempty"`
+}
+
+// TeamState is one of Active, Suspended or Archived
+type TeamState string
+
+// TeamPhase is the lifecycle phase of a team
+type TeamPhase string
+
+// NamespaceMigrationPolicy is one of Confirm or Copy
+type NamespaceMigrationPolicy string
+
+// TeamNamespaceMigration defines how environments move to their new namespace
+type TeamNamespaceMigration struct {
+	// Policy defaults to Confirm
+	Policy NamespaceMigrationPolicy `json:"policy,omitempty"`
+	// Resources lists the kinds copied by the Copy policy among ConfigMap, Secret, Deployment and Service.
+	// Defaults to all of them
+	Resources []string `json:"resources,omitempty"`
+}
+
+// TeamQuotaAlerts are the usage percentages of the most used quota resource raising an alert
+type TeamQuotaAlerts struct {
+	Warning  int32 `json:"warning,omitempty"`
+	Critical int32 `json:"critical,omitempty"`
+}
+
+// DeletionPolicy is one of Delete, Retain or Orphan
+type DeletionPolicy string
+
+// PodSecurityLevel is a level of the pod security standards
+type PodSecurityLevel string
+
+// TeamPodSecurity defines the pod security admission levels and versions of the team namespaces.
+// Versions are like v1.25 and default to latest
+type TeamPodSecurity struct {
+	// Enforce is the level above which pods are rejected
+	Enforce        PodSecurityLevel `json:"enforce,omitempty"`
+	EnforceVersion string           `json:"enforceVersion,omitempty"`
+	// Audit is the level above which pods are reported in the audit log
+	Audit        PodSecurityLevel `json:"audit,omitempty"`
+	AuditVersion string           `json:"auditVersion,omitempty"`
+	// Warn is the level above which users get a warning
+	Warn        PodSecurityLevel `json:"warn,omitempty"`
+	WarnVersion string           `json:"warnVersion,omitempty"`
+}
+
+// TeamMetadata holds labels and annotations propagated to the objects managed for the team
+type TeamMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// TeamEnvironment defines a team environment and its resourcequotas
+type TeamEnvironment struct {
+	Name string `json:"name"`
+	// ResourceQuotaSpec is the spec of the default resourcequota
+	ResourceQuotaSpec corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+	// ResourceQuotas are the additional resourcequotas of the environment
+	ResourceQuotas []TeamResourceQuota `json:"resourceQuotas,omitempty"`
+	// PodSecurity overrides the team pod security levels in the environment namespace
+	PodSecurity *TeamPodSecurity `json:"podSecurity,omitempty"`
+}
+
+// TeamResourceQuota is a named resourcequota of a team environment
+type TeamResourceQuota struct {
+	// Name of the quota. A quota named default replaces the one defined by resourceQuota
+	Name                     string `json:"name"`
+	corev1.ResourceQuotaSpec `json:",inline"`
+}
+
+// TeamMember is a subject that belongs to the team
+type TeamMember struct {
+	// Kind is one of User, Group or ServiceAccount
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Namespace of the service account. Defaults to the team namespace. Ignored for users and groups
+	Namespace string `json:"namespace,omitempty"`
+	// Role is the name of the cluster role given to the member in the team namespaces, like admin, edit or view
+	Role string `json:"role"`
+}
+
+// NetworkPolicyMode selects the baseline networkpolicies of the team namespaces
+type NetworkPolicyMode string
+
+// TeamNetworkPolicy defines the ingress traffic allowed into the team namespaces
+type TeamNetworkPolicy struct {
+	// Mode is one of open, isolated or team-isolated. Defaults to open
+	Mode NetworkPolicyMode `json:"mode,omitempty"`
+	// AllowedNamespaces selects other namespaces allowed to reach isolated team namespaces
+	AllowedNamespaces []metav1.LabelSelector `json:"allowedNamespaces,omitempty"`
+}
+
+// PolicyReference references a policy object applied to the team
+type PolicyReference struct {
+	APIGroup string `json:"apiGroup,omitempty"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+}
+
+// TeamStatus is the status for a Team resource
+type TeamStatus struct {
+	// ObservedGeneration is the most recent generation observed by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// MostUsedResource is the most used quota resource of the team, as <environment>/<resource>
+	MostUsedResource string `json:"mostUsedResource,omitempty"`
+	// MostUsedPercentage is the percentage of the hard limit used by MostUsedResource
+	MostUsedPercentage int32 `json:"mostUsedPercentage,omitempty"`
+	// Phase is Active, Suspending, Suspended, Archived or Resuming
+	Phase TeamPhase `json:"phase,omitempty"`
+	// ExpiresAt is the time the team is deleted at, extended by the aftouh.io/extend-expiry annotation
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// TimeLeft is the time left before the team expires, rounded up to the hour and to the minute in the last hour,
+	// e.g. 5h or 2d
+	TimeLeft string `json:"timeLeft,omitempty"`
+	// CIServiceAccount is the deploy service account of the team pipelines in each team namespace
+	CIServiceAccount string `json:"ciServiceAccount,omitempty"`
+	// Budget reports the allocation of the team budget to its child teams
+	Budget       *BudgetStatus       `json:"budget,omitempty"`
+	Environments []EnvironmentStatus `json:"environments,omitempty"`
+	Conditions   []TeamCondition     `json:"conditions,omitempty"`
+}
+
+// EnvironmentStatus is the status of a team environment
+type EnvironmentStatus struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// ResourceQuotas lists the resourcequotas of the environment
+	ResourceQuotas []string `json:"resourcequotas,omitempty"`
+	LimitRange     string   `json:"limitrange,omitempty"`
+	// PendingNamespace is the new generated namespace name the environment is not migrated to yet
+	PendingNamespace string `json:"pendingNamespace,omitempty"`
+	// PodSecurityViolations lists the running pods of the namespace that violate the enforced pod security level
+	PodSecurityViolations []string `json:"podSecurityViolations,omitempty"`
+	// MigratingFrom is the namespace whose resources are copied into the environment namespace
+	MigratingFrom string `json:"migratingFrom,omitempty"`
+	// QuotaUsage reports the hard limits and usage of the environment resourcequotas
+	QuotaUsage []ResourceQuotaUsage `json:"quotaUsage,omitempty"`
+}
+
+// ResourceQuotaUsage is the usage of a resourcequota of the environment
+type ResourceQuotaUsage struct {
+	Name      string          `json:"name"`
+	Resources []ResourceUsage `json:"resources,omitempty"`
+}
+
+// ResourceUsage is the hard limit and the usage of a resource of a resourcequota
+type ResourceUsage struct {
+	Name corev1.ResourceName `json:"name"`
+	Hard string              `json:"hard"`
+	Used string              `json:"used"`
+	// Percentage is the used share of the hard limit, rounded down
+	Percentage int32 `json:"percentage"`
+}
+
+// TeamConditionType is a valid value for TeamCondition.Type
+type TeamConditionType string
+
+const (
+	// TeamReady means the team namespaces and resourcequotas are up to date
+	TeamReady TeamConditionType = "Ready"
+	// TeamNamespaceReady means the namespaces of all team environments exist, are active and are owned by the team
+	TeamNamespaceReady TeamConditionType = "NamespaceReady"
+	// TeamResourceQuotaReady means the resourcequotas of all team environments exist and are owned by the team
+	TeamResourceQuotaReady TeamConditionType = "ResourceQuotaReady"
+	// TeamLimitRangeReady means the limitranges of all team environments exist and are owned by the team
+	TeamLimitRangeReady TeamConditionType = "LimitRangeReady"
+	// TeamConflict means a resource the team should manage already exists and is owned by someone else
+	TeamConflict TeamConditionType = "Conflict"
+	// TeamPodSecurityCompliant means no running pod of the team namespaces violates the enforced pod security level
+	TeamPodSecurityCompliant TeamConditionType = "PodSecurityCompliant"
+	// TeamTerminating means the team is deleted and waits for its namespaces to terminate
+	TeamTerminating TeamConditionType = "Terminating"
+	// TeamMigrating means an environment is not migrated to its new namespace yet
+	TeamMigrating TeamConditionType = "Migrating"
+	// TeamQuotaPressure means a quota resource of the team is used above the warning or critical threshold
+	TeamQuotaPressure TeamConditionType = "QuotaPressure"
+	// TeamOverBudget means the team quotas exceed the remaining budget of its parent.
+	// The team keeps its current resourcequotas until they fit
+	TeamOverBudget TeamConditionType = "OverBudget"
+	// TeamExpiring means the team expires within a day
+	TeamExpiring TeamConditionType = "Expiring"
+)
+
+// TeamCondition describes the state of a team at a certain point
+type TeamCondition struct {
+	Type               TeamConditionType      `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+}
+
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TeamList is a list of Team resources
+type TeamList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Team `json:"items"`
+}
@@ -0,0 +1,536 @@
+// +build !ignore_autogenerated
+
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package v2
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BudgetStatus) DeepCopyInto(out *BudgetStatus) {
+	*out = *in
+	if in.Allocated != nil {
+		in, out := &in.Allocated, &out.Allocated
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Children != nil {
+		in, out := &in.Children, &out.Children
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OverBudget != nil {
+		in, out := &in.OverBudget, &out.OverBudget
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BudgetStatus.
+func (in *BudgetStatus) DeepCopy() *BudgetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BudgetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentStatus) DeepCopyInto(out *EnvironmentStatus) {
+	*out = *in
+	if in.ResourceQuotas != nil {
+		in, out := &in.ResourceQuotas, &out.ResourceQuotas
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodSecurityViolations != nil {
+		in, out := &in.PodSecurityViolations, &out.PodSecurityViolations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.QuotaUsage != nil {
+		in, out := &in.QuotaUsage, &out.QuotaUsage
+		*out = make([]ResourceQuotaUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentStatus.
+func (in *EnvironmentStatus) DeepCopy() *EnvironmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyReference) DeepCopyInto(out *PolicyReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyReference.
+func (in *PolicyReference) DeepCopy() *PolicyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceQuotaUsage) DeepCopyInto(out *ResourceQuotaUsage) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceUsage, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceQuotaUsage.
+func (in *ResourceQuotaUsage) DeepCopy() *ResourceQuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceQuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceUsage) DeepCopyInto(out *ResourceUsage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceUsage.
+func (in *ResourceUsage) DeepCopy() *ResourceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Team) DeepCopyInto(out *Team) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Team.
+func (in *Team) DeepCopy() *Team {
+	if in == nil {
+		return nil
+	}
+	out := new(Team)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Team) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamCondition) DeepCopyInto(out *TeamCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamCondition.
+func (in *TeamCondition) DeepCopy() *TeamCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamEnvironment) DeepCopyInto(out *TeamEnvironment) {
+	*out = *in
+	in.ResourceQuotaSpec.DeepCopyInto(&out.ResourceQuotaSpec)
+	if in.ResourceQuotas != nil {
+		in, out := &in.ResourceQuotas, &out.ResourceQuotas
+		*out = make([]TeamResourceQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodSecurity != nil {
+		in, out := &in.PodSecurity, &out.PodSecurity
+		*out = new(TeamPodSecurity)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamEnvironment.
+func (in *TeamEnvironment) DeepCopy() *TeamEnvironment {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamEnvironment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamList) DeepCopyInto(out *TeamList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Team, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamList.
+func (in *TeamList) DeepCopy() *TeamList {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TeamList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamMember) DeepCopyInto(out *TeamMember) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamMember.
+func (in *TeamMember) DeepCopy() *TeamMember {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamMetadata) DeepCopyInto(out *TeamMetadata) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamMetadata.
+func (in *TeamMetadata) DeepCopy() *TeamMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamNamespaceMigration) DeepCopyInto(out *TeamNamespaceMigration) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamNamespaceMigration.
+func (in *TeamNamespaceMigration) DeepCopy() *TeamNamespaceMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamNamespaceMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamNetworkPolicy) DeepCopyInto(out *TeamNetworkPolicy) {
+	*out = *in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]metav1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamNetworkPolicy.
+func (in *TeamNetworkPolicy) DeepCopy() *TeamNetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamNetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamParent) DeepCopyInto(out *TeamParent) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamParent.
+func (in *TeamParent) DeepCopy() *TeamParent {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamParent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamPodSecurity) DeepCopyInto(out *TeamPodSecurity) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamPodSecurity.
+func (in *TeamPodSecurity) DeepCopy() *TeamPodSecurity {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamPodSecurity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamQuotaAlerts) DeepCopyInto(out *TeamQuotaAlerts) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamQuotaAlerts.
+func (in *TeamQuotaAlerts) DeepCopy() *TeamQuotaAlerts {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamQuotaAlerts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamResourceQuota) DeepCopyInto(out *TeamResourceQuota) {
+	*out = *in
+	in.ResourceQuotaSpec.DeepCopyInto(&out.ResourceQuotaSpec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamResourceQuota.
+func (in *TeamResourceQuota) DeepCopy() *TeamResourceQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamResourceQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamSpec) DeepCopyInto(out *TeamSpec) {
+	*out = *in
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]TeamEnvironment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]TeamMember, len(*in))
+		copy(*out, *in)
+	}
+	if in.PolicyRefs != nil {
+		in, out := &in.PolicyRefs, &out.PolicyRefs
+		*out = make([]PolicyReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.LimitRange != nil {
+		in, out := &in.LimitRange, &out.LimitRange
+		*out = new(v1.LimitRangeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(TeamNetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceMetadata != nil {
+		in, out := &in.NamespaceMetadata, &out.NamespaceMetadata
+		*out = new(TeamMetadata)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSecurity != nil {
+		in, out := &in.PodSecurity, &out.PodSecurity
+		*out = new(TeamPodSecurity)
+		**out = **in
+	}
+	if in.NamespaceMigration != nil {
+		in, out := &in.NamespaceMigration, &out.NamespaceMigration
+		*out = new(TeamNamespaceMigration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QuotaAlerts != nil {
+		in, out := &in.QuotaAlerts, &out.QuotaAlerts
+		*out = new(TeamQuotaAlerts)
+		**out = **in
+	}
+	if in.Parent != nil {
+		in, out := &in.Parent, &out.Parent
+		*out = new(TeamParent)
+		**out = **in
+	}
+	if in.Budget != nil {
+		in, out := &in.Budget, &out.Budget
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.SyncedResources != nil {
+		in, out := &in.SyncedResources, &out.SyncedResources
+		*out = make([]TeamSyncedResource, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamSpec.
+func (in *TeamSpec) DeepCopy() *TeamSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamStatus) DeepCopyInto(out *TeamStatus) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Budget != nil {
+		in, out := &in.Budget, &out.Budget
+		*out = new(BudgetStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]EnvironmentStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]TeamCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamStatus.
+func (in *TeamStatus) DeepCopy() *TeamStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamSyncedResource) DeepCopyInto(out *TeamSyncedResource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamSyncedResource.
+func (in *TeamSyncedResource) DeepCopy() *TeamSyncedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamSyncedResource)
+	in.DeepCopyInto(out)
+	return out
+}
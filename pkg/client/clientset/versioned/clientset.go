@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/typed/team/v1"
+	aftouhv2 "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/typed/team/v2"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -14,6 +15,7 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	AftouhV1() aftouhv1.AftouhV1Interface
+	AftouhV2() aftouhv2.AftouhV2Interface
 }
 
 // Clientset contains the clients for groups. Each group has exactly one
@@ -21,6 +23,7 @@ type Interface interface {
 type Clientset struct {
 	*discovery.DiscoveryClient
 	aftouhV1 *aftouhv1.AftouhV1Client
+	aftouhV2 *aftouhv2.AftouhV2Client
 }
 
 // AftouhV1 retrieves the AftouhV1Client
@@ -28,6 +31,11 @@ func (c *Clientset) AftouhV1() aftouhv1.AftouhV1Interface {
 	return c.aftouhV1
 }
 
+// AftouhV2 retrieves the AftouhV2Client
+func (c *Clientset) AftouhV2() aftouhv2.AftouhV2Interface {
+	return c.aftouhV2
+}
+
 // Discovery retrieves the DiscoveryClient
 func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 	if c == nil {
@@ -53,6 +61,10 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 	if err != nil {
 		return nil, err
 	}
+	cs.aftouhV2, err = aftouhv2.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(&configShallowCopy)
 	if err != nil {
@@ -66,6 +78,7 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 func NewForConfigOrDie(c *rest.Config) *Clientset {
 	var cs Clientset
 	cs.aftouhV1 = aftouhv1.NewForConfigOrDie(c)
+	cs.aftouhV2 = aftouhv2.NewForConfigOrDie(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClientForConfigOrDie(c)
 	return &cs
@@ -75,6 +88,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.aftouhV1 = aftouhv1.New(c)
+	cs.aftouhV2 = aftouhv2.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
@@ -6,6 +6,8 @@ import (
 	clientset "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned"
 	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/typed/team/v1"
 	fakeaftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/typed/team/v1/fake"
+	aftouhv2 "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/typed/team/v2"
+	fakeaftouhv2 "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/typed/team/v2/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -64,3 +66,8 @@ var _ clientset.Interface = &Clientset{}
 func (c *Clientset) AftouhV1() aftouhv1.AftouhV1Interface {
 	return &fakeaftouhv1.FakeAftouhV1{Fake: &c.Fake}
 }
+
+// AftouhV2 retrieves the AftouhV2Client
+func (c *Clientset) AftouhV2() aftouhv2.AftouhV2Interface {
+	return &fakeaftouhv2.FakeAftouhV2{Fake: &c.Fake}
+}
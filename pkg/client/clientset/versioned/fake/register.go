@@ -4,6 +4,7 @@ package fake
 
 import (
 	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	aftouhv2 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v2"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -16,6 +17,7 @@ var codecs = serializer.NewCodecFactory(scheme)
 var parameterCodec = runtime.NewParameterCodec(scheme)
 var localSchemeBuilder = runtime.SchemeBuilder{
 	aftouhv1.AddToScheme,
+	aftouhv2.AddToScheme,
 }
 
 // AddToScheme adds all types of this clientset into the given scheme. This allows composition
@@ -4,6 +4,7 @@ package scheme
 
 import (
 	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	aftouhv2 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v2"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -16,6 +17,7 @@ var Codecs = serializer.NewCodecFactory(Scheme)
 var ParameterCodec = runtime.NewParameterCodec(Scheme)
 var localSchemeBuilder = runtime.SchemeBuilder{
 	aftouhv1.AddToScheme,
+	aftouhv2.AddToScheme,
 }
 
 // AddToScheme adds all types of this clientset into the given scheme. This allows composition
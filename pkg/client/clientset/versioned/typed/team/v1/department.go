@@ -0,0 +1,164 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"time"
+
+	v1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	scheme "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// DepartmentsGetter has a method to return a DepartmentInterface.
+// A group's client should implement this interface.
+type DepartmentsGetter interface {
+	Departments() DepartmentInterface
+}
+
+// DepartmentInterface has methods to work with Department resources.
+type DepartmentInterface interface {
+	Create(*v1.Department) (*v1.Department, error)
+	Update(*v1.Department) (*v1.Department, error)
+	UpdateStatus(*v1.Department) (*v1.Department, error)
+	Delete(name string, options *metav1.DeleteOptions) error
+	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
+	Get(name string, options metav1.GetOptions) (*v1.Department, error)
+	List(opts metav1.ListOptions) (*v1.DepartmentList, error)
+	Watch(opts metav1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.Department, err error)
+	DepartmentExpansion
+}
+
+// departments implements DepartmentInterface
+type departments struct {
+	client rest.Interface
+}
+
+// newDepartments returns a Departments
+func newDepartments(c *AftouhV1Client) *departments {
+	return &departments{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the department, and returns the corresponding department object, and an error if there is any.
+func (c *departments) Get(name string, options metav1.GetOptions) (result *v1.Department, err error) {
+	result = &v1.Department{}
+	err = c.client.Get().
+		Resource("departments").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of Departments that match those selectors.
+func (c *departments) List(opts metav1.ListOptions) (result *v1.DepartmentList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.DepartmentList{}
+	err = c.client.Get().
+		Resource("departments").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested departments.
+func (c *departments) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("departments").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a department and creates it.  Returns the server's representation of the department, and an error, if there is any.
+func (c *departments) Create(department *v1.Department) (result *v1.Department, err error) {
+	result = &v1.Department{}
+	err = c.client.Post().
+		Resource("departments").
+		Body(department).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a department and updates it. Returns the server's representation of the department, and an error, if there is any.
+func (c *departments) Update(department *v1.Department) (result *v1.Department, err error) {
+	result = &v1.Department{}
+	err = c.client.Put().
+		Resource("departments").
+		Name(department.Name).
+		Body(department).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *departments) UpdateStatus(department *v1.Department) (result *v1.Department, err error) {
+	result = &v1.Department{}
+	err = c.client.Put().
+		Resource("departments").
+		Name(department.Name).
+		SubResource("status").
+		Body(department).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the department and deletes it. Returns an error if one occurs.
+func (c *departments) Delete(name string, options *metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("departments").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *departments) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("departments").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched department.
+func (c *departments) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.Department, err error) {
+	result = &v1.Department{}
+	err = c.client.Patch(pt).
+		Resource("departments").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -0,0 +1,115 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	teamv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeDepartments implements DepartmentInterface
+type FakeDepartments struct {
+	Fake *FakeAftouhV1
+}
+
+var departmentsResource = schema.GroupVersionResource{Group: "aftouh.io", Version: "v1", Resource: "departments"}
+
+var departmentsKind = schema.GroupVersionKind{Group: "aftouh.io", Version: "v1", Kind: "Department"}
+
+// Get takes name of the department, and returns the corresponding department object, and an error if there is any.
+func (c *FakeDepartments) Get(name string, options v1.GetOptions) (result *teamv1.Department, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(departmentsResource, name), &teamv1.Department{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*teamv1.Department), err
+}
+
+// List takes label and field selectors, and returns the list of Departments that match those selectors.
+func (c *FakeDepartments) List(opts v1.ListOptions) (result *teamv1.DepartmentList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(departmentsResource, departmentsKind, opts), &teamv1.DepartmentList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &teamv1.DepartmentList{ListMeta: obj.(*teamv1.DepartmentList).ListMeta}
+	for _, item := range obj.(*teamv1.DepartmentList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested departments.
+func (c *FakeDepartments) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(departmentsResource, opts))
+}
+
+// Create takes the representation of a department and creates it.  Returns the server's representation of the department, and an error, if there is any.
+func (c *FakeDepartments) Create(department *teamv1.Department) (result *teamv1.Department, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(departmentsResource, department), &teamv1.Department{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*teamv1.Department), err
+}
+
+// Update takes the representation of a department and updates it. Returns the server's representation of the department, and an error, if there is any.
+func (c *FakeDepartments) Update(department *teamv1.Department) (result *teamv1.Department, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(departmentsResource, department), &teamv1.Department{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*teamv1.Department), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeDepartments) UpdateStatus(department *teamv1.Department) (*teamv1.Department, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(departmentsResource, "status", department), &teamv1.Department{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*teamv1.Department), err
+}
+
+// Delete takes name of the department and deletes it. Returns an error if one occurs.
+func (c *FakeDepartments) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(departmentsResource, name), &teamv1.Department{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeDepartments) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(departmentsResource, listOptions)
+
+	_, err := c.Fake.Invokes(action, &teamv1.DepartmentList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched department.
+func (c *FakeDepartments) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *teamv1.Department, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(departmentsResource, name, pt, data, subresources...), &teamv1.Department{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*teamv1.Department), err
+}
@@ -12,10 +12,18 @@ type FakeAftouhV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeAftouhV1) Departments() v1.DepartmentInterface {
+	return &FakeDepartments{c}
+}
+
 func (c *FakeAftouhV1) Teams() v1.TeamInterface {
 	return &FakeTeams{c}
 }
 
+func (c *FakeAftouhV1) TeamClasses() v1.TeamClassInterface {
+	return &FakeTeamClasses{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeAftouhV1) RESTClient() rest.Interface {
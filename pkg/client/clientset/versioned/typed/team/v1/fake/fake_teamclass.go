@@ -0,0 +1,104 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	teamv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeTeamClasses implements TeamClassInterface
+type FakeTeamClasses struct {
+	Fake *FakeAftouhV1
+}
+
+var teamclassesResource = schema.GroupVersionResource{Group: "aftouh.io", Version: "v1", Resource: "teamclasses"}
+
+var teamclassesKind = schema.GroupVersionKind{Group: "aftouh.io", Version: "v1", Kind: "TeamClass"}
+
+// Get takes name of the teamClass, and returns the corresponding teamClass object, and an error if there is any.
+func (c *FakeTeamClasses) Get(name string, options v1.GetOptions) (result *teamv1.TeamClass, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(teamclassesResource, name), &teamv1.TeamClass{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*teamv1.TeamClass), err
+}
+
+// List takes label and field selectors, and returns the list of TeamClasses that match those selectors.
+func (c *FakeTeamClasses) List(opts v1.ListOptions) (result *teamv1.TeamClassList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(teamclassesResource, teamclassesKind, opts), &teamv1.TeamClassList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &teamv1.TeamClassList{ListMeta: obj.(*teamv1.TeamClassList).ListMeta}
+	for _, item := range obj.(*teamv1.TeamClassList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested teamClasses.
+func (c *FakeTeamClasses) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(teamclassesResource, opts))
+}
+
+// Create takes the representation of a teamClass and creates it.  Returns the server's representation of the teamClass, and an error, if there is any.
+func (c *FakeTeamClasses) Create(teamClass *teamv1.TeamClass) (result *teamv1.TeamClass, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(teamclassesResource, teamClass), &teamv1.TeamClass{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*teamv1.TeamClass), err
+}
+
+// Update takes the representation of a teamClass and updates it. Returns the server's representation of the teamClass, and an error, if there is any.
+func (c *FakeTeamClasses) Update(teamClass *teamv1.TeamClass) (result *teamv1.TeamClass, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(teamclassesResource, teamClass), &teamv1.TeamClass{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*teamv1.TeamClass), err
+}
+
+// Delete takes name of the teamClass and deletes it. Returns an error if one occurs.
+func (c *FakeTeamClasses) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(teamclassesResource, name), &teamv1.TeamClass{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeTeamClasses) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(teamclassesResource, listOptions)
+
+	_, err := c.Fake.Invokes(action, &teamv1.TeamClassList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched teamClass.
+func (c *FakeTeamClasses) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *teamv1.TeamClass, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(teamclassesResource, name, pt, data, subresources...), &teamv1.TeamClass{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*teamv1.TeamClass), err
+}
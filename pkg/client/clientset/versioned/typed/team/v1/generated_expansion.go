@@ -2,4 +2,8 @@
 
 package v1
 
+type DepartmentExpansion interface{}
+
 type TeamExpansion interface{}
+
+type TeamClassExpansion interface{}
@@ -10,7 +10,9 @@ import (
 
 type AftouhV1Interface interface {
 	RESTClient() rest.Interface
+	DepartmentsGetter
 	TeamsGetter
+	TeamClassesGetter
 }
 
 // AftouhV1Client is used to interact with features provided by the aftouh.io group.
@@ -18,10 +20,18 @@ type AftouhV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *AftouhV1Client) Departments() DepartmentInterface {
+	return newDepartments(c)
+}
+
 func (c *AftouhV1Client) Teams() TeamInterface {
 	return newTeams(c)
 }
 
+func (c *AftouhV1Client) TeamClasses() TeamClassInterface {
+	return newTeamClasses(c)
+}
+
 // NewForConfig creates a new AftouhV1Client for the given config.
 func NewForConfig(c *rest.Config) (*AftouhV1Client, error) {
 	config := *c
@@ -0,0 +1,148 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"time"
+
+	v1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	scheme "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// TeamClassesGetter has a method to return a TeamClassInterface.
+// A group's client should implement this interface.
+type TeamClassesGetter interface {
+	TeamClasses() TeamClassInterface
+}
+
+// TeamClassInterface has methods to work with TeamClass resources.
+type TeamClassInterface interface {
+	Create(*v1.TeamClass) (*v1.TeamClass, error)
+	Update(*v1.TeamClass) (*v1.TeamClass, error)
+	Delete(name string, options *metav1.DeleteOptions) error
+	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
+	Get(name string, options metav1.GetOptions) (*v1.TeamClass, error)
+	List(opts metav1.ListOptions) (*v1.TeamClassList, error)
+	Watch(opts metav1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.TeamClass, err error)
+	TeamClassExpansion
+}
+
+// teamClasses implements TeamClassInterface
+type teamClasses struct {
+	client rest.Interface
+}
+
+// newTeamClasses returns a TeamClasses
+func newTeamClasses(c *AftouhV1Client) *teamClasses {
+	return &teamClasses{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the teamClass, and returns the corresponding teamClass object, and an error if there is any.
+func (c *teamClasses) Get(name string, options metav1.GetOptions) (result *v1.TeamClass, err error) {
+	result = &v1.TeamClass{}
+	err = c.client.Get().
+		Resource("teamclasses").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of TeamClasses that match those selectors.
+func (c *teamClasses) List(opts metav1.ListOptions) (result *v1.TeamClassList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.TeamClassList{}
+	err = c.client.Get().
+		Resource("teamclasses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested teamClasses.
+func (c *teamClasses) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("teamclasses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a teamClass and creates it.  Returns the server's representation of the teamClass, and an error, if there is any.
+func (c *teamClasses) Create(teamClass *v1.TeamClass) (result *v1.TeamClass, err error) {
+	result = &v1.TeamClass{}
+	err = c.client.Post().
+		Resource("teamclasses").
+		Body(teamClass).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a teamClass and updates it. Returns the server's representation of the teamClass, and an error, if there is any.
+func (c *teamClasses) Update(teamClass *v1.TeamClass) (result *v1.TeamClass, err error) {
+	result = &v1.TeamClass{}
+	err = c.client.Put().
+		Resource("teamclasses").
+		Name(teamClass.Name).
+		Body(teamClass).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the teamClass and deletes it. Returns an error if one occurs.
+func (c *teamClasses) Delete(name string, options *metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("teamclasses").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *teamClasses) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("teamclasses").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched teamClass.
+func (c *teamClasses) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.TeamClass, err error) {
+	result = &v1.TeamClass{}
+	err = c.client.Patch(pt).
+		Resource("teamclasses").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -0,0 +1,24 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	v2 "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/typed/team/v2"
+	rest "k8s.io/client-go/rest"
+	testing "k8s.io/client-go/testing"
+)
+
+type FakeAftouhV2 struct {
+	*testing.Fake
+}
+
+func (c *FakeAftouhV2) Teams() v2.TeamInterface {
+	return &FakeTeams{c}
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *FakeAftouhV2) RESTClient() rest.Interface {
+	var ret *rest.RESTClient
+	return ret
+}
@@ -0,0 +1,164 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v2
+
+import (
+	"time"
+
+	v2 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v2"
+	scheme "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// TeamsGetter has a method to return a TeamInterface.
+// A group's client should implement this interface.
+type TeamsGetter interface {
+	Teams() TeamInterface
+}
+
+// TeamInterface has methods to work with Team resources.
+type TeamInterface interface {
+	Create(*v2.Team) (*v2.Team, error)
+	Update(*v2.Team) (*v2.Team, error)
+	UpdateStatus(*v2.Team) (*v2.Team, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v2.Team, error)
+	List(opts v1.ListOptions) (*v2.TeamList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v2.Team, err error)
+	TeamExpansion
+}
+
+// teams implements TeamInterface
+type teams struct {
+	client rest.Interface
+}
+
+// newTeams returns a Teams
+func newTeams(c *AftouhV2Client) *teams {
+	return &teams{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the team, and returns the corresponding team object, and an error if there is any.
+func (c *teams) Get(name string, options v1.GetOptions) (result *v2.Team, err error) {
+	result = &v2.Team{}
+	err = c.client.Get().
+		Resource("teams").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of Teams that match those selectors.
+func (c *teams) List(opts v1.ListOptions) (result *v2.TeamList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v2.TeamList{}
+	err = c.client.Get().
+		Resource("teams").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested teams.
+func (c *teams) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("teams").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a team and creates it.  Returns the server's representation of the team, and an error, if there is any.
+func (c *teams) Create(team *v2.Team) (result *v2.Team, err error) {
+	result = &v2.Team{}
+	err = c.client.Post().
+		Resource("teams").
+		Body(team).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a team and updates it. Returns the server's representation of the team, and an error, if there is any.
+func (c *teams) Update(team *v2.Team) (result *v2.Team, err error) {
+	result = &v2.Team{}
+	err = c.client.Put().
+		Resource("teams").
+		Name(team.Name).
+		Body(team).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *teams) UpdateStatus(team *v2.Team) (result *v2.Team, err error) {
+	result = &v2.Team{}
+	err = c.client.Put().
+		Resource("teams").
+		Name(team.Name).
+		SubResource("status").
+		Body(team).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the team and deletes it. Returns an error if one occurs.
+func (c *teams) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("teams").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *teams) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("teams").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched team.
+func (c *teams) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v2.Team, err error) {
+	result = &v2.Team{}
+	err = c.client.Patch(pt).
+		Resource("teams").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	v1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	v2 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v2"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -37,8 +38,16 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=aftouh.io, Version=v1
+	case v1.SchemeGroupVersion.WithResource("departments"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Aftouh().V1().Departments().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("teams"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Aftouh().V1().Teams().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("teamclasses"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Aftouh().V1().TeamClasses().Informer()}, nil
+
+		// Group=aftouh.io, Version=v2
+	case v2.SchemeGroupVersion.WithResource("teams"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Aftouh().V2().Teams().Informer()}, nil
 
 	}
 
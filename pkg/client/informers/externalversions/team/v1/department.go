@@ -0,0 +1,72 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	time "time"
+
+	teamv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	versioned "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/aftouh/k8s-sample-controller/pkg/client/informers/externalversions/internalinterfaces"
+	v1 "github.com/aftouh/k8s-sample-controller/pkg/client/listers/team/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// DepartmentInformer provides access to a shared informer and lister for
+// Departments.
+type DepartmentInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.DepartmentLister
+}
+
+type departmentInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewDepartmentInformer constructs a new informer for Department type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewDepartmentInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredDepartmentInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredDepartmentInformer constructs a new informer for Department type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredDepartmentInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.AftouhV1().Departments().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.AftouhV1().Departments().Watch(options)
+			},
+		},
+		&teamv1.Department{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *departmentInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredDepartmentInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *departmentInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&teamv1.Department{}, f.defaultInformer)
+}
+
+func (f *departmentInformer) Lister() v1.DepartmentLister {
+	return v1.NewDepartmentLister(f.Informer().GetIndexer())
+}
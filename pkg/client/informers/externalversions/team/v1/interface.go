@@ -8,8 +8,12 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// Departments returns a DepartmentInformer.
+	Departments() DepartmentInformer
 	// Teams returns a TeamInformer.
 	Teams() TeamInformer
+	// TeamClasses returns a TeamClassInformer.
+	TeamClasses() TeamClassInformer
 }
 
 type version struct {
@@ -23,7 +27,17 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// Departments returns a DepartmentInformer.
+func (v *version) Departments() DepartmentInformer {
+	return &departmentInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // Teams returns a TeamInformer.
 func (v *version) Teams() TeamInformer {
 	return &teamInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// TeamClasses returns a TeamClassInformer.
+func (v *version) TeamClasses() TeamClassInformer {
+	return &teamClassInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,72 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	time "time"
+
+	teamv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	versioned "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/aftouh/k8s-sample-controller/pkg/client/informers/externalversions/internalinterfaces"
+	v1 "github.com/aftouh/k8s-sample-controller/pkg/client/listers/team/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// TeamClassInformer provides access to a shared informer and lister for
+// TeamClasses.
+type TeamClassInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.TeamClassLister
+}
+
+type teamClassInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewTeamClassInformer constructs a new informer for TeamClass type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewTeamClassInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredTeamClassInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredTeamClassInformer constructs a new informer for TeamClass type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredTeamClassInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.AftouhV1().TeamClasses().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.AftouhV1().TeamClasses().Watch(options)
+			},
+		},
+		&teamv1.TeamClass{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *teamClassInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredTeamClassInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *teamClassInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&teamv1.TeamClass{}, f.defaultInformer)
+}
+
+func (f *teamClassInformer) Lister() v1.TeamClassLister {
+	return v1.NewTeamClassLister(f.Informer().GetIndexer())
+}
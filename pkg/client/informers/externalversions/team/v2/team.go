@@ -0,0 +1,72 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v2
+
+import (
+	time "time"
+
+	teamv2 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v2"
+	versioned "github.com/aftouh/k8s-sample-controller/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/aftouh/k8s-sample-controller/pkg/client/informers/externalversions/internalinterfaces"
+	v2 "github.com/aftouh/k8s-sample-controller/pkg/client/listers/team/v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// TeamInformer provides access to a shared informer and lister for
+// Teams.
+type TeamInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v2.TeamLister
+}
+
+type teamInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewTeamInformer constructs a new informer for Team type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewTeamInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredTeamInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredTeamInformer constructs a new informer for Team type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredTeamInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.AftouhV2().Teams().List(options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.AftouhV2().Teams().Watch(options)
+			},
+		},
+		&teamv2.Team{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *teamInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredTeamInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *teamInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&teamv2.Team{}, f.defaultInformer)
+}
+
+func (f *teamInformer) Lister() v2.TeamLister {
+	return v2.NewTeamLister(f.Informer().GetIndexer())
+}
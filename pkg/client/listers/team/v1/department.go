@@ -0,0 +1,49 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DepartmentLister helps list Departments.
+type DepartmentLister interface {
+	// List lists all Departments in the indexer.
+	List(selector labels.Selector) (ret []*v1.Department, err error)
+	// Get retrieves the Department from the index for a given name.
+	Get(name string) (*v1.Department, error)
+	DepartmentListerExpansion
+}
+
+// departmentLister implements the DepartmentLister interface.
+type departmentLister struct {
+	indexer cache.Indexer
+}
+
+// NewDepartmentLister returns a new DepartmentLister.
+func NewDepartmentLister(indexer cache.Indexer) DepartmentLister {
+	return &departmentLister{indexer: indexer}
+}
+
+// List lists all Departments in the indexer.
+func (s *departmentLister) List(selector labels.Selector) (ret []*v1.Department, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.Department))
+	})
+	return ret, err
+}
+
+// Get retrieves the Department from the index for a given name.
+func (s *departmentLister) Get(name string) (*v1.Department, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("department"), name)
+	}
+	return obj.(*v1.Department), nil
+}
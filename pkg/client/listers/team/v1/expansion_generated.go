@@ -2,6 +2,14 @@
 
 package v1
 
+// DepartmentListerExpansion allows custom methods to be added to
+// DepartmentLister.
+type DepartmentListerExpansion interface{}
+
 // TeamListerExpansion allows custom methods to be added to
 // TeamLister.
 type TeamListerExpansion interface{}
+
+// TeamClassListerExpansion allows custom methods to be added to
+// TeamClassLister.
+type TeamClassListerExpansion interface{}
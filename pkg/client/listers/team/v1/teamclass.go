@@ -0,0 +1,49 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// TeamClassLister helps list TeamClasses.
+type TeamClassLister interface {
+	// List lists all TeamClasses in the indexer.
+	List(selector labels.Selector) (ret []*v1.TeamClass, err error)
+	// Get retrieves the TeamClass from the index for a given name.
+	Get(name string) (*v1.TeamClass, error)
+	TeamClassListerExpansion
+}
+
+// teamClassLister implements the TeamClassLister interface.
+type teamClassLister struct {
+	indexer cache.Indexer
+}
+
+// NewTeamClassLister returns a new TeamClassLister.
+func NewTeamClassLister(indexer cache.Indexer) TeamClassLister {
+	return &teamClassLister{indexer: indexer}
+}
+
+// List lists all TeamClasses in the indexer.
+func (s *teamClassLister) List(selector labels.Selector) (ret []*v1.TeamClass, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.TeamClass))
+	})
+	return ret, err
+}
+
+// Get retrieves the TeamClass from the index for a given name.
+func (s *teamClassLister) Get(name string) (*v1.TeamClass, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("teamclass"), name)
+	}
+	return obj.(*v1.TeamClass), nil
+}
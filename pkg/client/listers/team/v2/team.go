@@ -0,0 +1,49 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v2
+
+import (
+	v2 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// TeamLister helps list Teams.
+type TeamLister interface {
+	// List lists all Teams in the indexer.
+	List(selector labels.Selector) (ret []*v2.Team, err error)
+	// Get retrieves the Team from the index for a given name.
+	Get(name string) (*v2.Team, error)
+	TeamListerExpansion
+}
+
+// teamLister implements the TeamLister interface.
+type teamLister struct {
+	indexer cache.Indexer
+}
+
+// NewTeamLister returns a new TeamLister.
+func NewTeamLister(indexer cache.Indexer) TeamLister {
+	return &teamLister{indexer: indexer}
+}
+
+// List lists all Teams in the indexer.
+func (s *teamLister) List(selector labels.Selector) (ret []*v2.Team, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v2.Team))
+	})
+	return ret, err
+}
+
+// Get retrieves the Team from the index for a given name.
+func (s *teamLister) Get(name string) (*v2.Team, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v2.Resource("team"), name)
+	}
+	return obj.(*v2.Team), nil
+}
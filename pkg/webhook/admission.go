@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+//admitFunc reviews an admission request
+type admitFunc func(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse
+
+//serveAdmission decodes the admission review, calls admit and writes the response
+func serveAdmission(w http.ResponseWriter, r *http.Request, admit admitFunc) {
+	var review admissionv1beta1.AdmissionReview
+	if err := readReview(r, &review); err != nil {
+		klog.Errorf("Invalid admission request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "admission review has no request", http.StatusBadRequest)
+		return
+	}
+
+	resp := admit(review.Request)
+	resp.UID = review.Request.UID
+	review.Response = resp
+	review.Request = nil
+	writeReview(w, &review)
+}
+
+func allowed() *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{Allowed: true}
+}
+
+func denied(code int32, reason metav1.StatusReason, msg string) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    code,
+			Reason:  reason,
+			Message: msg,
+		},
+	}
+}
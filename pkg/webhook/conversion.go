@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	aftouhv2 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v2"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+)
+
+//ConversionHandler converts teams between the aftouh.io api versions
+type ConversionHandler struct{}
+
+//NewConversionHandler creates the team conversion webhook handler
+func NewConversionHandler() *ConversionHandler {
+	return &ConversionHandler{}
+}
+
+func (h *ConversionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var review apiextensionsv1beta1.ConversionReview
+	if err := readReview(r, &review); err != nil {
+		klog.Errorf("Invalid conversion request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "conversion review has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.convert(review.Request)
+	review.Request = nil
+	writeReview(w, &review)
+}
+
+func (h *ConversionHandler) convert(req *apiextensionsv1beta1.ConversionRequest) *apiextensionsv1beta1.ConversionResponse {
+	resp := &apiextensionsv1beta1.ConversionResponse{UID: req.UID}
+
+	for _, obj := range req.Objects {
+		converted, err := convertTeam(obj.Raw, req.DesiredAPIVersion)
+		if err != nil {
+			klog.Warningf("Failed converting team to %s: %v", req.DesiredAPIVersion, err)
+			resp.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+			}
+			return resp
+		}
+		resp.ConvertedObjects = append(resp.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	resp.Result = metav1.Status{Status: metav1.StatusSuccess}
+	return resp
+}
+
+//convertTeam converts the json encoded team to the desired api version
+func convertTeam(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.Kind != "Team" {
+		return nil, fmt.Errorf("unexpected kind %q", typeMeta.Kind)
+	}
+
+	v1 := aftouhv1.SchemeGroupVersion.String()
+	v2 := aftouhv2.SchemeGroupVersion.String()
+	switch {
+	case typeMeta.APIVersion == desiredAPIVersion:
+		return raw, nil
+	case typeMeta.APIVersion == v1 && desiredAPIVersion == v2:
+		var in aftouhv1.Team
+		var out aftouhv2.Team
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, err
+		}
+		if err := aftouhv2.ConvertV1ToV2(&in, &out); err != nil {
+			return nil, err
+		}
+		return json.Marshal(&out)
+	case typeMeta.APIVersion == v2 && desiredAPIVersion == v1:
+		var in aftouhv2.Team
+		var out aftouhv1.Team
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, err
+		}
+		if err := aftouhv2.ConvertV2ToV1(&in, &out); err != nil {
+			return nil, err
+		}
+		return json.Marshal(&out)
+	default:
+		return nil, fmt.Errorf("unsupported conversion from %q to %q", typeMeta.APIVersion, desiredAPIVersion)
+	}
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	aftouhv2 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v2"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func postReview(t *testing.T, handler http.Handler, review interface{}, resp interface{}) int {
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("failed encoding review: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), resp); err != nil {
+			t.Fatalf("failed decoding review response: %v", err)
+		}
+	}
+	return rec.Code
+}
+
+func TestConvertV1ToV2(t *testing.T) {
+	review := apiextensionsv1beta1.ConversionReview{
+		Request: &apiextensionsv1beta1.ConversionRequest{
+			UID:               "uid",
+			DesiredAPIVersion: "aftouh.io/v2",
+			Objects: []runtime.RawExtension{{Raw: []byte(`{
+				"apiVersion": "aftouh.io/v1",
+				"kind": "Team",
+				"metadata": {"name": "poc-dev"},
+				"spec": {"name": "poc", "environment": "dev", "description": "", "resourceQuota": {"hard": {"pods": "4"}}}
+			}`)}},
+		},
+	}
+
+	var resp apiextensionsv1beta1.ConversionReview
+	if code := postReview(t, NewConversionHandler(), review, &resp); code != http.StatusOK {
+		t.Fatalf("expected status code 200, got %d", code)
+	}
+
+	if resp.Response == nil || resp.Response.Result.Status != metav1.StatusSuccess {
+		t.Fatalf("expected successful conversion, got %+v", resp.Response)
+	}
+	if resp.Response.UID != "uid" {
+		t.Errorf("expected response uid %q, got %q", "uid", resp.Response.UID)
+	}
+	if len(resp.Response.ConvertedObjects) != 1 {
+		t.Fatalf("expected 1 converted object, got %d", len(resp.Response.ConvertedObjects))
+	}
+
+	var team aftouhv2.Team
+	if err := json.Unmarshal(resp.Response.ConvertedObjects[0].Raw, &team); err != nil {
+		t.Fatalf("failed decoding converted team: %v", err)
+	}
+	if team.APIVersion != "aftouh.io/v2" {
+		t.Errorf("expected apiVersion aftouh.io/v2, got %q", team.APIVersion)
+	}
+	if len(team.Spec.Environments) != 1 || team.Spec.Environments[0].Name != "dev" {
+		t.Errorf("expected single dev environment, got %+v", team.Spec.Environments)
+	}
+}
+
+func TestConvertUnsupportedVersion(t *testing.T) {
+	review := apiextensionsv1beta1.ConversionReview{
+		Request: &apiextensionsv1beta1.ConversionRequest{
+			DesiredAPIVersion: "aftouh.io/v3",
+			Objects: []runtime.RawExtension{{Raw: []byte(`{
+				"apiVersion": "aftouh.io/v1",
+				"kind": "Team",
+				"metadata": {"name": "poc-dev"}
+			}`)}},
+		},
+	}
+
+	var resp apiextensionsv1beta1.ConversionReview
+	if code := postReview(t, NewConversionHandler(), review, &resp); code != http.StatusOK {
+		t.Fatalf("expected status code 200, got %d", code)
+	}
+	if resp.Response == nil || resp.Response.Result.Status != metav1.StatusFailure {
+		t.Errorf("expected failed conversion, got %+v", resp.Response)
+	}
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+//patchOperation is a json patch operation
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+//MutationHandler sets the default values of teams before they are validated
+type MutationHandler struct {
+	defaults aftouhv1.TeamDefaults
+}
+
+//NewMutationHandler creates the team defaulting webhook handler
+func NewMutationHandler(defaults aftouhv1.TeamDefaults) *MutationHandler {
+	return &MutationHandler{defaults: defaults}
+}
+
+func (h *MutationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveAdmission(w, r, h.admit)
+}
+
+func (h *MutationHandler) admit(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return allowed()
+	}
+
+	//spec is decoded separately to know whether the request contains it
+	var raw struct {
+		Spec json.RawMessage `json:"spec"`
+	}
+	var t aftouhv1.Team
+	if err := json.Unmarshal(req.Object.Raw, &raw); err != nil {
+		return denied(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid team: %v", err))
+	}
+	if err := json.Unmarshal(req.Object.Raw, &t); err != nil {
+		return denied(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid team: %v", err))
+	}
+
+	defaulted := t.DeepCopy()
+	h.defaults.SetDefaults(defaulted)
+
+	var patch []patchOperation
+	if raw.Spec == nil {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec", Value: defaulted.Spec})
+	} else {
+		patch = teamSpecPatch(&t.Spec, &defaulted.Spec)
+	}
+	if len(patch) == 0 {
+		return allowed()
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return denied(http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+	}
+	klog.V(4).Infof("Setting defaults of team %q: %s", t.Name, patchBytes)
+
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	resp := allowed()
+	resp.Patch = patchBytes
+	resp.PatchType = &patchType
+	return resp
+}
+
+//teamSpecPatch returns the json patch operations setting the defaulted fields
+func teamSpecPatch(spec, defaulted *aftouhv1.TeamSpec) []patchOperation {
+	var patch []patchOperation
+	if spec.Name != defaulted.Name {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/name", Value: defaulted.Name})
+	}
+	if spec.Environment != defaulted.Environment {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/environment", Value: defaulted.Environment})
+	}
+	if !reflect.DeepEqual(spec.ResourceQuotaSpec, defaulted.ResourceQuotaSpec) {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/resourceQuota", Value: defaulted.ResourceQuotaSpec})
+	}
+	if !reflect.DeepEqual(spec.NetworkPolicy, defaulted.NetworkPolicy) {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/networkPolicy", Value: defaulted.NetworkPolicy})
+	}
+	for i := range spec.Environments {
+		if !reflect.DeepEqual(spec.Environments[i].ResourceQuotaSpec, defaulted.Environments[i].ResourceQuotaSpec) {
+			patch = append(patch, patchOperation{
+				Op:    "add",
+				Path:  fmt.Sprintf("/spec/environments/%d/resourceQuota", i),
+				Value: defaulted.Environments[i].ResourceQuotaSpec,
+			})
+		}
+	}
+	return patch
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestMutateTeam(t *testing.T) {
+	defaults := aftouhv1.TeamDefaults{
+		Environment: "dev",
+		ResourceQuotaSpec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{
+				corev1.ResourcePods: *resource.NewQuantity(4, resource.DecimalSI),
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		team  string
+		patch string
+	}{
+		{
+			name:  "short team",
+			team:  `{"metadata": {"name": "poc"}, "spec": {}}`,
+			patch: `[{"op":"add","path":"/spec/name","value":"poc"},{"op":"add","path":"/spec/environment","value":"dev"},{"op":"add","path":"/spec/resourceQuota","value":{"hard":{"pods":"4"}}}]`,
+		},
+		{
+			name:  "missing spec",
+			team:  `{"metadata": {"name": "poc"}}`,
+			patch: `[{"op":"add","path":"/spec","value":{"name":"poc","environment":"dev","description":"","resourceQuota":{"hard":{"pods":"4"}}}}]`,
+		},
+		{
+			name:  "environment without quota",
+			team:  `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environments": [{"name": "dev", "resourceQuota": {"hard": {"pods": "2"}}}, {"name": "prod"}]}}`,
+			patch: `[{"op":"add","path":"/spec/environments/1/resourceQuota","value":{"hard":{"pods":"4"}}}]`,
+		},
+		{
+			name:  "team with class",
+			team:  `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "className": "standard"}}`,
+			patch: `[{"op":"add","path":"/spec/environment","value":"dev"}]`,
+		},
+		{
+			name: "complete team",
+			team: `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "resourceQuota": {"hard": {"pods": "2"}}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var resp admissionv1beta1.AdmissionReview
+			if code := postReview(t, NewMutationHandler(defaults), admissionReview(admissionv1beta1.Create, test.team), &resp); code != http.StatusOK {
+				t.Fatalf("expected status code 200, got %d", code)
+			}
+			if resp.Response == nil || !resp.Response.Allowed {
+				t.Fatalf("expected team to be allowed, got %+v", resp.Response)
+			}
+
+			if test.patch == "" {
+				if resp.Response.Patch != nil {
+					t.Errorf("expected no patch, got %s", resp.Response.Patch)
+				}
+				return
+			}
+
+			var expected, got interface{}
+			json.Unmarshal([]byte(test.patch), &expected)
+			if err := json.Unmarshal(resp.Response.Patch, &got); err != nil {
+				t.Fatalf("invalid patch %s: %v", resp.Response.Patch, err)
+			}
+			expectedBytes, _ := json.Marshal(expected)
+			gotBytes, _ := json.Marshal(got)
+			if string(expectedBytes) != string(gotBytes) {
+				t.Errorf("expected patch\n\t%s\ngot\n\t%s", expectedBytes, gotBytes)
+			}
+		})
+	}
+}
+
+func TestMutateNetworkPolicyMode(t *testing.T) {
+	defaults := aftouhv1.TeamDefaults{NetworkPolicyMode: aftouhv1.NetworkPolicyTeamIsolated}
+
+	tests := []struct {
+		name  string
+		team  string
+		patch string
+	}{
+		{
+			name:  "team without networkpolicy",
+			team:  `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "dev"}}`,
+			patch: `[{"op":"add","path":"/spec/networkPolicy","value":{"mode":"team-isolated"}}]`,
+		},
+		{
+			name:  "networkpolicy without mode",
+			team:  `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "dev", "networkPolicy": {"allowedNamespaces": [{"matchLabels": {"name": "monitoring"}}]}}}`,
+			patch: `[{"op":"add","path":"/spec/networkPolicy","value":{"mode":"team-isolated","allowedNamespaces":[{"matchLabels":{"name":"monitoring"}}]}}]`,
+		},
+		{
+			name: "open team",
+			team: `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "dev", "networkPolicy": {"mode": "open"}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var resp admissionv1beta1.AdmissionReview
+			if code := postReview(t, NewMutationHandler(defaults), admissionReview(admissionv1beta1.Create, test.team), &resp); code != http.StatusOK {
+				t.Fatalf("expected status code 200, got %d", code)
+			}
+			if resp.Response == nil || !resp.Response.Allowed {
+				t.Fatalf("expected team to be allowed, got %+v", resp.Response)
+			}
+			if test.patch == "" {
+				if resp.Response.Patch != nil {
+					t.Errorf("expected no patch, got %s", resp.Response.Patch)
+				}
+				return
+			}
+			if string(resp.Response.Patch) != test.patch {
+				t.Errorf("expected patch\n\t%s\ngot\n\t%s", test.patch, resp.Response.Patch)
+			}
+		})
+	}
+}
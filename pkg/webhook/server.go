@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"k8s.io/klog"
+)
+
+const shutdownTimeout = time.Second * 10
+
+//Server serves the team webhooks over https
+type Server struct {
+	server   *http.Server
+	mux      *http.ServeMux
+	certFile string
+	keyFile  string
+}
+
+//NewServer creates a webhook server listening on the given port
+func NewServer(port int, certFile, keyFile string) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		server: &http.Server{
+			Addr:    fmt.Sprintf(":%d", port),
+			Handler: mux,
+		},
+		mux:      mux,
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+}
+
+//Handle registers the handler for the given path
+func (s *Server) Handle(path string, handler http.Handler) {
+	s.mux.Handle(path, handler)
+}
+
+//Run starts the server and blocks until stopCh is closed
+func (s *Server) Run(stopCh <-chan struct{}) error {
+	errCh := make(chan error, 1)
+	go func() {
+		klog.Infof("Starting webhook server on %s", s.server.Addr)
+		if err := s.server.ListenAndServeTLS(s.certFile, s.keyFile); err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-stopCh:
+	}
+
+	klog.Info("Shutting down webhook server")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+//readReview decodes the json review sent by the apiserver
+func readReview(r *http.Request, review interface{}) error {
+	if r.Method != http.MethodPost {
+		return fmt.Errorf("unsupported method %q", r.Method)
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		return fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed reading request body: %v", err)
+	}
+	if err := json.Unmarshal(body, review); err != nil {
+		return fmt.Errorf("failed decoding review: %v", err)
+	}
+	return nil
+}
+
+//writeReview encodes the review response
+func writeReview(w http.ResponseWriter, review interface{}) {
+	resp, err := json.Marshal(review)
+	if err != nil {
+		klog.Errorf("Failed encoding review response: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(resp); err != nil {
+		klog.Errorf("Failed writing review response: %v", err)
+	}
+}
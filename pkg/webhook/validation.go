@@ -0,0 +1,501 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	tlister "github.com/aftouh/k8s-sample-controller/pkg/client/listers/team/v1"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+)
+
+//podSecurityVersion matches the pod security standards versions
+var podSecurityVersion = regexp.MustCompile(`^(latest|v1\.[0-9]+)$`)
+
+//NamespaceFunc returns the namespace name of a team environment
+type NamespaceFunc func(t *aftouhv1.Team, env string) (string, error)
+
+//ValidationHandler rejects invalid teams before they are stored
+type ValidationHandler struct {
+	tLister       tlister.TeamLister
+	namespaceFunc NamespaceFunc
+	//requireNameMatch rejects teams whose spec.name differs from metadata.name
+	requireNameMatch bool
+	//syncedNamespaces are the namespaces teams may sync configmaps and secrets from
+	syncedNamespaces []string
+	//allowedMemberRoles are the cluster roles team members may be given
+	allowedMemberRoles []string
+}
+
+//NewValidationHandler creates the team validating webhook handler
+func NewValidationHandler(tLister tlister.TeamLister, namespaceFunc NamespaceFunc, requireNameMatch bool, syncedNamespaces, allowedMemberRoles []string) *ValidationHandler {
+	return &ValidationHandler{
+		tLister:            tLister,
+		namespaceFunc:      namespaceFunc,
+		requireNameMatch:   requireNameMatch,
+		syncedNamespaces:   syncedNamespaces,
+		allowedMemberRoles: allowedMemberRoles,
+	}
+}
+
+func (h *ValidationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveAdmission(w, r, h.admit)
+}
+
+func (h *ValidationHandler) admit(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return allowed()
+	}
+
+	var t aftouhv1.Team
+	if err := json.Unmarshal(req.Object.Raw, &t); err != nil {
+		return denied(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid team: %v", err))
+	}
+
+	//The finalizer removal and the other updates leaving the spec unchanged are allowed even if the spec is no longer
+	//valid for the current controller flags, e.g. a synced namespace removed from -synced-namespaces
+	if t.DeletionTimestamp != nil {
+		return allowed()
+	}
+	specUnchanged := false
+	if req.Operation == admissionv1beta1.Update {
+		var old aftouhv1.Team
+		if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+			return denied(http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid old team: %v", err))
+		}
+		specUnchanged = equality.Semantic.DeepEqual(old.Spec, t.Spec)
+	}
+
+	var errs field.ErrorList
+	var err error
+	if specUnchanged {
+		errs = validateAnnotations(&t)
+	} else {
+		errs, err = h.validateTeam(&t)
+	}
+	if err != nil {
+		klog.Errorf("Failed validating team %q: %v", t.Name, err)
+		return denied(http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+	}
+	if len(errs) > 0 {
+		klog.V(4).Infof("Rejecting team %q: %v", t.Name, errs.ToAggregate())
+		return denied(http.StatusUnprocessableEntity, metav1.StatusReasonInvalid, errs.ToAggregate().Error())
+	}
+	return allowed()
+}
+
+func (h *ValidationHandler) validateTeam(t *aftouhv1.Team) (field.ErrorList, error) {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	errs = append(errs, validateDNSLabel(t.Spec.Name, specPath.Child("name"))...)
+	if h.requireNameMatch && t.Spec.Name != t.Name {
+		errs = append(errs, field.Invalid(specPath.Child("name"), t.Spec.Name, "must match metadata.name"))
+	}
+	errs = append(errs, validateMembers(t.Spec.Members, h.allowedMemberRoles, specPath.Child("members"))...)
+	if t.Spec.LimitRange != nil {
+		errs = append(errs, validateLimitRange(*t.Spec.LimitRange, specPath.Child("limitRange"))...)
+	}
+	if t.Spec.NetworkPolicy != nil {
+		errs = append(errs, validateNetworkPolicy(*t.Spec.NetworkPolicy, specPath.Child("networkPolicy"))...)
+	}
+	if t.Spec.NamespaceMetadata != nil {
+		errs = append(errs, validateNamespaceMetadata(*t.Spec.NamespaceMetadata, specPath.Child("namespaceMetadata"))...)
+	}
+	switch t.Spec.DeletionPolicy {
+	case "", aftouhv1.DeletionPolicyDelete, aftouhv1.DeletionPolicyRetain, aftouhv1.DeletionPolicyOrphan:
+	default:
+		policies := []string{string(aftouhv1.DeletionPolicyDelete), string(aftouhv1.DeletionPolicyRetain), string(aftouhv1.DeletionPolicyOrphan)}
+		errs = append(errs, field.NotSupported(specPath.Child("deletionPolicy"), t.Spec.DeletionPolicy, policies))
+	}
+	switch t.Spec.State {
+	case "", aftouhv1.TeamStateActive, aftouhv1.TeamStateSuspended, aftouhv1.TeamStateArchived:
+	default:
+		states := []string{string(aftouhv1.TeamStateActive), string(aftouhv1.TeamStateSuspended), string(aftouhv1.TeamStateArchived)}
+		errs = append(errs, field.NotSupported(specPath.Child("state"), t.Spec.State, states))
+	}
+	if t.Spec.PodSecurity != nil {
+		errs = append(errs, validatePodSecurity(*t.Spec.PodSecurity, specPath.Child("podSecurity"))...)
+	}
+	if t.Spec.NamespaceMigration != nil {
+		errs = append(errs, validateNamespaceMigration(*t.Spec.NamespaceMigration, specPath.Child("namespaceMigration"))...)
+	}
+	if t.Spec.QuotaAlerts != nil {
+		errs = append(errs, validateQuotaAlerts(*t.Spec.QuotaAlerts, specPath.Child("quotaAlerts"))...)
+	}
+	if t.Spec.Parent != nil {
+		errs = append(errs, validateParent(t, *t.Spec.Parent, specPath.Child("parent"))...)
+	}
+	errs = append(errs, validateResourceList(t.Spec.Budget, specPath.Child("budget"))...)
+	errs = append(errs, validateExpiry(t, specPath)...)
+	errs = append(errs, validateAnnotations(t)...)
+	errs = append(errs, h.validateSyncedResources(t.Spec.SyncedResources, specPath.Child("syncedResources"))...)
+
+	//Environments and namespaces used by the other teams
+	teams, err := h.tLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("unable to list teams: %v", err)
+	}
+	usedEnvs := make(map[string]string)
+	usedNamespaces := make(map[string]string)
+	for _, other := range teams {
+		if other.Name == t.Name {
+			continue
+		}
+		for _, env := range other.Spec.GetEnvironments() {
+			usedEnvs[other.Spec.Name+"/"+env.Name] = other.Name
+			if namespace, err := h.namespaceFunc(other, env.Name); err == nil {
+				usedNamespaces[namespace] = other.Name
+			}
+		}
+	}
+
+	if len(t.Spec.Environments) == 0 {
+		errs = append(errs, h.validateEnvironment(t, t.Spec.Environment, specPath.Child("environment"), usedEnvs, usedNamespaces)...)
+		errs = append(errs, validateResourceQuota(t.Spec.ResourceQuotaSpec, specPath.Child("resourceQuota"))...)
+		errs = append(errs, validateResourceQuotas(t.Spec.ResourceQuotas, specPath.Child("resourceQuotas"))...)
+		return errs, nil
+	}
+
+	envNames := make(map[string]bool)
+	for i, env := range t.Spec.Environments {
+		envPath := specPath.Child("environments").Index(i)
+		if envNames[env.Name] {
+			errs = append(errs, field.Duplicate(envPath.Child("name"), env.Name))
+			continue
+		}
+		envNames[env.Name] = true
+		errs = append(errs, h.validateEnvironment(t, env.Name, envPath.Child("name"), usedEnvs, usedNamespaces)...)
+		errs = append(errs, validateResourceQuota(env.ResourceQuotaSpec, envPath.Child("resourceQuota"))...)
+		errs = append(errs, validateResourceQuotas(env.ResourceQuotas, envPath.Child("resourceQuotas"))...)
+		if env.PodSecurity != nil {
+			errs = append(errs, validatePodSecurity(*env.PodSecurity, envPath.Child("podSecurity"))...)
+		}
+	}
+	return errs, nil
+}
+
+func (h *ValidationHandler) validateEnvironment(t *aftouhv1.Team, env string, path *field.Path, usedEnvs, usedNamespaces map[string]string) field.ErrorList {
+	if env == "" {
+		return field.ErrorList{field.Required(path, "")}
+	}
+
+	errs := validateDNSLabel(env, path)
+	if owner, ok := usedEnvs[t.Spec.Name+"/"+env]; ok {
+		errs = append(errs, field.Duplicate(path, fmt.Sprintf("%s/%s is already used by team %q", t.Spec.Name, env, owner)))
+	}
+
+	namespace, err := h.namespaceFunc(t, env)
+	if err != nil {
+		return append(errs, field.Invalid(path, env, err.Error()))
+	}
+	for _, msg := range validation.IsDNS1123Label(namespace) {
+		errs = append(errs, field.Invalid(path, env, fmt.Sprintf("generated namespace %q is invalid: %s", namespace, msg)))
+	}
+	if owner, ok := usedNamespaces[namespace]; ok {
+		errs = append(errs, field.Invalid(path, env, fmt.Sprintf("generated namespace %q is already used by team %q", namespace, owner)))
+	}
+	return errs
+}
+
+func validateMembers(members []aftouhv1.TeamMember, allowedRoles []string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	kinds := []string{aftouhv1.MemberKindUser, aftouhv1.MemberKindGroup, aftouhv1.MemberKindServiceAccount}
+	for i, m := range members {
+		memberPath := path.Index(i)
+		switch m.Kind {
+		case aftouhv1.MemberKindUser, aftouhv1.MemberKindGroup:
+		case aftouhv1.MemberKindServiceAccount:
+			errs = append(errs, validateDNSSubdomain(m.Name, memberPath.Child("name"))...)
+			if m.Namespace != "" {
+				errs = append(errs, validateDNSLabel(m.Namespace, memberPath.Child("namespace"))...)
+			}
+		default:
+			errs = append(errs, field.NotSupported(memberPath.Child("kind"), m.Kind, kinds))
+		}
+		if m.Name == "" {
+			errs = append(errs, field.Required(memberPath.Child("name"), ""))
+		}
+		//The controller is only allowed to bind the allowed roles
+		if m.Role == "" {
+			errs = append(errs, field.Required(memberPath.Child("role"), ""))
+		} else if !isAllowedRole(m.Role, allowedRoles) {
+			errs = append(errs, field.NotSupported(memberPath.Child("role"), m.Role, allowedRoles))
+		}
+	}
+	return errs
+}
+
+func isAllowedRole(role string, allowedRoles []string) bool {
+	for _, r := range allowedRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func validateDNSSubdomain(value string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if value == "" {
+		return errs
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(value) {
+		errs = append(errs, field.Invalid(path, value, msg))
+	}
+	return errs
+}
+
+func validateDNSLabel(value string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for _, msg := range validation.IsDNS1123Label(value) {
+		errs = append(errs, field.Invalid(path, value, msg))
+	}
+	return errs
+}
+
+func validateNetworkPolicy(np aftouhv1.TeamNetworkPolicy, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch np.Mode {
+	case "", aftouhv1.NetworkPolicyOpen, aftouhv1.NetworkPolicyIsolated, aftouhv1.NetworkPolicyTeamIsolated:
+	default:
+		modes := []string{string(aftouhv1.NetworkPolicyOpen), string(aftouhv1.NetworkPolicyIsolated), string(aftouhv1.NetworkPolicyTeamIsolated)}
+		errs = append(errs, field.NotSupported(path.Child("mode"), np.Mode, modes))
+	}
+	for i := range np.AllowedNamespaces {
+		errs = append(errs, metav1validation.ValidateLabelSelector(&np.AllowedNamespaces[i], path.Child("allowedNamespaces").Index(i))...)
+	}
+	return errs
+}
+
+//validateNamespaceMetadata rejects invalid keys and values, and the keys the controller sets itself
+func validateNamespaceMetadata(md aftouhv1.TeamMetadata, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, metav1validation.ValidateLabels(md.Labels, path.Child("labels"))...)
+	errs = append(errs, apivalidation.ValidateAnnotations(md.Annotations, path.Child("annotations"))...)
+	for _, k := range []string{"team", "env"} {
+		if _, ok := md.Labels[k]; ok {
+			errs = append(errs, field.Forbidden(path.Child("labels").Key(k), "is set by the controller"))
+		}
+	}
+	for _, k := range []string{aftouhv1.ManagedLabelsAnnotation, aftouhv1.ManagedAnnotationsAnnotation} {
+		if _, ok := md.Annotations[k]; ok {
+			errs = append(errs, field.Forbidden(path.Child("annotations").Key(k), "is set by the controller"))
+		}
+	}
+	return errs
+}
+
+func validatePodSecurity(ps aftouhv1.TeamPodSecurity, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	modes := []struct {
+		mode    string
+		level   aftouhv1.PodSecurityLevel
+		version string
+	}{
+		{"enforce", ps.Enforce, ps.EnforceVersion},
+		{"audit", ps.Audit, ps.AuditVersion},
+		{"warn", ps.Warn, ps.WarnVersion},
+	}
+	for _, m := range modes {
+		switch m.level {
+		case "", aftouhv1.PodSecurityPrivileged, aftouhv1.PodSecurityBaseline, aftouhv1.PodSecurityRestricted:
+		default:
+			levels := []string{string(aftouhv1.PodSecurityPrivileged), string(aftouhv1.PodSecurityBaseline), string(aftouhv1.PodSecurityRestricted)}
+			errs = append(errs, field.NotSupported(path.Child(m.mode), m.level, levels))
+		}
+		if m.version != "" && !podSecurityVersion.MatchString(m.version) {
+			errs = append(errs, field.Invalid(path.Child(m.mode+"Version"), m.version, "must be latest or a kubernetes minor version like v1.25"))
+		}
+	}
+	return errs
+}
+
+func validateNamespaceMigration(m aftouhv1.TeamNamespaceMigration, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch m.Policy {
+	case "", aftouhv1.NamespaceMigrationConfirm, aftouhv1.NamespaceMigrationCopy:
+	default:
+		policies := []string{string(aftouhv1.NamespaceMigrationConfirm), string(aftouhv1.NamespaceMigrationCopy)}
+		errs = append(errs, field.NotSupported(path.Child("policy"), m.Policy, policies))
+	}
+
+	used := make(map[string]bool)
+	for i, r := range m.Resources {
+		idxPath := path.Child("resources").Index(i)
+		supported := false
+		for _, kind := range aftouhv1.NamespaceMigrationResources {
+			supported = supported || r == kind
+		}
+		switch {
+		case !supported:
+			errs = append(errs, field.NotSupported(idxPath, r, aftouhv1.NamespaceMigrationResources))
+		case used[r]:
+			errs = append(errs, field.Duplicate(idxPath, r))
+		}
+		used[r] = true
+	}
+	return errs
+}
+
+func validateQuotaAlerts(qa aftouhv1.TeamQuotaAlerts, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if qa.Warning < 0 || qa.Warning > 100 {
+		errs = append(errs, field.Invalid(path.Child("warning"), qa.Warning, "must be a percentage between 0 and 100"))
+	}
+	if qa.Critical < 0 || qa.Critical > 100 {
+		errs = append(errs, field.Invalid(path.Child("critical"), qa.Critical, "must be a percentage between 0 and 100"))
+	}
+	//Thresholds left empty are set by the controller, the order can only be checked when both are set
+	if qa.Warning > 0 && qa.Critical > 0 && qa.Warning >= qa.Critical {
+		errs = append(errs, field.Invalid(path.Child("warning"), qa.Warning, "must be lower than the critical threshold"))
+	}
+	return errs
+}
+
+//validateExpiry checks the team expiry
+func validateExpiry(t *aftouhv1.Team, specPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if t.Spec.TTL != nil {
+		if t.Spec.ExpiresAt != nil {
+			errs = append(errs, field.Forbidden(specPath.Child("ttl"), "may not be set with expiresAt"))
+		}
+		if t.Spec.TTL.Duration <= 0 {
+			errs = append(errs, field.Invalid(specPath.Child("ttl"), t.Spec.TTL.Duration.String(), "must be positive"))
+		}
+	}
+	return errs
+}
+
+//validateAnnotations checks the team annotations read by the controller
+func validateAnnotations(t *aftouhv1.Team) field.ErrorList {
+	var errs field.ErrorList
+	if ext, ok := t.Annotations[aftouhv1.ExtendExpiryAnnotation]; ok {
+		annotationPath := field.NewPath("metadata", "annotations").Key(aftouhv1.ExtendExpiryAnnotation)
+		if d, err := time.ParseDuration(ext); err != nil || d < 0 {
+			errs = append(errs, field.Invalid(annotationPath, ext, "must be a positive duration, e.g. 48h"))
+		}
+	}
+	return errs
+}
+
+//validateSyncedResources checks the synced resource references. The copies keep the source name, so two sources
+//of the same kind cannot have the same name. Sources outside of the allowed namespaces are forbidden: the team
+//members could otherwise read any secret of the cluster through its copy
+func (h *ValidationHandler) validateSyncedResources(resources []aftouhv1.TeamSyncedResource, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := make(map[string]bool)
+	for i, r := range resources {
+		rPath := path.Index(i)
+		switch r.Kind {
+		case aftouhv1.SyncedResourceKindConfigMap, aftouhv1.SyncedResourceKindSecret:
+		default:
+			kinds := []string{aftouhv1.SyncedResourceKindConfigMap, aftouhv1.SyncedResourceKindSecret}
+			errs = append(errs, field.NotSupported(rPath.Child("kind"), r.Kind, kinds))
+		}
+		if r.Namespace == "" {
+			errs = append(errs, field.Required(rPath.Child("namespace"), ""))
+		} else if !h.isSyncedNamespace(r.Namespace) {
+			errs = append(errs, field.Forbidden(rPath.Child("namespace"),
+				fmt.Sprintf("resources of namespace %q may not be synced, allowed namespaces: [%s]", r.Namespace, strings.Join(h.syncedNamespaces, ", "))))
+		}
+		if r.Name == "" {
+			errs = append(errs, field.Required(rPath.Child("name"), ""))
+			continue
+		}
+		errs = append(errs, validateDNSSubdomain(r.Name, rPath.Child("name"))...)
+		if names[r.Kind+"/"+r.Name] {
+			errs = append(errs, field.Duplicate(rPath.Child("name"), r.Name))
+		}
+		names[r.Kind+"/"+r.Name] = true
+	}
+	return errs
+}
+
+func (h *ValidationHandler) isSyncedNamespace(namespace string) bool {
+	for _, ns := range h.syncedNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+//validateParent checks the parent reference. The parent may not exist yet, the controller reports it in the team status
+func validateParent(t *aftouhv1.Team, parent aftouhv1.TeamParent, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch parent.Kind {
+	case aftouhv1.ParentKindTeam:
+		if parent.Name == t.Name {
+			errs = append(errs, field.Invalid(path.Child("name"), parent.Name, "a team cannot be its own parent"))
+		}
+	case aftouhv1.ParentKindDepartment:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("kind"), parent.Kind, []string{aftouhv1.ParentKindTeam, aftouhv1.ParentKindDepartment}))
+	}
+	if parent.Name == "" {
+		errs = append(errs, field.Required(path.Child("name"), ""))
+	} else {
+		errs = append(errs, validateDNSSubdomain(parent.Name, path.Child("name"))...)
+	}
+	return errs
+}
+
+func validateLimitRange(spec corev1.LimitRangeSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, item := range spec.Limits {
+		itemPath := path.Child("limits").Index(i)
+		errs = append(errs, validateResourceList(item.Max, itemPath.Child("max"))...)
+		errs = append(errs, validateResourceList(item.Min, itemPath.Child("min"))...)
+		errs = append(errs, validateResourceList(item.Default, itemPath.Child("default"))...)
+		errs = append(errs, validateResourceList(item.DefaultRequest, itemPath.Child("defaultRequest"))...)
+	}
+	return errs
+}
+
+func validateResourceQuotas(quotas []aftouhv1.TeamResourceQuota, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := make(map[string]bool)
+	for i, q := range quotas {
+		quotaPath := path.Index(i)
+		switch {
+		case q.Name == "":
+			errs = append(errs, field.Required(quotaPath.Child("name"), ""))
+		case names[q.Name]:
+			errs = append(errs, field.Duplicate(quotaPath.Child("name"), q.Name))
+		default:
+			errs = append(errs, validateDNSLabel(q.Name, quotaPath.Child("name"))...)
+		}
+		names[q.Name] = true
+		errs = append(errs, validateResourceQuota(q.ResourceQuotaSpec, quotaPath)...)
+	}
+	return errs
+}
+
+func validateResourceQuota(spec corev1.ResourceQuotaSpec, path *field.Path) field.ErrorList {
+	return validateResourceList(spec.Hard, path.Child("hard"))
+}
+
+func validateResourceList(rl corev1.ResourceList, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for name, q := range rl {
+		if q.Sign() < 0 {
+			errs = append(errs, field.Invalid(path.Key(string(name)), q.String(), "must be greater than or equal to 0"))
+		}
+	}
+	return errs
+}
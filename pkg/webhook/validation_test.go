@@ -0,0 +1,373 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	aftouhv1 "github.com/aftouh/k8s-sample-controller/pkg/apis/team/v1"
+	tlister "github.com/aftouh/k8s-sample-controller/pkg/client/listers/team/v1"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+func testNamespace(t *aftouhv1.Team, env string) (string, error) {
+	return fmt.Sprintf("team-%s-%s", t.Spec.Name, env), nil
+}
+
+func newTeamLister(teams ...*aftouhv1.Team) tlister.TeamLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, t := range teams {
+		indexer.Add(t)
+	}
+	return tlister.NewTeamLister(indexer)
+}
+
+func admissionReview(op admissionv1beta1.Operation, team string) admissionv1beta1.AdmissionReview {
+	return admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       "uid",
+			Operation: op,
+			Object:    runtime.RawExtension{Raw: []byte(team)},
+		},
+	}
+}
+
+func TestValidateTeam(t *testing.T) {
+	existing := &aftouhv1.Team{
+		ObjectMeta: metav1.ObjectMeta{Name: "poc-dev"},
+		Spec:       aftouhv1.TeamSpec{Name: "poc", Environment: "dev"},
+	}
+
+	tests := []struct {
+		name             string
+		team             string
+		requireNameMatch bool
+		allowed          bool
+		message          string
+	}{
+		{
+			name:    "valid single environment",
+			team:    `{"metadata": {"name": "poc-prod"}, "spec": {"name": "poc", "environment": "prod"}}`,
+			allowed: true,
+		},
+		{
+			name:    "update of the existing team",
+			team:    `{"metadata": {"name": "poc-dev"}, "spec": {"name": "poc", "environment": "dev"}}`,
+			allowed: true,
+		},
+		{
+			name:    "uppercase name",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "Poc", "environment": "prod"}}`,
+			message: "spec.name: Invalid value",
+		},
+		{
+			name:    "missing environment",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc"}}`,
+			message: "spec.environment: Required value",
+		},
+		{
+			name:    "namespace too long",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "` + strings.Repeat("a", 55) + `"}}`,
+			message: "generated namespace",
+		},
+		{
+			name:    "environment used by another team",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environments": [{"name": "prod"}, {"name": "dev"}]}}`,
+			message: `spec.environments[1].name: Duplicate value: "poc/dev is already used by team \"poc-dev\""`,
+		},
+		{
+			name:    "duplicated environment",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environments": [{"name": "prod"}, {"name": "prod"}]}}`,
+			message: `spec.environments[1].name: Duplicate value: "prod"`,
+		},
+		{
+			name:    "negative quota",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "resourceQuota": {"hard": {"pods": "-1"}}}}`,
+			message: "spec.resourceQuota.hard[pods]: Invalid value",
+		},
+		{
+			name:    "negative limitrange",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "limitRange": {"limits": [{"type": "Container", "defaultRequest": {"cpu": "-1"}}]}}}`,
+			message: "spec.limitRange.limits[0].defaultRequest[cpu]: Invalid value",
+		},
+		{
+			name:    "unknown networkpolicy mode",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "networkPolicy": {"mode": "closed"}}}`,
+			message: `spec.networkPolicy.mode: Unsupported value: "closed"`,
+		},
+		{
+			name:    "invalid allowed namespaces",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "networkPolicy": {"mode": "isolated", "allowedNamespaces": [{"matchExpressions": [{"key": "name", "operator": "In"}]}]}}}`,
+			message: "spec.networkPolicy.allowedNamespaces[0].matchExpressions[0].values: Required value",
+		},
+		{
+			name:    "valid namespace metadata",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "namespaceMetadata": {"labels": {"istio-injection": "enabled"}, "annotations": {"owner": "alice"}}}}`,
+			allowed: true,
+		},
+		{
+			name:    "invalid namespace label",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "namespaceMetadata": {"labels": {"cost center": "platform"}}}}`,
+			message: `spec.namespaceMetadata.labels: Invalid value: "cost center"`,
+		},
+		{
+			name:    "controller namespace label",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "namespaceMetadata": {"labels": {"team": "other"}}}}`,
+			message: "spec.namespaceMetadata.labels[team]: Forbidden: is set by the controller",
+		},
+		{
+			name:    "valid pod security",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "podSecurity": {"enforce": "baseline", "enforceVersion": "v1.25"}, "environments": [{"name": "prod", "podSecurity": {"enforce": "restricted", "warn": "restricted", "warnVersion": "latest"}}]}}`,
+			allowed: true,
+		},
+		{
+			name:    "unknown pod security level",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environments": [{"name": "prod", "podSecurity": {"enforce": "strict"}}]}}`,
+			message: `spec.environments[0].podSecurity.enforce: Unsupported value: "strict"`,
+		},
+		{
+			name:    "invalid pod security version",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "podSecurity": {"audit": "baseline", "auditVersion": "1.25"}}}`,
+			message: `spec.podSecurity.auditVersion: Invalid value: "1.25"`,
+		},
+		{
+			name:    "valid deletion policy",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "deletionPolicy": "Retain"}}`,
+			allowed: true,
+		},
+		{
+			name:    "unknown deletion policy",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "deletionPolicy": "Keep"}}`,
+			message: `spec.deletionPolicy: Unsupported value: "Keep"`,
+		},
+		{
+			name:    "suspended team",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "state": "Suspended"}}`,
+			allowed: true,
+		},
+		{
+			name:    "unknown state",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "state": "Paused"}}`,
+			message: `spec.state: Unsupported value: "Paused"`,
+		},
+		{
+			name:    "team with ttl",
+			team:    `{"metadata": {"name": "poc", "annotations": {"aftouh.io/extend-expiry": "48h"}}, "spec": {"name": "poc", "environment": "prod", "ttl": "72h"}}`,
+			allowed: true,
+		},
+		{
+			name:    "ttl and expiry time",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "ttl": "72h", "expiresAt": "2020-05-01T10:00:00Z"}}`,
+			message: `spec.ttl: Forbidden: may not be set with expiresAt`,
+		},
+		{
+			name:    "negative ttl",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "ttl": "-1h"}}`,
+			message: `spec.ttl: Invalid value: "-1h0m0s": must be positive`,
+		},
+		{
+			name:    "invalid expiry extension",
+			team:    `{"metadata": {"name": "poc", "annotations": {"aftouh.io/extend-expiry": "2 days"}}, "spec": {"name": "poc", "environment": "prod", "expiresAt": "2020-05-01T10:00:00Z"}}`,
+			message: `metadata.annotations[aftouh.io/extend-expiry]: Invalid value: "2 days": must be a positive duration, e.g. 48h`,
+		},
+		{
+			name:    "synced resources",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "syncedResources": [{"kind": "ConfigMap", "namespace": "platform", "name": "ca-bundle"}, {"kind": "Secret", "namespace": "platform", "name": "ca-bundle"}]}}`,
+			allowed: true,
+		},
+		{
+			name:    "unsupported synced resource kind",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "syncedResources": [{"kind": "Deployment", "namespace": "platform", "name": "proxy"}]}}`,
+			message: `spec.syncedResources[0].kind: Unsupported value: "Deployment"`,
+		},
+		{
+			name:    "synced resource of a namespace not allowed",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "syncedResources": [{"kind": "Secret", "namespace": "kube-system", "name": "admin-token"}]}}`,
+			message: `spec.syncedResources[0].namespace: Forbidden: resources of namespace "kube-system" may not be synced, allowed namespaces: [platform, network]`,
+		},
+		{
+			name:    "synced resources with the same name",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "syncedResources": [{"kind": "ConfigMap", "namespace": "platform", "name": "proxy"}, {"kind": "ConfigMap", "namespace": "network", "name": "proxy"}]}}`,
+			message: `spec.syncedResources[1].name: Duplicate value: "proxy"`,
+		},
+		{
+			name:    "valid namespace migration",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "namespaceMigration": {"policy": "Copy", "resources": ["ConfigMap", "Deployment"]}}}`,
+			allowed: true,
+		},
+		{
+			name:    "unknown namespace migration policy",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "namespaceMigration": {"policy": "Move"}}}`,
+			message: `spec.namespaceMigration.policy: Unsupported value: "Move"`,
+		},
+		{
+			name:    "unsupported namespace migration resource",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "namespaceMigration": {"policy": "Copy", "resources": ["StatefulSet"]}}}`,
+			message: `spec.namespaceMigration.resources[0]: Unsupported value: "StatefulSet"`,
+		},
+		{
+			name:    "duplicated namespace migration resource",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "namespaceMigration": {"resources": ["Secret", "Secret"]}}}`,
+			message: `spec.namespaceMigration.resources[1]: Duplicate value: "Secret"`,
+		},
+		{
+			name:    "valid quota alerts",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "quotaAlerts": {"warning": 70, "critical": 90}}}`,
+			allowed: true,
+		},
+		{
+			name:    "quota alert above 100",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "quotaAlerts": {"critical": 120}}}`,
+			message: `spec.quotaAlerts.critical: Invalid value: 120: must be a percentage between 0 and 100`,
+		},
+		{
+			name:    "warning above critical",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "quotaAlerts": {"warning": 90, "critical": 80}}}`,
+			message: `spec.quotaAlerts.warning: Invalid value: 90: must be lower than the critical threshold`,
+		},
+		{
+			name:    "valid department parent",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "parent": {"kind": "Department", "name": "engineering"}, "budget": {"requests.cpu": "8"}}}`,
+			allowed: true,
+		},
+		{
+			name:    "unsupported parent kind",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "parent": {"kind": "Namespace", "name": "engineering"}}}`,
+			message: `spec.parent.kind: Unsupported value: "Namespace"`,
+		},
+		{
+			name:    "team parent of itself",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "parent": {"kind": "Team", "name": "poc"}}}`,
+			message: `spec.parent.name: Invalid value: "poc": a team cannot be its own parent`,
+		},
+		{
+			name:    "negative budget",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "budget": {"requests.cpu": "-1"}}}`,
+			message: `spec.budget[requests.cpu]: Invalid value: "-1": must be greater than or equal to 0`,
+		},
+		{
+			name:    "valid scoped quotas",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environments": [{"name": "prod", "resourceQuotas": [{"name": "best-effort", "hard": {"pods": "2"}, "scopes": ["BestEffort"]}]}]}}`,
+			allowed: true,
+		},
+		{
+			name:    "duplicated quota",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "resourceQuotas": [{"name": "objects"}, {"name": "objects"}]}}`,
+			message: `spec.resourceQuotas[1].name: Duplicate value: "objects"`,
+		},
+		{
+			name:    "negative scoped quota",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environments": [{"name": "prod", "resourceQuotas": [{"name": "best-effort", "hard": {"pods": "-2"}}]}]}}`,
+			message: "spec.environments[0].resourceQuotas[0].hard[pods]: Invalid value",
+		},
+		{
+			name:    "invalid quantity",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "resourceQuota": {"hard": {"pods": "four"}}}}`,
+			message: "invalid team",
+		},
+		{
+			name:    "valid members",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "members": [{"kind": "User", "name": "alice@example.com", "role": "admin"}, {"kind": "ServiceAccount", "name": "deployer", "namespace": "ci", "role": "edit"}]}}`,
+			allowed: true,
+		},
+		{
+			name:    "unknown member kind",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "members": [{"kind": "Robot", "name": "r2d2", "role": "view"}]}}`,
+			message: `spec.members[0].kind: Unsupported value: "Robot"`,
+		},
+		{
+			name:    "member role not allowed",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "members": [{"kind": "User", "name": "alice", "role": "cluster-admin"}]}}`,
+			message: `spec.members[0].role: Unsupported value: "cluster-admin": supported values: "admin", "edit", "view"`,
+		},
+		{
+			name:    "member without role",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "prod", "members": [{"kind": "Group", "name": "developers"}]}}`,
+			message: "spec.members[0].role: Required value",
+		},
+		{
+			name:             "name mismatch",
+			team:             `{"metadata": {"name": "poc-prod"}, "spec": {"name": "poc", "environment": "prod"}}`,
+			requireNameMatch: true,
+			message:          "spec.name: Invalid value: \"poc\": must match metadata.name",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewValidationHandler(newTeamLister(existing), testNamespace, test.requireNameMatch, []string{"platform", "network"},
+				[]string{"admin", "edit", "view"})
+
+			var resp admissionv1beta1.AdmissionReview
+			if code := postReview(t, handler, admissionReview(admissionv1beta1.Create, test.team), &resp); code != http.StatusOK {
+				t.Fatalf("expected status code 200, got %d", code)
+			}
+			if resp.Response == nil {
+				t.Fatal("expected admission response")
+			}
+			if resp.Response.Allowed != test.allowed {
+				t.Fatalf("expected allowed %v, got %v: %+v", test.allowed, resp.Response.Allowed, resp.Response.Result)
+			}
+			if !test.allowed && !strings.Contains(resp.Response.Result.Message, test.message) {
+				t.Errorf("expected message containing %q, got %q", test.message, resp.Response.Result.Message)
+			}
+		})
+	}
+}
+
+func TestValidateTeamUpdate(t *testing.T) {
+	//kube-system is no longer an allowed synced namespace
+	old := `{"metadata": {"name": "poc", "finalizers": ["aftouh.io/team"]}, "spec": {"name": "poc", "environment": "dev", "syncedResources": [{"kind": "Secret", "namespace": "kube-system", "name": "creds"}]}}`
+
+	tests := []struct {
+		name    string
+		team    string
+		allowed bool
+		message string
+	}{
+		{
+			name:    "finalizer removal",
+			team:    `{"metadata": {"name": "poc"}, "spec": {"name": "poc", "environment": "dev", "syncedResources": [{"kind": "Secret", "namespace": "kube-system", "name": "creds"}]}}`,
+			allowed: true,
+		},
+		{
+			name:    "deleted team",
+			team:    `{"metadata": {"name": "poc", "deletionTimestamp": "2020-05-01T10:00:00Z"}, "spec": {"name": "poc", "environment": "prod", "syncedResources": [{"kind": "Secret", "namespace": "kube-system", "name": "creds"}]}}`,
+			allowed: true,
+		},
+		{
+			name:    "spec change",
+			team:    `{"metadata": {"name": "poc", "finalizers": ["aftouh.io/team"]}, "spec": {"name": "poc", "environment": "prod", "syncedResources": [{"kind": "Secret", "namespace": "kube-system", "name": "creds"}]}}`,
+			message: `spec.syncedResources[0].namespace`,
+		},
+		{
+			name:    "invalid annotation without spec change",
+			team:    `{"metadata": {"name": "poc", "annotations": {"aftouh.io/extend-expiry": "tomorrow"}}, "spec": {"name": "poc", "environment": "dev", "syncedResources": [{"kind": "Secret", "namespace": "kube-system", "name": "creds"}]}}`,
+			message: `metadata.annotations[aftouh.io/extend-expiry]: Invalid value: "tomorrow"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewValidationHandler(newTeamLister(), testNamespace, false, []string{"platform"}, []string{"admin", "edit", "view"})
+			review := admissionReview(admissionv1beta1.Update, test.team)
+			review.Request.OldObject = runtime.RawExtension{Raw: []byte(old)}
+
+			var resp admissionv1beta1.AdmissionReview
+			if code := postReview(t, handler, review, &resp); code != http.StatusOK {
+				t.Fatalf("expected status code 200, got %d", code)
+			}
+			if resp.Response.Allowed != test.allowed {
+				t.Fatalf("expected allowed %v, got %v: %+v", test.allowed, resp.Response.Allowed, resp.Response.Result)
+			}
+			if !test.allowed && !strings.Contains(resp.Response.Result.Message, test.message) {
+				t.Errorf("expected message containing %q, got %q", test.message, resp.Response.Result.Message)
+			}
+		})
+	}
+}